@@ -0,0 +1,303 @@
+// Package bench load tests an HTTP target with bounded concurrency and an
+// optional request rate, collecting latency percentiles and a histogram.
+package bench
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxRPS is the highest request rate a run can be limited to, the ticker
+// pacing requests cannot tick faster than once per nanosecond.
+const MaxRPS = 1e9
+
+type Options struct {
+	URL     string
+	Method  string
+	Headers http.Header
+	Body    []byte
+	// Concurrency is the number of workers sending requests
+	Concurrency int
+	// RPS caps the overall request rate up to MaxRPS, 0 for as fast as
+	// possible
+	RPS      float64
+	Duration time.Duration
+	Timeout  time.Duration
+	Client   *http.Client
+}
+
+// Bounds are the upper bounds of the latency histogram buckets, the last
+// bucket collects everything above them.
+var Bounds = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+}
+
+type Bucket struct {
+	// UpperBound is 0 for the overflow bucket
+	UpperBound time.Duration
+	Count      int
+}
+
+// Snapshot is the state of a run at some point in time.
+type Snapshot struct {
+	Elapsed     time.Duration
+	Requests    int
+	Errors      int
+	StatusCodes map[int]int
+	Throughput  float64
+	ErrorRate   float64
+	Min         time.Duration
+	Mean        time.Duration
+	P50         time.Duration
+	P90         time.Duration
+	P99         time.Duration
+	Max         time.Duration
+	Histogram   []Bucket
+	// LastError is the most recent transport error, if any
+	LastError string
+}
+
+// Runner drives a single run. It is safe to take snapshots while it runs.
+type Runner struct {
+	opts Options
+
+	mu        sync.Mutex
+	started   time.Time
+	finished  time.Time
+	latencies []time.Duration
+	errors    int
+	codes     map[int]int
+	lastErr   string
+
+	done chan struct{}
+}
+
+func NewRunner(opts Options) *Runner {
+	if opts.Method == "" {
+		opts.Method = http.MethodGet
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+
+	return &Runner{
+		opts:  opts,
+		codes: map[int]int{},
+		done:  make(chan struct{}),
+	}
+}
+
+func (r *Runner) Options() Options {
+	return r.opts
+}
+
+// Start runs the benchmark in the background until the duration passes or
+// ctx is cancelled.
+func (r *Runner) Start(ctx context.Context) {
+	r.mu.Lock()
+	r.started = time.Now()
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.opts.Duration)
+
+	var tokens <-chan time.Time
+	if r.opts.RPS > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / r.opts.RPS))
+		tokens = ticker.C
+		go func() {
+			<-ctx.Done()
+			ticker.Stop()
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx, tokens)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+
+		r.mu.Lock()
+		r.finished = time.Now()
+		r.mu.Unlock()
+
+		close(r.done)
+	}()
+}
+
+// Run runs the benchmark and waits for it to finish.
+func (r *Runner) Run(ctx context.Context) Snapshot {
+	r.Start(ctx)
+	<-r.done
+
+	return r.Snapshot()
+}
+
+func (r *Runner) Done() <-chan struct{} {
+	return r.done
+}
+
+func (r *Runner) work(ctx context.Context, tokens <-chan time.Time) {
+	for {
+		if tokens != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tokens:
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+
+		code, latency, err := r.send(ctx)
+		if ctx.Err() != nil {
+			// Requests cut short by the end of the run are not counted
+			return
+		}
+
+		r.record(code, latency, err)
+	}
+}
+
+func (r *Runner) send(ctx context.Context) (int, time.Duration, error) {
+	var body io.Reader
+	if r.opts.Body != nil {
+		body = bytes.NewReader(r.opts.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.opts.Method, r.opts.URL, body)
+	if err != nil {
+		return 0, 0, err
+	}
+	for key, values := range r.opts.Headers {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	start := time.Now()
+	resp, err := r.opts.Client.Do(req)
+	if err != nil {
+		return 0, time.Since(start), err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, time.Since(start), err
+}
+
+func (r *Runner) record(code int, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latencies = append(r.latencies, latency)
+	if err != nil {
+		r.errors++
+		r.lastErr = err.Error()
+		return
+	}
+
+	r.codes[code]++
+	if code >= 400 {
+		r.errors++
+	}
+}
+
+func (r *Runner) Snapshot() Snapshot {
+	r.mu.Lock()
+	latencies := append([]time.Duration{}, r.latencies...)
+	codes := make(map[int]int, len(r.codes))
+	for code, n := range r.codes {
+		codes[code] = n
+	}
+	s := Snapshot{
+		Requests:    len(latencies),
+		Errors:      r.errors,
+		StatusCodes: codes,
+		LastError:   r.lastErr,
+	}
+
+	end := r.finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if !r.started.IsZero() {
+		s.Elapsed = end.Sub(r.started)
+	}
+	r.mu.Unlock()
+
+	if s.Elapsed > 0 {
+		s.Throughput = float64(s.Requests) / s.Elapsed.Seconds()
+	}
+	if s.Requests > 0 {
+		s.ErrorRate = float64(s.Errors) / float64(s.Requests)
+	}
+
+	s.Histogram = histogram(latencies)
+	if len(latencies) == 0 {
+		return s
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+
+	s.Min = latencies[0]
+	s.Max = latencies[len(latencies)-1]
+	s.Mean = total / time.Duration(len(latencies))
+	s.P50 = percentile(latencies, 50)
+	s.P90 = percentile(latencies, 90)
+	s.P99 = percentile(latencies, 99)
+
+	return s
+}
+
+// percentile uses the nearest-rank method on sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p/100*float64(len(sorted))+0.999999) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+
+	return sorted[rank]
+}
+
+func histogram(latencies []time.Duration) []Bucket {
+	buckets := make([]Bucket, len(Bounds)+1)
+	for i, b := range Bounds {
+		buckets[i].UpperBound = b
+	}
+
+	for _, l := range latencies {
+		i := sort.Search(len(Bounds), func(i int) bool { return l <= Bounds[i] })
+		buckets[i].Count++
+	}
+
+	return buckets
+}
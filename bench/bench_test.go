@@ -0,0 +1,168 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunCountsRequestsAndErrors(t *testing.T) {
+	var served atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served.Add(1)%2 == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewRunner(Options{
+		URL:         srv.URL,
+		Concurrency: 4,
+		Duration:    300 * time.Millisecond,
+	}).Run(context.Background())
+
+	if s.Requests == 0 {
+		t.Fatal("no requests were recorded")
+	}
+	if s.Requests != s.StatusCodes[200]+s.StatusCodes[500] {
+		t.Errorf("requests = %d, status codes = %v", s.Requests, s.StatusCodes)
+	}
+	if s.Errors != s.StatusCodes[500] {
+		t.Errorf("errors = %d, want %d", s.Errors, s.StatusCodes[500])
+	}
+	if s.P50 > s.P90 || s.P90 > s.P99 || s.P99 > s.Max {
+		t.Errorf("percentiles out of order: p50 %s p90 %s p99 %s max %s", s.P50, s.P90, s.P99, s.Max)
+	}
+
+	total := 0
+	for _, b := range s.Histogram {
+		total += b.Count
+	}
+	if total != s.Requests {
+		t.Errorf("histogram holds %d requests, want %d", total, s.Requests)
+	}
+}
+
+func TestRunHonorsRPS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	s := NewRunner(Options{
+		URL:         srv.URL,
+		Concurrency: 8,
+		RPS:         50,
+		Duration:    time.Second,
+	}).Run(context.Background())
+
+	if s.Requests < 40 || s.Requests > 55 {
+		t.Errorf("requests = %d, want about 50", s.Requests)
+	}
+}
+
+func TestRunSendsRequest(t *testing.T) {
+	type seen struct {
+		method, header, body string
+	}
+	got := make(chan seen, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+		select {
+		case got <- seen{r.Method, r.Header.Get("X-Test"), body.String()}:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	NewRunner(Options{
+		URL:         srv.URL,
+		Method:      http.MethodPost,
+		Headers:     http.Header{"X-Test": {"yes"}},
+		Body:        []byte("payload"),
+		Concurrency: 1,
+		Duration:    50 * time.Millisecond,
+	}).Run(context.Background())
+
+	want := seen{http.MethodPost, "yes", "payload"}
+	if s := <-got; s != want {
+		t.Errorf("got request %+v, want %+v", s, want)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	for p, want := range map[float64]time.Duration{
+		50: 50 * time.Millisecond,
+		90: 90 * time.Millisecond,
+		99: 99 * time.Millisecond,
+	} {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("p%g = %s, want %s", p, got, want)
+		}
+	}
+}
+
+func TestReportFormats(t *testing.T) {
+	s := Snapshot{
+		Elapsed:     time.Second,
+		Requests:    3,
+		Errors:      1,
+		StatusCodes: map[int]int{200: 2, 502: 1},
+		P50:         2 * time.Millisecond,
+		Histogram:   histogram([]time.Duration{time.Millisecond, 3 * time.Millisecond, 10 * time.Second}),
+	}
+	r := NewReport(Options{URL: "http://gw/hello", Method: "GET", Concurrency: 2}, s)
+
+	var j bytes.Buffer
+	if err := r.WriteJSON(&j); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(j.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Latency.P50 != 2 || decoded.StatusCodes[502] != 1 {
+		t.Errorf("unexpected JSON report: %s", j.String())
+	}
+	if last := decoded.Histogram[len(decoded.Histogram)-1]; last.LE != "+Inf" || last.Count != 1 {
+		t.Errorf("overflow bucket = %+v", last)
+	}
+
+	var c bytes.Buffer
+	if err := r.WriteCSV(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&c).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]string{}
+	for _, row := range rows {
+		values[row[0]] = row[1]
+	}
+	for key, want := range map[string]string{
+		"requests":          "3",
+		"status_502":        "1",
+		"latency_p50_ms":    "2",
+		"histogram_le_1":    "1",
+		"histogram_le_5":    "1",
+		"histogram_le_+Inf": "1",
+	} {
+		if values[key] != want {
+			t.Errorf("%s = %q, want %q", key, values[key], want)
+		}
+	}
+}
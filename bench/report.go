@@ -0,0 +1,134 @@
+package bench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Report is the exportable summary of a finished run. Latencies are in
+// milliseconds.
+type Report struct {
+	URL         string         `json:"url"`
+	Method      string         `json:"method"`
+	Concurrency int            `json:"concurrency"`
+	RPS         float64        `json:"rps"`
+	Duration    float64        `json:"duration_seconds"`
+	Requests    int            `json:"requests"`
+	Errors      int            `json:"errors"`
+	StatusCodes map[int]int    `json:"status_codes"`
+	Throughput  float64        `json:"throughput"`
+	ErrorRate   float64        `json:"error_rate"`
+	Latency     LatencyReport  `json:"latency_ms"`
+	Histogram   []BucketReport `json:"histogram"`
+}
+
+type LatencyReport struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+type BucketReport struct {
+	// LE is the bucket upper bound in milliseconds, "+Inf" for the last one
+	LE    string `json:"le"`
+	Count int    `json:"count"`
+}
+
+func NewReport(opts Options, s Snapshot) Report {
+	r := Report{
+		URL:         opts.URL,
+		Method:      opts.Method,
+		Concurrency: opts.Concurrency,
+		RPS:         opts.RPS,
+		Duration:    s.Elapsed.Seconds(),
+		Requests:    s.Requests,
+		Errors:      s.Errors,
+		StatusCodes: s.StatusCodes,
+		Throughput:  s.Throughput,
+		ErrorRate:   s.ErrorRate,
+		Latency: LatencyReport{
+			Min:  ms(s.Min),
+			Mean: ms(s.Mean),
+			P50:  ms(s.P50),
+			P90:  ms(s.P90),
+			P99:  ms(s.P99),
+			Max:  ms(s.Max),
+		},
+	}
+
+	for _, b := range s.Histogram {
+		le := "+Inf"
+		if b.UpperBound > 0 {
+			le = strconv.FormatFloat(ms(b.UpperBound), 'f', -1, 64)
+		}
+		r.Histogram = append(r.Histogram, BucketReport{LE: le, Count: b.Count})
+	}
+
+	return r
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (r Report) WriteJSON(w io.Writer) error {
+	j, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(j))
+	return err
+}
+
+// WriteCSV writes the report as metric,value rows.
+func (r Report) WriteCSV(w io.Writer) error {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+	rows := [][]string{
+		{"metric", "value"},
+		{"url", r.URL},
+		{"method", r.Method},
+		{"concurrency", strconv.Itoa(r.Concurrency)},
+		{"rps", f(r.RPS)},
+		{"duration_seconds", f(r.Duration)},
+		{"requests", strconv.Itoa(r.Requests)},
+		{"errors", strconv.Itoa(r.Errors)},
+		{"throughput", f(r.Throughput)},
+		{"error_rate", f(r.ErrorRate)},
+		{"latency_min_ms", f(r.Latency.Min)},
+		{"latency_mean_ms", f(r.Latency.Mean)},
+		{"latency_p50_ms", f(r.Latency.P50)},
+		{"latency_p90_ms", f(r.Latency.P90)},
+		{"latency_p99_ms", f(r.Latency.P99)},
+		{"latency_max_ms", f(r.Latency.Max)},
+	}
+
+	codes := make([]int, 0, len(r.StatusCodes))
+	for code := range r.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		rows = append(rows, []string{fmt.Sprintf("status_%d", code), strconv.Itoa(r.StatusCodes[code])})
+	}
+
+	for _, b := range r.Histogram {
+		rows = append(rows, []string{fmt.Sprintf("histogram_le_%s", b.LE), strconv.Itoa(b.Count)})
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
@@ -0,0 +1,348 @@
+// Package bundle exports the resources of a server into a portable tar
+// archive: a manifest of every runtime, lambda and endpoint, along with the
+// Dockerfiles and lambda archives that could be rebuilt from the local
+// history, and a checksum of every file.
+package bundle
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/ops"
+	"github.com/onpremless/opcli/vcs"
+)
+
+const (
+	// Version is bumped whenever the layout of bundles changes
+	// incompatibly.
+	Version = 1
+
+	ManifestFile  = "manifest.json"
+	ChecksumsFile = "SHA256SUMS"
+)
+
+const (
+	KindDockerfile = "dockerfile"
+	KindContext    = "context"
+	KindLambda     = "lambda"
+)
+
+// Artifact is a file of the bundle a resource can be recreated from.
+type Artifact struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type Runtime struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	CreatedAt  int64             `json:"created_at"`
+	UpdatedAt  int64             `json:"updated_at"`
+	Source     string            `json:"source,omitempty"`
+	Dockerfile string            `json:"dockerfile,omitempty"`
+	BuildArgs  map[string]string `json:"build_args,omitempty"`
+	Git        *vcs.Info         `json:"git,omitempty"`
+	Artifact   *Artifact         `json:"artifact,omitempty"`
+	// Missing tells why there is no artifact
+	Missing string `json:"missing,omitempty"`
+}
+
+type Lambda struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Runtime    string    `json:"runtime"`
+	LambdaType string    `json:"lambda_type"`
+	Status     string    `json:"status,omitempty"`
+	CreatedAt  int64     `json:"created_at"`
+	UpdatedAt  int64     `json:"updated_at"`
+	Source     string    `json:"source,omitempty"`
+	Digest     string    `json:"digest,omitempty"`
+	Git        *vcs.Info `json:"git,omitempty"`
+	Artifact   *Artifact `json:"artifact,omitempty"`
+	Missing    string    `json:"missing,omitempty"`
+}
+
+type Endpoint struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Lambda string `json:"lambda"`
+}
+
+type Manifest struct {
+	Version   int        `json:"version"`
+	Server    string     `json:"server"`
+	CreatedAt time.Time  `json:"created_at"`
+	Runtimes  []Runtime  `json:"runtimes"`
+	Lambdas   []Lambda   `json:"lambdas"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// Missing counts the runtimes and lambdas exported without an artifact.
+func (m *Manifest) Missing() int {
+	n := 0
+	for _, rt := range m.Runtimes {
+		if rt.Artifact == nil {
+			n++
+		}
+	}
+	for _, l := range m.Lambdas {
+		if l.Artifact == nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Lister lists the resources of a server, implemented by ops.Client.
+type Lister interface {
+	ListRuntimes(ctx context.Context) ([]api.Runtime, error)
+	ListLambdas(ctx context.Context) ([]api.Lambda, error)
+	ListEndpoints(ctx context.Context) ([]api.Endpoint, error)
+}
+
+// file is an artifact packaged to a temporary file until the bundle is
+// written.
+type file struct {
+	artifact *Artifact
+	tmp      string
+}
+
+// Export writes a bundle of every resource of server to w. The server API
+// only hands out metadata, so artifacts are packaged again from the sources
+// recorded in the local history, as long as they did not change since.
+// Resources without an artifact are still listed in the manifest, with the
+// reason in Missing.
+func Export(ctx context.Context, lister Lister, store *history.Store, server string, w io.Writer) (*Manifest, error) {
+	runtimes, err := lister.ListRuntimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lambdas, err := lister.ListLambdas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := lister.ListEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	builds, err := store.Runtimes(server)
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := store.Lambdas(server)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Version:   Version,
+		Server:    server,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Runtimes:  []Runtime{},
+		Lambdas:   []Lambda{},
+		Endpoints: []Endpoint{},
+	}
+
+	files := []file{}
+	defer func() {
+		for _, f := range files {
+			os.Remove(f.tmp)
+		}
+	}()
+
+	for _, rt := range runtimes {
+		r := Runtime{ID: rt.Id, Name: rt.Name, CreatedAt: rt.CreatedAt, UpdatedAt: rt.UpdatedAt}
+
+		b, ok := builds[rt.Id]
+		if !ok {
+			r.Missing = "not built from this machine"
+			m.Runtimes = append(m.Runtimes, r)
+			continue
+		}
+		r.Source, r.Dockerfile, r.BuildArgs, r.Git = b.Source, b.Dockerfile, b.BuildArgs, b.Git
+
+		f, err := packageRuntime(b)
+		if err != nil {
+			r.Missing = err.Error()
+		} else {
+			r.Artifact = f.artifact
+			files = append(files, f)
+		}
+		m.Runtimes = append(m.Runtimes, r)
+	}
+
+	for _, l := range lambdas {
+		e := Lambda{
+			ID:         l.Id,
+			Name:       l.Name,
+			Runtime:    l.Runtime,
+			LambdaType: l.LambdaType,
+			Status:     l.Docker.Status,
+			CreatedAt:  l.CreatedAt,
+			UpdatedAt:  l.UpdatedAt,
+		}
+
+		d, ok := deployments[l.Id]
+		if !ok {
+			e.Missing = "not deployed from this machine"
+			m.Lambdas = append(m.Lambdas, e)
+			continue
+		}
+		e.Source, e.Digest, e.Git = d.Source, d.Digest, d.Git
+
+		f, err := packageLambda(d)
+		if err != nil {
+			e.Missing = err.Error()
+		} else {
+			e.Artifact = f.artifact
+			files = append(files, f)
+		}
+		m.Lambdas = append(m.Lambdas, e)
+	}
+
+	for _, e := range endpoints {
+		m.Endpoints = append(m.Endpoints, Endpoint{ID: e.Id, Name: e.Name, Path: e.Path, Lambda: e.Lambda})
+	}
+
+	if err := write(w, m, files); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func packageRuntime(b history.RuntimeBuild) (file, error) {
+	if err := b.CheckSources(); err != nil {
+		return file{}, err
+	}
+
+	tmp, err := ops.PackageRuntime(ops.CreateRuntimeM{Name: b.Name, Dockerfile: b.Dockerfile, BuildArgs: b.BuildArgs}, b.Source)
+	if err != nil {
+		return file{}, err
+	}
+
+	kind, path := KindDockerfile, "runtimes/"+b.RuntimeID+"/Dockerfile"
+	if info, err := os.Stat(b.Source); err == nil && info.IsDir() {
+		kind, path = KindContext, "runtimes/"+b.RuntimeID+"/context.tar"
+	}
+
+	return newFile(tmp, kind, path)
+}
+
+func packageLambda(d history.Deployment) (file, error) {
+	if err := d.CheckSources(); err != nil {
+		return file{}, err
+	}
+
+	tmp, err := ops.PackageLambda(d.Source)
+	if err != nil {
+		return file{}, err
+	}
+
+	return newFile(tmp, KindLambda, "lambdas/"+d.LambdaID+".tar")
+}
+
+func newFile(tmp string, kind string, path string) (file, error) {
+	sum, size, err := checksum(tmp)
+	if err != nil {
+		os.Remove(tmp)
+		return file{}, err
+	}
+
+	return file{tmp: tmp, artifact: &Artifact{Path: path, Kind: kind, Size: size, SHA256: sum}}, nil
+}
+
+func checksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// write lays the bundle out as the manifest, the artifacts in the order of
+// the manifest, then the checksums of all of them in the sha256sum format.
+func write(w io.Writer, m *Manifest, files []file) error {
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifest = append(manifest, '\n')
+
+	tw := tar.NewWriter(w)
+	header := func(name string, size int64) *tar.Header {
+		return &tar.Header{Name: name, Mode: 0644, Size: size, ModTime: m.CreatedAt, Format: tar.FormatPAX}
+	}
+
+	if err := tw.WriteHeader(header(ManifestFile, int64(len(manifest)))); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+
+	manifestSum := sha256.Sum256(manifest)
+	sums := map[string]string{ManifestFile: hex.EncodeToString(manifestSum[:])}
+
+	for _, f := range files {
+		if err := tw.WriteHeader(header(f.artifact.Path, f.artifact.Size)); err != nil {
+			return err
+		}
+
+		content, err := os.Open(f.tmp)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+
+		sums[f.artifact.Path] = f.artifact.SHA256
+	}
+
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", sums[name], name)
+	}
+
+	if err := tw.WriteHeader(header(ChecksumsFile, int64(b.Len()))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(tw, b.String()); err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
@@ -0,0 +1,371 @@
+package bundle_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/bundle"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/opcsrv/fake"
+	"github.com/onpremless/opcli/ops"
+)
+
+type server struct {
+	fake   *fake.Server
+	url    string
+	client *ops.Client
+}
+
+func newServer(t *testing.T) *server {
+	t.Helper()
+
+	srv, url, client := fake.NewTestClient(t, fake.Options{})
+
+	return &server{fake: srv, url: url, client: client}
+}
+
+// exportStaging deploys a runtime, a running and a stopped lambda and an
+// endpoint to a server, recording them in the history, and exports them.
+func exportStaging(t *testing.T) (*server, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("OPCLI_CONFIG", filepath.Join(dir, "config.yaml"))
+	store, err := history.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staging := newServer(t)
+	ctx := context.Background()
+
+	fake.WriteFile(t, filepath.Join(dir, "runtime", "Dockerfile"), "FROM alpine\nARG VERSION\n")
+	buildArgs := map[string]string{"VERSION": "1"}
+	rt, err := staging.client.CreateRuntime(ctx, ops.CreateRuntimeM{Name: "shell", BuildArgs: buildArgs}, filepath.Join(dir, "runtime"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := ops.SourceDigest(filepath.Join(dir, "runtime"))
+	store.AddRuntime(history.NewRuntimeBuild(staging.url, rt, filepath.Join(dir, "runtime"), "", buildArgs, digest))
+
+	for _, l := range []struct {
+		name  string
+		ltype string
+		start bool
+	}{{"hello", "ENDPOINT", true}, {"worker", "INTERNAL", false}} {
+		src := filepath.Join(dir, l.name)
+		fake.WriteFile(t, filepath.Join(src, "handler.sh"), "echo "+l.name+"\n")
+
+		create := staging.client.CreateLambda
+		if l.start {
+			create = staging.client.DeployLambda
+		}
+		created, err := create(ctx, ops.CreateLambdaM{Name: l.name, Runtime: rt.Id, LambdaType: l.ltype}, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest, _ := ops.SourceDigest(src)
+		store.Add(history.New(staging.url, created, src, digest))
+
+		if l.name == "hello" {
+			if _, err := staging.client.CreateEndpoint(ctx, &api.CreateEndpoint{Name: "hello", Path: "/hello", Lambda: created.Id}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	path := filepath.Join(dir, "bundle.tar")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	m, err := bundle.Export(ctx, staging.client, store, staging.url, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Missing() != 0 {
+		t.Fatalf("%d artifacts missing from %+v", m.Missing(), m)
+	}
+
+	return staging, path
+}
+
+func TestExportImport(t *testing.T) {
+	staging, path := exportStaging(t)
+	prod := newServer(t)
+	ctx := context.Background()
+
+	b, err := bundle.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	plan, err := bundle.Plan(ctx, prod.client, b.Manifest, bundle.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bundle.Import(ctx, prod.client, b, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]string{}
+	for _, mp := range plan {
+		if mp.Action != bundle.ActionCreate || mp.NewID == "" {
+			t.Errorf("mapping %+v was not created", mp)
+		}
+		ids[mp.OldID] = mp.NewID
+	}
+
+	lambdas := prod.fake.Lambdas()
+	if len(lambdas) != 2 {
+		t.Fatalf("imported lambdas = %+v", lambdas)
+	}
+	for _, l := range lambdas {
+		if l.Runtime != prod.fake.Runtimes()[0].Id {
+			t.Errorf("lambda %s uses runtime %s", l.Name, l.Runtime)
+		}
+
+		want := fake.StatusCreated
+		if l.Name == "hello" {
+			want = fake.StatusRunning
+		}
+		if l.Docker.Status != want {
+			t.Errorf("lambda %s is %s, want %s", l.Name, l.Docker.Status, want)
+		}
+
+		archive, _ := prod.fake.Archive(l.Id)
+		if content := readEntry(t, archive, "handler.sh"); content != "echo "+l.Name+"\n" {
+			t.Errorf("lambda %s imported with handler %q", l.Name, content)
+		}
+	}
+
+	endpoints := prod.fake.Endpoints()
+	if len(endpoints) != 1 || endpoints[0].Path != "/hello" || endpoints[0].Lambda != ids[staging.fake.Endpoints()[0].Lambda] {
+		t.Errorf("imported endpoints = %+v", endpoints)
+	}
+}
+
+// TestExportVerifiesSources exports lambdas whose sources can, or cannot,
+// be packaged as they were deployed, and reads the bundle back.
+func TestExportVerifiesSources(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("OPCLI_CONFIG", filepath.Join(dir, "config.yaml"))
+	store, err := history.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staging := newServer(t)
+	rt := staging.fake.AddRuntime("shell")
+	ctx := context.Background()
+
+	missing := map[string]string{}
+	for _, name := range []string{"kept", "changed", "undigested"} {
+		src := filepath.Join(dir, name)
+		fake.WriteFile(t, filepath.Join(src, "handler.sh"), "echo "+name+"\n")
+
+		l, err := staging.client.CreateLambda(ctx, ops.CreateLambdaM{Name: name, Runtime: rt.Id, LambdaType: "INTERNAL"}, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		digest, err := ops.SourceDigest(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch name {
+		case "changed":
+			fake.WriteFile(t, filepath.Join(src, "handler.sh"), "echo edited\n")
+			missing[l.Id] = "sources in " + src + " changed since they were deployed"
+		case "undigested":
+			digest = ""
+			missing[l.Id] = "no digest recorded for the sources in " + src + ", they cannot be verified"
+		}
+		if err := store.Add(history.New(staging.url, l, src, digest)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, "bundle.tar")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := bundle.Export(ctx, staging.client, store, staging.url, out)
+	out.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := bundle.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	// The runtime was not built from this machine either
+	if b.Manifest.Missing() != 3 || exported.Missing() != 3 {
+		t.Errorf("%d artifacts missing from the bundle, want 3", b.Manifest.Missing())
+	}
+	for _, l := range b.Manifest.Lambdas {
+		if l.Missing != missing[l.ID] {
+			t.Errorf("lambda %s missing = %q, want %q", l.Name, l.Missing, missing[l.ID])
+		}
+		if l.Name != "kept" {
+			continue
+		}
+		if l.Artifact == nil {
+			t.Fatalf("lambda %s exported without its artifact", l.Name)
+		}
+
+		src, err := b.Source(l.Artifact)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if digest, err := ops.SourceDigest(src); err != nil || digest != l.Digest {
+			t.Errorf("unpacked sources digest = %q, %v, want %q", digest, err, l.Digest)
+		}
+	}
+}
+
+func TestImportDryRunAndConflicts(t *testing.T) {
+	_, path := exportStaging(t)
+	prod := newServer(t)
+	existing := prod.fake.AddRuntime("shell")
+	ctx := context.Background()
+
+	b, err := bundle.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	plan, err := bundle.Plan(ctx, prod.client, b.Manifest, bundle.Options{})
+	var conflict *bundle.ConflictError
+	if !errors.As(err, &conflict) || len(conflict.Conflicts) != 1 {
+		t.Fatalf("got %v, want a conflict on the runtime", err)
+	}
+	if plan[0].Action != bundle.ActionSkip || plan[0].NewID != existing.Id {
+		t.Errorf("runtime mapping = %+v", plan[0])
+	}
+	if len(prod.fake.Lambdas()) != 0 {
+		t.Error("planning created lambdas")
+	}
+
+	plan, err = bundle.Plan(ctx, prod.client, b.Manifest, bundle.Options{SkipExisting: true, RenamePrefix: "prod-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mp := range plan {
+		if mp.Action != bundle.ActionCreate || mp.Name[:5] != "prod-" {
+			t.Errorf("mapping %+v, want a prefixed create", mp)
+		}
+	}
+}
+
+func TestOpenRejectsTamperedBundle(t *testing.T) {
+	_, path := exportStaging(t)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewrite the bundle with another handler in the lambda archive
+	var out bytes.Buffer
+	tw := tar.NewWriter(&out)
+	tr := tar.NewReader(bytes.NewReader(content))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		if filepath.Dir(header.Name) == "lambdas" {
+			data = bytes.Replace(data, []byte("echo hello"), []byte("echo HACKED"), 1)
+		}
+		tw.WriteHeader(header)
+		tw.Write(data)
+	}
+	tw.Close()
+
+	tampered := filepath.Join(t.TempDir(), "tampered.tar")
+	if err := os.WriteFile(tampered, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bundle.Open(tampered); err == nil {
+		t.Error("opened a tampered bundle")
+	}
+}
+
+func readEntry(t *testing.T, archive []byte, name string) string {
+	t.Helper()
+
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			t.Fatalf("%s not found in archive: %v", name, err)
+		}
+		if header.Name == name {
+			content, _ := io.ReadAll(tr)
+			return string(content)
+		}
+	}
+}
+
+// TestImportRoutedPaths imports a bundle again under other names: the
+// endpoint path is still routed, which the prefix does not change.
+func TestImportRoutedPaths(t *testing.T) {
+	_, path := exportStaging(t)
+	prod := newServer(t)
+	ctx := context.Background()
+
+	b, err := bundle.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	plan, err := bundle.Plan(ctx, prod.client, b.Manifest, bundle.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bundle.Import(ctx, prod.client, b, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = bundle.Plan(ctx, prod.client, b.Manifest, bundle.Options{RenamePrefix: "copy-"})
+	var conflict *bundle.ConflictError
+	if !errors.As(err, &conflict) || len(conflict.Conflicts) != 0 || len(conflict.Paths) != 1 || conflict.Paths[0] != "/hello" {
+		t.Fatalf("got %v, want a conflict on the /hello path only", err)
+	}
+
+	plan, err = bundle.Plan(ctx, prod.client, b.Manifest, bundle.Options{SkipExisting: true, RenamePrefix: "copy-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mp := range plan {
+		want := bundle.ActionCreate
+		if mp.Kind == bundle.KindEndpoint {
+			want = bundle.ActionUnavailable
+		}
+		if mp.Action != want || mp.NewID != "" {
+			t.Errorf("mapping %+v, want %s", mp, want)
+		}
+	}
+}
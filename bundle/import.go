@@ -0,0 +1,267 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/ops"
+)
+
+const (
+	KindRuntime  = "runtime"
+	KindEndpoint = "endpoint"
+)
+
+const (
+	ActionCreate = "create"
+	// ActionSkip maps the resource to the existing one of the same name
+	ActionSkip = "skip"
+	// ActionUnavailable leaves the resource out, see Mapping.Reason
+	ActionUnavailable = "unavailable"
+)
+
+// Importer recreates resources on a server, implemented by ops.Client.
+type Importer interface {
+	Lister
+	CreateRuntime(ctx context.Context, runtime ops.CreateRuntimeM, path string) (*api.Runtime, error)
+	CreateLambda(ctx context.Context, lambda ops.CreateLambdaM, path string) (*api.Lambda, error)
+	DeployLambda(ctx context.Context, input ops.CreateLambdaM, path string) (*api.Lambda, error)
+	CreateEndpoint(ctx context.Context, req *api.CreateEndpoint) (*api.Endpoint, error)
+}
+
+type Options struct {
+	// SkipExisting maps resources to existing ones of the same name instead
+	// of failing
+	SkipExisting bool
+	// RenamePrefix is prepended to the name of every imported resource
+	RenamePrefix string
+}
+
+// Mapping is what an import does with a resource of the bundle, and the ID
+// it ends up with on the server.
+type Mapping struct {
+	Kind   string
+	Name   string
+	OldID  string
+	NewID  string
+	Action string
+	Reason string
+
+	runtime  *Runtime
+	lambda   *Lambda
+	endpoint *Endpoint
+}
+
+// ConflictError lists the resources that already exist on the server.
+type ConflictError struct {
+	Conflicts []string
+	// Paths lists the endpoint paths routed to other endpoints, which are
+	// not renamed by the prefix
+	Paths []string
+}
+
+func (e *ConflictError) Error() string {
+	parts := []string{}
+	if len(e.Conflicts) > 0 {
+		parts = append(parts, fmt.Sprintf("already on the server: %s", strings.Join(e.Conflicts, ", ")))
+	}
+	if len(e.Paths) > 0 {
+		parts = append(parts, fmt.Sprintf("paths already routed: %s", strings.Join(e.Paths, ", ")))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// Plan decides what importing the bundle does with each of its resources,
+// in dependency order: runtimes, then lambdas, then endpoints. Resources
+// whose name is taken make a ConflictError, unless they are skipped with
+// opts.SkipExisting. Endpoints whose path is routed to another endpoint do
+// too, unless they are left out with opts.SkipExisting. The plan is returned
+// along with the ConflictError so that it can be shown.
+func Plan(ctx context.Context, lister Lister, m *Manifest, opts Options) ([]Mapping, error) {
+	runtimes, err := lister.ListRuntimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lambdas, err := lister.ListLambdas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := lister.ListEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first, so that the newest of duplicate names wins
+	sort.SliceStable(runtimes, func(i, j int) bool { return runtimes[i].CreatedAt > runtimes[j].CreatedAt })
+	sort.SliceStable(lambdas, func(i, j int) bool { return lambdas[i].CreatedAt > lambdas[j].CreatedAt })
+
+	runtimeByName := map[string]string{}
+	for _, rt := range runtimes {
+		if _, ok := runtimeByName[rt.Name]; !ok {
+			runtimeByName[rt.Name] = rt.Id
+		}
+	}
+	lambdaByName := map[string]string{}
+	for _, l := range lambdas {
+		if _, ok := lambdaByName[l.Name]; !ok {
+			lambdaByName[l.Name] = l.Id
+		}
+	}
+	endpointByName, endpointByPath := map[string]string{}, map[string]string{}
+	for _, e := range endpoints {
+		endpointByName[e.Name] = e.Id
+		endpointByPath[e.Path] = e.Id
+	}
+
+	plan := []Mapping{}
+	conflicts, paths := []string{}, []string{}
+	// available holds the old IDs of the resources the import provides
+	available := map[string]bool{}
+	names := map[string]string{}
+
+	existing := func(mp *Mapping, id string) {
+		if !opts.SkipExisting {
+			conflicts = append(conflicts, mp.Kind+" "+mp.Name)
+		}
+		mp.Action = ActionSkip
+		mp.NewID = id
+		mp.Reason = "already exists"
+	}
+
+	for i := range m.Runtimes {
+		rt := &m.Runtimes[i]
+		mp := Mapping{Kind: KindRuntime, Name: opts.RenamePrefix + rt.Name, OldID: rt.ID, Action: ActionCreate, runtime: rt}
+
+		if id, ok := runtimeByName[mp.Name]; ok {
+			existing(&mp, id)
+		} else if rt.Artifact == nil {
+			mp.Action, mp.Reason = ActionUnavailable, rt.Missing
+		}
+
+		available[rt.ID] = mp.Action != ActionUnavailable
+		names[rt.ID] = mp.Name
+		plan = append(plan, mp)
+	}
+
+	for i := range m.Lambdas {
+		l := &m.Lambdas[i]
+		mp := Mapping{Kind: KindLambda, Name: opts.RenamePrefix + l.Name, OldID: l.ID, Action: ActionCreate, lambda: l}
+
+		if id, ok := lambdaByName[mp.Name]; ok {
+			existing(&mp, id)
+		} else if ok, known := available[l.Runtime]; !known {
+			mp.Action, mp.Reason = ActionUnavailable, fmt.Sprintf("runtime %s is not in the bundle", l.Runtime)
+		} else if !ok {
+			mp.Action, mp.Reason = ActionUnavailable, fmt.Sprintf("runtime %s is unavailable", names[l.Runtime])
+		} else if l.Artifact == nil {
+			mp.Action, mp.Reason = ActionUnavailable, l.Missing
+		}
+
+		available[l.ID] = mp.Action != ActionUnavailable
+		names[l.ID] = mp.Name
+		plan = append(plan, mp)
+	}
+
+	for i := range m.Endpoints {
+		e := &m.Endpoints[i]
+		mp := Mapping{Kind: KindEndpoint, Name: opts.RenamePrefix + e.Name, OldID: e.ID, Action: ActionCreate, endpoint: e}
+
+		if id, ok := endpointByName[mp.Name]; ok {
+			existing(&mp, id)
+		} else if _, ok := endpointByPath[e.Path]; ok {
+			if !opts.SkipExisting {
+				paths = append(paths, e.Path)
+			}
+			mp.Action, mp.Reason = ActionUnavailable, fmt.Sprintf("path %s is taken", e.Path)
+		} else if ok, known := available[e.Lambda]; !known {
+			mp.Action, mp.Reason = ActionUnavailable, fmt.Sprintf("lambda %s is not in the bundle", e.Lambda)
+		} else if !ok {
+			mp.Action, mp.Reason = ActionUnavailable, fmt.Sprintf("lambda %s is unavailable", names[e.Lambda])
+		}
+
+		plan = append(plan, mp)
+	}
+
+	if len(conflicts) > 0 || len(paths) > 0 {
+		return plan, &ConflictError{Conflicts: conflicts, Paths: paths}
+	}
+
+	return plan, nil
+}
+
+// Import carries the plan out in order, filling in the new IDs. Lambdas that
+// were running when exported are started. It stops at the first failure,
+// leaving what was created so far in place.
+func Import(ctx context.Context, client Importer, b *Bundle, plan []Mapping) error {
+	ids := map[string]string{}
+	for _, mp := range plan {
+		if mp.Action == ActionSkip {
+			ids[mp.OldID] = mp.NewID
+		}
+	}
+
+	for i := range plan {
+		mp := &plan[i]
+		if mp.Action != ActionCreate {
+			continue
+		}
+
+		id, err := create(ctx, client, b, mp, ids)
+		if err != nil {
+			return fmt.Errorf("importing %s %s: %w", mp.Kind, mp.Name, err)
+		}
+
+		mp.NewID = id
+		ids[mp.OldID] = id
+	}
+
+	return nil
+}
+
+func create(ctx context.Context, client Importer, b *Bundle, mp *Mapping, ids map[string]string) (string, error) {
+	switch mp.Kind {
+	case KindRuntime:
+		src, err := b.Source(mp.runtime.Artifact)
+		if err != nil {
+			return "", err
+		}
+
+		// Build args were applied to the exported Dockerfile
+		rt, err := client.CreateRuntime(ctx, ops.CreateRuntimeM{Name: mp.Name}, src)
+		if err != nil {
+			return "", err
+		}
+		return rt.Id, nil
+
+	case KindLambda:
+		src, err := b.Source(mp.lambda.Artifact)
+		if err != nil {
+			return "", err
+		}
+
+		input := ops.CreateLambdaM{Name: mp.Name, Runtime: ids[mp.lambda.Runtime], LambdaType: mp.lambda.LambdaType}
+		create := client.CreateLambda
+		if mp.lambda.Status == "RUNNING" {
+			create = client.DeployLambda
+		}
+
+		l, err := create(ctx, input, src)
+		if err != nil {
+			return "", err
+		}
+		return l.Id, nil
+
+	case KindEndpoint:
+		e, err := client.CreateEndpoint(ctx, &api.CreateEndpoint{Name: mp.Name, Path: mp.endpoint.Path, Lambda: ids[mp.endpoint.Lambda]})
+		if err != nil {
+			return "", err
+		}
+		return e.Id, nil
+	}
+
+	return "", fmt.Errorf("unknown kind %s", mp.Kind)
+}
@@ -0,0 +1,328 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Bundle is a bundle unpacked to a temporary directory, removed by Close.
+type Bundle struct {
+	Manifest *Manifest
+	dir      string
+}
+
+// Open unpacks the bundle at path and verifies it: its version, the
+// checksums of SHA256SUMS and the ones of the manifest artifacts.
+func Open(path string) (*Bundle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dir, err := os.MkdirTemp("", "bundle-")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{dir: dir}
+	if err := b.read(file); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return b, nil
+}
+
+func (b *Bundle) Close() error {
+	return os.RemoveAll(b.dir)
+}
+
+func (b *Bundle) read(r io.Reader) error {
+	files := filepath.Join(b.dir, "files")
+	sums := map[string]string{}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		target, err := entryPath(files, header.Name)
+		if err != nil {
+			return err
+		}
+
+		sum, err := writeFile(target, tr, 0644)
+		if err != nil {
+			return err
+		}
+		sums[path.Clean(header.Name)] = sum
+	}
+
+	content, err := os.ReadFile(filepath.Join(files, ManifestFile))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no %s, not a bundle", ManifestFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(content, m); err != nil {
+		return fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	if m.Version < 1 || m.Version > Version {
+		return fmt.Errorf("unsupported bundle version %d, expected at most %d", m.Version, Version)
+	}
+
+	if err := verify(files, sums, m); err != nil {
+		return err
+	}
+
+	b.Manifest = m
+	return nil
+}
+
+// verify checks every file against SHA256SUMS, and every artifact of the
+// manifest against its own checksum.
+func verify(files string, sums map[string]string, m *Manifest) error {
+	listed, err := readChecksums(filepath.Join(files, ChecksumsFile))
+	if err != nil {
+		return err
+	}
+
+	for name, sum := range listed {
+		got, ok := sums[name]
+		if !ok {
+			return fmt.Errorf("%s is listed in %s but missing", name, ChecksumsFile)
+		}
+		if got != sum {
+			return fmt.Errorf("checksum mismatch for %s", name)
+		}
+	}
+	if _, ok := listed[ManifestFile]; !ok {
+		return fmt.Errorf("%s is not listed in %s", ManifestFile, ChecksumsFile)
+	}
+
+	artifacts := []*Artifact{}
+	for _, rt := range m.Runtimes {
+		artifacts = append(artifacts, rt.Artifact)
+	}
+	for _, l := range m.Lambdas {
+		artifacts = append(artifacts, l.Artifact)
+	}
+
+	for _, a := range artifacts {
+		if a == nil {
+			continue
+		}
+		if sums[path.Clean(a.Path)] != a.SHA256 || listed[path.Clean(a.Path)] != a.SHA256 {
+			return fmt.Errorf("checksum mismatch for %s", a.Path)
+		}
+	}
+
+	return nil
+}
+
+func readChecksums(p string) (map[string]string, error) {
+	file, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no %s, cannot verify the bundle", ChecksumsFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		sum, name, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			return nil, fmt.Errorf("invalid %s line %q", ChecksumsFile, scanner.Text())
+		}
+		sums[path.Clean(name)] = sum
+	}
+
+	return sums, scanner.Err()
+}
+
+// Source returns the path to create the artifact's resource from: the
+// Dockerfile, or the directory its archive is unpacked to.
+func (b *Bundle) Source(a *Artifact) (string, error) {
+	file, err := entryPath(filepath.Join(b.dir, "files"), a.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if a.Kind == KindDockerfile {
+		return file, nil
+	}
+
+	dir, err := entryPath(filepath.Join(b.dir, "sources"), a.Path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+
+	archive, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer archive.Close()
+
+	if err := unpack(archive, dir); err != nil {
+		return "", fmt.Errorf("%s: %w", a.Path, err)
+	}
+
+	return dir, nil
+}
+
+// unpack extracts the directories, regular files and symlinks of a source
+// archive to dir, like the node_modules/.bin links packaged with a lambda.
+// Symlinks are created once the files are written, so that no write goes
+// through them, and must resolve inside dir.
+func unpack(r io.Reader, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	links := []*tar.Header{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := entryPath(dir, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			_, err = writeFile(target, tr, os.FileMode(header.Mode).Perm())
+		case tar.TypeSymlink:
+			links = append(links, header)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	created := []string{}
+	for _, header := range links {
+		target, err := symlink(root, header.Name, header.Linkname)
+		if err != nil {
+			return err
+		}
+		created = append(created, target)
+	}
+
+	// Links may go through each other, they are resolved once all exist.
+	// Those that do not resolve are kept.
+	for i, target := range created {
+		if resolved, err := filepath.EvalSymlinks(target); err == nil && !inside(root, resolved) {
+			for _, target := range created {
+				os.Remove(target)
+			}
+			return fmt.Errorf("symlink %s points outside of the sources: %s", links[i].Name, links[i].Linkname)
+		}
+	}
+
+	return nil
+}
+
+// symlink creates the link name to linkname inside root and returns its
+// path, refusing links that lead outside of it.
+func symlink(root string, name string, linkname string) (string, error) {
+	target, err := entryPath(root, name)
+	if err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(linkname) {
+		return "", fmt.Errorf("symlink %s points outside of the sources: %s", name, linkname)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+	if !inside(root, parent) || !inside(root, filepath.Join(parent, filepath.FromSlash(linkname))) {
+		return "", fmt.Errorf("symlink %s points outside of the sources: %s", name, linkname)
+	}
+
+	target = filepath.Join(parent, filepath.Base(target))
+	return target, os.Symlink(filepath.FromSlash(linkname), target)
+}
+
+func inside(root string, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && (rel == "." || filepath.IsLocal(rel))
+}
+
+// entryPath resolves an archive entry name inside dir, refusing names that
+// would escape it.
+func entryPath(dir string, name string) (string, error) {
+	clean := filepath.FromSlash(path.Clean(name))
+	if !filepath.IsLocal(clean) {
+		return "", fmt.Errorf("invalid archive entry %s", name)
+	}
+
+	return filepath.Join(dir, clean), nil
+}
+
+// writeFile writes r to target, refusing to write through a symlink.
+func writeFile(target string, r io.Reader, mode os.FileMode) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return "", fmt.Errorf("refusing to write through the symlink %s", target)
+	}
+
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(file, hash), r); err != nil {
+		file.Close()
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), file.Close()
+}
@@ -0,0 +1,134 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type entry struct {
+	name     string
+	linkname string
+	content  string
+}
+
+func archive(t *testing.T, entries []entry) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.content))}
+		if e.linkname != "" {
+			header = &tar.Header{Name: e.name, Mode: 0777, Typeflag: tar.TypeSymlink, Linkname: e.linkname}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &buf
+}
+
+func TestUnpackStaysInside(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+	}{
+		{"parent entry", []entry{{name: "../evil", content: "x"}}},
+		{"absolute entry", []entry{{name: "/evil", content: "x"}}},
+		{"symlink chain", []entry{
+			{name: "s/up", linkname: ".."},
+			{name: "s/up/up2", linkname: ".."},
+			{name: "s/up/up2/evil", content: "x"},
+		}},
+		{"symlink to parent", []entry{
+			{name: "up", linkname: "../"},
+			{name: "up/evil", content: "x"},
+		}},
+		{"file through symlink", []entry{
+			{name: "evil", linkname: "../evil"},
+			{name: "evil", content: "x"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "a", "b")
+
+			unpack(archive(t, tt.entries), dir)
+
+			filepath.Walk(parent, func(p string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if info.Mode()&os.ModeSymlink != 0 {
+					t.Errorf("symlink %s extracted", p)
+				}
+				if info.Mode().IsRegular() {
+					if rel, _ := filepath.Rel(dir, p); !filepath.IsLocal(rel) {
+						t.Errorf("%s written outside %s", p, dir)
+					}
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestUnpackSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	entries := []entry{
+		{name: "node_modules/.bin/hello", linkname: "../hello/bin.js"},
+		{name: "node_modules/hello/bin.js", content: "console.log('hello')\n"},
+		{name: "link", linkname: "dangling"},
+	}
+
+	if err := unpack(archive(t, entries), dir); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(filepath.Join(dir, "node_modules", ".bin", "hello")); err != nil || string(content) != "console.log('hello')\n" {
+		t.Errorf("node_modules/.bin/hello = %q, %v", content, err)
+	}
+	if linkname, err := os.Readlink(filepath.Join(dir, "link")); err != nil || linkname != "dangling" {
+		t.Errorf("link = %q, %v", linkname, err)
+	}
+}
+
+func TestUnpackRefusesEscapingSymlinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+	}{
+		{"absolute", []entry{{name: "link", linkname: "/etc/passwd"}}},
+		{"parent", []entry{{name: "a/link", linkname: "../../x"}}},
+		{"through link", []entry{
+			{name: "up", linkname: "q/.."},
+			{name: "q", linkname: "."},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			if err := unpack(archive(t, tt.entries), dir); err == nil {
+				t.Error("unpack() accepted a symlink leading outside")
+			}
+			for _, e := range tt.entries {
+				if _, err := os.Lstat(filepath.Join(dir, e.name)); !os.IsNotExist(err) {
+					t.Errorf("symlink %s extracted: %v", e.name, err)
+				}
+			}
+		})
+	}
+}
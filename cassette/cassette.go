@@ -0,0 +1,376 @@
+// Package cassette records the HTTP exchanges with an API server into
+// fixture files and replays them, so that clients can be tested against
+// realistic responses without running the server.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const Version = 1
+
+// Redacted replaces secrets in recorded exchanges.
+const Redacted = "REDACTED"
+
+// SensitiveHeaders are recorded with their value redacted.
+var SensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// recordedHeaders are recorded as is, every other header is left out to
+// keep fixtures stable.
+var recordedHeaders = []string{"Content-Type"}
+
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body holds JSON bodies, Text any other textual body. Multipart bodies
+	// are not recorded.
+	Body json.RawMessage `json:"body,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Text    string            `json:"text,omitempty"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is a sequence of recorded exchanges. As an http.RoundTripper it
+// replays them in order, failing on any request that doesn't match the
+// next recorded one.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+
+	mu   sync.Mutex
+	next int
+}
+
+func Load(path string) (*Cassette, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cassette{}
+	if err := json.Unmarshal(content, c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("%s: unsupported cassette version %d", path, c.Version)
+	}
+
+	return c, nil
+}
+
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Version = Version
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// Remaining returns how many recorded exchanges have not been replayed.
+func (c *Cassette) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.Interactions) - c.next
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	got := newRequest(req, body)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.next >= len(c.Interactions) {
+		return nil, fmt.Errorf("cassette: unexpected request %s %s, all %d exchanges were replayed", got.Method, got.Path, len(c.Interactions))
+	}
+
+	want := c.Interactions[c.next]
+	if err := match(want.Request, got); err != nil {
+		return nil, fmt.Errorf("cassette: exchange %d: %w", c.next, err)
+	}
+	c.next++
+
+	resp := &http.Response{
+		StatusCode: want.Response.Status,
+		Status:     fmt.Sprintf("%d %s", want.Response.Status, http.StatusText(want.Response.Status)),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Request:    req,
+	}
+	for key, value := range want.Response.Headers {
+		resp.Header.Set(key, value)
+	}
+
+	content := []byte(want.Response.Text)
+	if want.Response.Body != nil {
+		// Bodies are indented along with the cassette
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, want.Response.Body); err != nil {
+			return nil, fmt.Errorf("cassette: exchange %d: %w", c.next-1, err)
+		}
+		content = compact.Bytes()
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(content))
+	resp.ContentLength = int64(len(content))
+
+	return resp, nil
+}
+
+func match(want Request, got Request) error {
+	if want.Method != got.Method || want.Path != got.Path {
+		return fmt.Errorf("got request %s %s, want %s %s", got.Method, got.Path, want.Method, want.Path)
+	}
+
+	if want.Body != nil {
+		var w, g interface{}
+		json.Unmarshal(want.Body, &w)
+		json.Unmarshal(got.Body, &g)
+		if !reflect.DeepEqual(w, g) {
+			return fmt.Errorf("%s %s: got body %s, want %s", got.Method, got.Path, got.Body, want.Body)
+		}
+	}
+
+	return nil
+}
+
+// Recorder is an http.RoundTripper recording the exchanges sent through
+// Transport.
+type Recorder struct {
+	// Transport sends the requests, http.DefaultTransport when nil
+	Transport http.RoundTripper
+	// Redact lists patterns whose matches are redacted from recorded
+	// bodies, on top of the SensitiveHeaders. In JSON bodies they apply to
+	// every string value, keys aside.
+	Redact []*regexp.Regexp
+	// RedactKeys lists the keys of JSON objects whose values are redacted
+	// from recorded bodies, at any depth
+	RedactKeys []string
+
+	cassette Cassette
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := Interaction{
+		Request: newRequest(req, body),
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: headers(resp.Header),
+		},
+	}
+	interaction.Response.Body, interaction.Response.Text = splitBody(resp.Header, respBody)
+	if err := r.redact(&interaction); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("redacting %s %s: %w", req.Method, req.URL.Path, err)
+	}
+
+	r.cassette.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.cassette.mu.Unlock()
+
+	return resp, nil
+}
+
+// Cassette returns what has been recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.cassette.mu.Lock()
+	defer r.cassette.mu.Unlock()
+
+	return &Cassette{Version: Version, Interactions: append([]Interaction{}, r.cassette.Interactions...)}
+}
+
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+// redact applies Redact and RedactKeys to the bodies of i. JSON bodies are
+// redacted as decoded documents, so that they stay valid JSON and no secret
+// survives an invalid pattern replacement.
+func (r *Recorder) redact(i *Interaction) error {
+	var err error
+	if i.Request.Body, err = r.redactJSON(i.Request.Body); err != nil {
+		return err
+	}
+	if i.Response.Body, err = r.redactJSON(i.Response.Body); err != nil {
+		return err
+	}
+	i.Request.Text = r.redactString(i.Request.Text)
+	i.Response.Text = r.redactString(i.Response.Text)
+
+	return nil
+}
+
+func (r *Recorder) redactJSON(body json.RawMessage) (json.RawMessage, error) {
+	if body == nil {
+		return nil, nil
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(r.redactValue(doc))
+}
+
+func (r *Recorder) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if r.redactedKey(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = r.redactValue(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.redactValue(value)
+		}
+	case string:
+		return r.redactString(v)
+	}
+
+	return v
+}
+
+func (r *Recorder) redactedKey(key string) bool {
+	for _, k := range r.RedactKeys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Recorder) redactString(s string) string {
+	for _, re := range r.Redact {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+
+	return s
+}
+
+func newRequest(req *http.Request, body []byte) Request {
+	r := Request{
+		Method:  req.Method,
+		Path:    req.URL.RequestURI(),
+		Headers: headers(req.Header),
+	}
+	r.Body, r.Text = splitBody(req.Header, body)
+
+	return r
+}
+
+func headers(h http.Header) map[string]string {
+	recorded := map[string]string{}
+	for _, key := range recordedHeaders {
+		if v := h.Get(key); v != "" {
+			recorded[key] = v
+		}
+	}
+	for _, key := range SensitiveHeaders {
+		if h.Get(key) != "" {
+			recorded[key] = Redacted
+		}
+	}
+
+	// Multipart boundaries are random
+	if ct, ok := recorded["Content-Type"]; ok && strings.HasPrefix(ct, "multipart/") {
+		recorded["Content-Type"], _, _ = strings.Cut(ct, ";")
+	}
+
+	if len(recorded) == 0 {
+		return nil
+	}
+
+	return recorded
+}
+
+func splitBody(h http.Header, body []byte) (json.RawMessage, string) {
+	if len(body) == 0 || strings.HasPrefix(h.Get("Content-Type"), "multipart/") {
+		return nil, ""
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if json.Valid(trimmed) {
+		return json.RawMessage(trimmed), ""
+	}
+
+	return nil, string(body)
+}
+
+// readBody reads the body and replaces it with a copy, so that it can still
+// be read.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	content, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+
+	*body = io.NopCloser(bytes.NewReader(content))
+	return content, nil
+}
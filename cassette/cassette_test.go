@@ -0,0 +1,133 @@
+package cassette_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/onpremless/opcli/cassette"
+)
+
+func TestRecordRedactsAndReplays(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=s3cr3t")
+		io.WriteString(w, `{"id":"runtime-1","token":"s3cr3t"}`)
+	}))
+	defer ts.Close()
+
+	rec := &cassette.Recorder{Redact: []*regexp.Regexp{regexp.MustCompile(`s3cr3t`)}}
+	client := &http.Client{Transport: rec}
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/runtime?dry=1", strings.NewReader(`{"name":"go","password":"s3cr3t"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "s3cr3t") {
+		t.Errorf("the recorder altered the response body: %s", body)
+	}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "s3cr3t") {
+		t.Errorf("cassette leaks a secret:\n%s", content)
+	}
+
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := &http.Client{Transport: c}
+
+	resp, err = replay.Post("http://replay.test/runtime?dry=1", "application/json", strings.NewReader(`{"password":"REDACTED","name":"go"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != `{"id":"runtime-1","token":"REDACTED"}` {
+		t.Errorf("replayed %d %s", resp.StatusCode, body)
+	}
+	if c.Remaining() != 0 {
+		t.Errorf("%d exchanges left", c.Remaining())
+	}
+
+	if _, err := replay.Get("http://replay.test/runtime"); err == nil {
+		t.Error("replayed more exchanges than recorded")
+	}
+}
+
+func TestReplayRejectsMismatch(t *testing.T) {
+	c := &cassette.Cassette{Interactions: []cassette.Interaction{{
+		Request:  cassette.Request{Method: http.MethodPost, Path: "/lambda", Body: []byte(`{"name":"hello"}`)},
+		Response: cassette.Response{Status: http.StatusOK, Body: []byte(`{}`)},
+	}}}
+	replay := &http.Client{Transport: c}
+
+	if _, err := replay.Get("http://replay.test/lambda"); err == nil {
+		t.Error("replayed a request with another method")
+	}
+	if _, err := replay.Post("http://replay.test/lambda", "application/json", strings.NewReader(`{"name":"world"}`)); err == nil {
+		t.Error("replayed a request with another body")
+	}
+	if c.Remaining() != 1 {
+		t.Errorf("mismatched requests consumed exchanges, %d left", c.Remaining())
+	}
+}
+
+func TestRecordNeverLeaksSecrets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"items":[{"id":"lambda-1","secret":{"value":"s3cr3t"}}],"note":"key s3cr3t"}`)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		rec  *cassette.Recorder
+	}{
+		{"keys", &cassette.Recorder{RedactKeys: []string{"token", "secret"}, Redact: []*regexp.Regexp{regexp.MustCompile(`s3cr3t`)}}},
+		// Replacing the match with the raw placeholder would make the body
+		// invalid JSON
+		{"pattern spanning JSON syntax", &cassette.Recorder{Redact: []*regexp.Regexp{regexp.MustCompile(`"?(token|value)"?:\s*"?s3cr3t"?|s3cr3t`)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: tt.rec}
+			resp, err := client.Post(ts.URL+"/lambda", "application/json", strings.NewReader(`{"token":"s3cr3t","name":"hello"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			path := filepath.Join(t.TempDir(), "cassette.json")
+			if err := tt.rec.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			content, _ := os.ReadFile(path)
+			if strings.Contains(string(content), "s3cr3t") {
+				t.Errorf("cassette leaks a secret:\n%s", content)
+			}
+			if _, err := cassette.Load(path); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/onpremless/opcli/config"
+	"github.com/onpremless/opcli/tui/listing"
+	"github.com/spf13/cobra"
+)
+
+var contextServer string
+var contextGateway string
+var contextToken string
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage contexts",
+	// Contexts are managed without resolving the current one, which may be
+	// the one to fix
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var contextColumns = []listing.Column{
+	{Key: "current", Title: ""},
+	{Key: "name", Title: "Name"},
+	{Key: "server", Title: "Server"},
+	{Key: "gateway", Title: "Gateway"},
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		rows := []table.Row{}
+		for _, name := range cfg.Names() {
+			c, _ := cfg.Context(name)
+			current := ""
+			if name == cfg.CurrentContext {
+				current = "*"
+			}
+			rows = append(rows, table.Row{current, name, c.Server, c.Gateway})
+		}
+
+		listing.WritePlain(os.Stdout, contextColumns, rows)
+	},
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch the current context",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		if _, err := cfg.Context(args[0]); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		cfg.CurrentContext = args[0]
+		if err := cfg.Save(); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Switched to context %s\n", args[0])
+	},
+}
+
+var contextSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Create or update a context",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		c := cfg.Contexts[args[0]]
+		if cmd.Flags().Changed("server") {
+			c.Server = contextServer
+		}
+		if cmd.Flags().Changed("gateway") {
+			c.Gateway = contextGateway
+		}
+		if cmd.Flags().Changed("token") {
+			c.Token = contextToken
+		}
+		cfg.Contexts[args[0]] = c
+
+		if err := cfg.Save(); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Context %s saved\n", args[0])
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextSetCmd)
+
+	contextSetCmd.Flags().StringVar(&contextServer, "server", "", "API server URL")
+	contextSetCmd.Flags().StringVar(&contextGateway, "gateway", "", "gateway URL endpoints are served under")
+	contextSetCmd.Flags().StringVar(&contextToken, "token", "", "bearer token API requests are authenticated with")
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/onpremless/opcli/deploy"
+	"github.com/onpremless/opcli/drift"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/tui/listing"
+	"github.com/spf13/cobra"
+)
+
+// driftExitCode is returned when drift is found, telling it apart from
+// failures.
+const driftExitCode = 2
+
+var driftOutput string
+
+var driftColumns = []listing.Column{
+	{Key: "kind", Title: "Kind"},
+	{Key: "name", Title: "Name"},
+	{Key: "id", Title: "ID"},
+	{Key: "drift", Title: "Drift"},
+	{Key: "details", Title: "Details"},
+}
+
+func driftRows(drifts []drift.Drift) []table.Row {
+	rows := []table.Row{}
+	for _, d := range drifts {
+		id := d.ID
+		if id == "" {
+			id = "-"
+		}
+
+		details := ""
+		if d.Field != "" {
+			details = fmt.Sprintf("%s: want %s, got %s", d.Field, d.Want, d.Got)
+		}
+
+		rows = append(rows, table.Row{d.Kind, d.Name, id, d.Drift, details})
+	}
+
+	return rows
+}
+
+func desiredState(patterns []string) (drift.Desired, error) {
+	if len(patterns) == 0 {
+		store, err := history.Open()
+		if err != nil {
+			return drift.Desired{}, err
+		}
+
+		builds, err := store.ListRuntimes(currentContext.Server)
+		if err != nil {
+			return drift.Desired{}, err
+		}
+		deployments, err := store.List(currentContext.Server)
+		if err != nil {
+			return drift.Desired{}, err
+		}
+		destroyed, err := store.Destroyed(currentContext.Server)
+		if err != nil {
+			return drift.Desired{}, err
+		}
+
+		return drift.FromHistory(builds, deployments, destroyed), nil
+	}
+
+	runtimes, err := deploy.DiscoverRuntimes(patterns)
+	if err != nil {
+		return drift.Desired{}, err
+	}
+	lambdas, err := deploy.Discover(patterns)
+	if err != nil {
+		return drift.Desired{}, err
+	}
+
+	return drift.FromManifests(runtimes, lambdas), nil
+}
+
+var driftCmd = &cobra.Command{
+	Use:   "drift [glob|dir|stack file]...",
+	Short: "Compare the manifests, or the last applied state, with the server",
+	Long: `Compare the runtimes, lambdas and endpoints the server should hold with the
+ones it holds, reporting unmanaged and missing resources, and lambdas or
+endpoints whose runtime, type, path or target changed.
+
+The desired state is read from the lambda.yaml and runtime.yaml manifests
+found in the given directories or globs, from the stack files given, such as
+the one written by generate manifest, or, when none is given, from the
+last applied state: what was last deployed to the server from this machine
+and not destroyed since, endpoints aside.
+
+Exits with code 2 when drift is found.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if driftOutput != "text" && driftOutput != "json" {
+			fmt.Printf("Error: invalid --output value %q, expected text or json\n", driftOutput)
+			os.Exit(1)
+		}
+
+		desired, err := desiredState(args)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		live, err := drift.Fetch(cmd.Context(), apiClient)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		drifts := drift.Compare(desired, live)
+
+		switch {
+		case driftOutput == "json":
+			j, _ := json.MarshalIndent(drifts, "", "  ")
+			fmt.Println(string(j))
+		case len(drifts) == 0:
+			fmt.Println("No drift")
+		default:
+			listing.WritePlain(os.Stdout, driftColumns, driftRows(drifts))
+		}
+
+		if len(drifts) > 0 {
+			os.Exit(driftExitCode)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(driftCmd)
+
+	driftCmd.Flags().StringVarP(&driftOutput, "output", "o", "text", "output format (text | json)")
+}
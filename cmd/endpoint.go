@@ -1,12 +1,21 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/bench"
 	"github.com/onpremless/opcli/ops"
 	"github.com/onpremless/opcli/tui/endpoint"
 	"github.com/spf13/cobra"
@@ -22,14 +31,25 @@ var endpointCmd = &cobra.Command{
 
 var endpointName string
 var endpointLambdaID string
+var endpointCallMethod string
+var endpointCallHeaders []string
+var endpointCallData string
+var endpointCallGateway string
+var endpointBenchConcurrency int
+var endpointBenchRPS float64
+var endpointBenchDuration time.Duration
+var endpointBenchTimeout time.Duration
+var endpointBenchOutput string
+var endpointBenchFormat string
 
 type endpointOps struct {
-	ctx context.Context
+	ctx    context.Context
+	client ops.API
 }
 
 func (op *endpointOps) Create(name string, path string, lambda string) tea.Cmd {
 	return func() tea.Msg {
-		endpt, err := ops.CreateEndpoint(op.ctx, &api.CreateEndpoint{
+		endpt, err := op.client.CreateEndpoint(op.ctx, &api.CreateEndpoint{
 			Name:   name,
 			Path:   path,
 			Lambda: lambda,
@@ -46,7 +66,7 @@ func (op *endpointOps) Create(name string, path string, lambda string) tea.Cmd {
 
 func (op *endpointOps) List() tea.Cmd {
 	return func() tea.Msg {
-		endpts, err := ops.ListEndpoints(op.ctx)
+		endpts, err := op.client.ListEndpoints(op.ctx)
 
 		return endpoint.EndpointListResponseMsg{
 			Resp: &endpoint.EndpointListResponse{
@@ -69,7 +89,7 @@ var endpointCreateCmd = &cobra.Command{
 		var lambda *api.Lambda
 		if endpointLambdaID != "" {
 			var err error
-			lambda, err = ops.GetLambda(cmd.Context(), endpointLambdaID)
+			lambda, err = apiClient.GetLambda(cmd.Context(), endpointLambdaID)
 			if err != nil {
 				fmt.Printf("Failed to get lambda: %s", err)
 				os.Exit(1)
@@ -79,8 +99,12 @@ var endpointCreateCmd = &cobra.Command{
 			Name:            endpointName,
 			Endpoint:        epoint,
 			Lambda:          lambda,
-			EndpointCreator: &endpointOps{ctx: cmd.Context()},
-			LambdaLister:    &lambdaOps{ctx: cmd.Context()},
+			EndpointCreator: &endpointOps{ctx: cmd.Context(), client: apiClient},
+			LambdaLister:    &lambdaOps{ctx: cmd.Context(), client: apiClient},
+			EndpointLister:  &endpointOps{ctx: cmd.Context(), client: apiClient},
+			LambdaCreator:   &lambdaOps{ctx: cmd.Context(), client: apiClient},
+			RuntimeLister:   &runtimeOps{ctx: cmd.Context(), client: apiClient},
+			RuntimeCreator:  &runtimeOps{ctx: cmd.Context(), client: apiClient},
 		}
 		p := tea.NewProgram(endpoint.InitEndpointCreateModel(m))
 
@@ -95,24 +119,275 @@ var endpointListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List",
 	Run: func(cmd *cobra.Command, args []string) {
+		filter, sort := listOptions(endpoint.EndpointColumns)
 		m := &endpoint.EndpointListModel{
 			Lister: &endpointOps{
-				ctx: cmd.Context(),
+				ctx:    cmd.Context(),
+				client: apiClient,
 			},
+			LambdaLister: &lambdaOps{ctx: cmd.Context(), client: apiClient},
+			Filter:       filter,
+			Sort:         sort,
+			Plain:        !isTerminal(),
 		}
-		p := tea.NewProgram(endpoint.InitEndpointListModel(m))
 
-		if _, err := p.Run(); err != nil {
+		if err := runList(endpoint.InitEndpointListModel(m)); err != nil {
 			fmt.Printf("Error: %s", err)
 		}
 	},
 }
 
+// readCallBody reads -d: @file, @- for stdin or the data itself.
+func readCallBody(data string) ([]byte, error) {
+	if data == "" {
+		return nil, nil
+	}
+	if data == "@-" {
+		return io.ReadAll(os.Stdin)
+	}
+	if strings.HasPrefix(data, "@") {
+		return os.ReadFile(data[1:])
+	}
+
+	return []byte(data), nil
+}
+
+func parseCallHeaders(headers []string) (http.Header, error) {
+	target := http.Header{}
+	for _, h := range headers {
+		key, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", h)
+		}
+
+		target.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+
+	return target, nil
+}
+
+func printCallResult(w io.Writer, res *ops.CallResult) {
+	fmt.Fprintf(w, "%s %s\n", res.Proto, res.Status)
+
+	keys := make([]string, 0, len(res.Headers))
+	for key := range res.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, v := range res.Headers[key] {
+			fmt.Fprintf(w, "%s: %s\n", key, v)
+		}
+	}
+
+	fmt.Fprintf(w, "\nTime: %s\n\n", res.Duration.Round(time.Microsecond))
+
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, res.Body, "", "  ") == nil {
+		fmt.Fprintln(w, pretty.String())
+	} else if len(res.Body) > 0 {
+		fmt.Fprintln(w, string(res.Body))
+	}
+}
+
+var endpointCallCmd = &cobra.Command{
+	Use:   "call <name|path>",
+	Short: "Send an HTTP request to an endpoint",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := callRequest()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		endpt, err := apiClient.FindEndpoint(cmd.Context(), args[0])
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		res, err := apiClient.CallEndpoint(cmd.Context(), callGateway(), endpt, req)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		printCallResult(os.Stdout, res)
+	},
+}
+
+// callRequest builds the request shared by call and bench from the -X, -H
+// and -d flags.
+func callRequest() (ops.CallEndpointM, error) {
+	body, err := readCallBody(endpointCallData)
+	if err != nil {
+		return ops.CallEndpointM{}, err
+	}
+
+	headers, err := parseCallHeaders(endpointCallHeaders)
+	if err != nil {
+		return ops.CallEndpointM{}, err
+	}
+
+	method := endpointCallMethod
+	if method == "" {
+		method = http.MethodGet
+		if body != nil {
+			method = http.MethodPost
+		}
+	}
+
+	return ops.CallEndpointM{
+		Method:  strings.ToUpper(method),
+		Headers: headers,
+		Body:    body,
+	}, nil
+}
+
+func callGateway() string {
+	if endpointCallGateway != "" {
+		return endpointCallGateway
+	}
+
+	return currentContext.Gateway
+}
+
+// benchReportFormat returns the format of the report file, checked before
+// the bench runs so that a typo does not waste the run.
+func benchReportFormat() (string, error) {
+	format := endpointBenchFormat
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(endpointBenchOutput), ".")
+	}
+
+	switch format {
+	case "csv", "json":
+		return format, nil
+	case "":
+		return "json", nil
+	default:
+		return "", fmt.Errorf("unknown report format %q, expected json or csv", format)
+	}
+}
+
+func writeBenchReport(report bench.Report, format string) error {
+	file, err := os.Create(endpointBenchOutput)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if format == "csv" {
+		return report.WriteCSV(file)
+	}
+
+	return report.WriteJSON(file)
+}
+
+var endpointBenchCmd = &cobra.Command{
+	Use:   "bench <name|path>",
+	Short: "Load test an endpoint",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// NaN fails both comparisons
+		if !(endpointBenchRPS >= 0 && endpointBenchRPS <= bench.MaxRPS) {
+			fmt.Printf("Error: --rps must be between 0 and %g, got %g\n", bench.MaxRPS, endpointBenchRPS)
+			os.Exit(1)
+		}
+
+		format, err := benchReportFormat()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		req, err := callRequest()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		endpt, err := apiClient.FindEndpoint(cmd.Context(), args[0])
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		target, err := ops.EndpointURL(callGateway(), endpt.Path)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		runner := bench.NewRunner(bench.Options{
+			URL:         target,
+			Method:      req.Method,
+			Headers:     req.Headers,
+			Body:        req.Body,
+			Concurrency: endpointBenchConcurrency,
+			RPS:         endpointBenchRPS,
+			Duration:    endpointBenchDuration,
+			Timeout:     endpointBenchTimeout,
+		})
+
+		ctx, stop := context.WithCancel(cmd.Context())
+		defer stop()
+		runner.Start(ctx)
+
+		if isTerminal() {
+			m := &endpoint.EndpointBenchModel{
+				Name:   endpt.Name,
+				Runner: runner,
+				Stop:   stop,
+			}
+			if _, err := tea.NewProgram(endpoint.InitEndpointBenchModel(m), programOptions()...).Run(); err != nil {
+				fmt.Printf("Error: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		<-runner.Done()
+		report := bench.NewReport(runner.Options(), runner.Snapshot())
+
+		if !isTerminal() && endpointBenchOutput == "" {
+			report.WriteJSON(os.Stdout)
+		}
+
+		if endpointBenchOutput != "" {
+			if err := writeBenchReport(report, format); err != nil {
+				fmt.Printf("Error: %s\n", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
 func init() {
 	RootCmd.AddCommand(endpointCmd)
+	endpointCmd.AddCommand(endpointBenchCmd)
+	endpointCmd.AddCommand(endpointCallCmd)
 	endpointCmd.AddCommand(endpointCreateCmd)
 	endpointCmd.AddCommand(endpointListCmd)
 
 	endpointCreateCmd.Flags().StringVarP(&endpointName, "name", "n", "", "endpoint name")
 	endpointCreateCmd.Flags().StringVarP(&endpointLambdaID, "lambda-id", "l", "", "lambda id")
+
+	addListFlags(endpointListCmd)
+
+	endpointCallCmd.Flags().StringVarP(&endpointCallMethod, "request", "X", "", "HTTP method (default GET, POST with a body)")
+	endpointCallCmd.Flags().StringArrayVarP(&endpointCallHeaders, "header", "H", nil, "request header as \"Name: value\", may be repeated")
+	endpointCallCmd.Flags().StringVarP(&endpointCallData, "data", "d", "", "request body, @file to read it from a file or @- from stdin")
+	endpointCallCmd.Flags().StringVar(&endpointCallGateway, "gateway", "", "gateway URL (default the context gateway)")
+
+	endpointBenchCmd.Flags().StringVarP(&endpointCallMethod, "request", "X", "", "HTTP method (default GET, POST with a body)")
+	endpointBenchCmd.Flags().StringArrayVarP(&endpointCallHeaders, "header", "H", nil, "request header as \"Name: value\", may be repeated")
+	endpointBenchCmd.Flags().StringVarP(&endpointCallData, "data", "d", "", "request body, @file to read it from a file or @- from stdin")
+	endpointBenchCmd.Flags().StringVar(&endpointCallGateway, "gateway", "", "gateway URL (default the context gateway)")
+	endpointBenchCmd.Flags().IntVarP(&endpointBenchConcurrency, "concurrency", "c", 10, "number of concurrent workers")
+	endpointBenchCmd.Flags().Float64Var(&endpointBenchRPS, "rps", 0, "overall requests per second limit, 0 for unlimited")
+	endpointBenchCmd.Flags().DurationVar(&endpointBenchDuration, "duration", 10*time.Second, "how long to run")
+	endpointBenchCmd.Flags().DurationVar(&endpointBenchTimeout, "timeout", 10*time.Second, "per request timeout")
+	endpointBenchCmd.Flags().StringVarP(&endpointBenchOutput, "output", "o", "", "write the final report to a file")
+	endpointBenchCmd.Flags().StringVar(&endpointBenchFormat, "format", "", "report format (json | csv), guessed from the output file extension by default")
 }
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/onpremless/opcli/bundle"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/tui/listing"
+	"github.com/spf13/cobra"
+)
+
+var exportOutput string
+
+var exportColumns = []listing.Column{
+	{Key: "kind", Title: "Kind"},
+	{Key: "name", Title: "Name"},
+	{Key: "id", Title: "ID"},
+	{Key: "artifact", Title: "Artifact"},
+}
+
+func exportRows(m *bundle.Manifest) []table.Row {
+	artifact := func(a *bundle.Artifact, missing string) string {
+		if a == nil {
+			return "missing: " + missing
+		}
+		return a.Path
+	}
+
+	rows := []table.Row{}
+	for _, rt := range m.Runtimes {
+		rows = append(rows, table.Row{"runtime", rt.Name, rt.ID, artifact(rt.Artifact, rt.Missing)})
+	}
+	for _, l := range m.Lambdas {
+		rows = append(rows, table.Row{"lambda", l.Name, l.ID, artifact(l.Artifact, l.Missing)})
+	}
+	for _, e := range m.Endpoints {
+		rows = append(rows, table.Row{"endpoint", e.Name, e.ID, "-"})
+	}
+
+	return rows
+}
+
+// writeBundle exports to a temporary file renamed to path once complete, so
+// that a failed export never leaves a truncated bundle behind.
+func writeBundle(cmd *cobra.Command, path string) (*bundle.Manifest, error) {
+	store, err := history.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	m, err := bundle.Export(cmd.Context(), apiClient, store, currentContext.Server, tmp)
+	if err != nil {
+		tmp.Close()
+		return nil, err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	return m, os.Rename(tmp.Name(), path)
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all runtimes, lambdas and endpoints into a bundle",
+	Long: `Export all runtimes, lambdas and endpoints of the server into a tar bundle.
+
+The bundle holds a versioned manifest of every resource, the Dockerfiles and
+lambda archives, and a SHA256SUMS file. The server does not hand out the
+uploaded files, so they are packaged again from the sources recorded when
+they were deployed from this machine; resources deployed elsewhere, or whose
+sources changed since, are exported without them.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := writeBundle(cmd, exportOutput)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		listing.WritePlain(os.Stdout, exportColumns, exportRows(m))
+		fmt.Printf("\nExported %d runtime(s), %d lambda(s) and %d endpoint(s) to %s\n", len(m.Runtimes), len(m.Lambdas), len(m.Endpoints), exportOutput)
+
+		if n := m.Missing(); n > 0 {
+			fmt.Printf("Warning: %d runtime(s) and lambda(s) were exported without their files\n", n)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "bundle.tar", "path of the bundle to write")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/drift"
+	"github.com/onpremless/opcli/gc"
+	"github.com/onpremless/opcli/tui/lambda"
+	"github.com/onpremless/opcli/tui/listing"
+	"github.com/onpremless/opcli/tui/runtime"
+	"github.com/spf13/cobra"
+)
+
+var gcDryRun bool
+var gcYes bool
+var gcOlderThan time.Duration
+
+var gcColumns = []listing.Column{
+	{Key: "kind", Title: "Kind"},
+	{Key: "name", Title: "Name"},
+	{Key: "id", Title: "ID"},
+	{Key: "created", Title: "Created"},
+	{Key: "reason", Title: "Reason"},
+	{Key: "details", Title: "Details"},
+}
+
+func gcRows(candidates []gc.Candidate) []table.Row {
+	rows := []table.Row{}
+	for _, c := range candidates {
+		rows = append(rows, table.Row{c.Kind, c.Name, c.ID, runtime.FormatTimestamp(c.CreatedAt), c.Reason, c.Details})
+	}
+
+	return rows
+}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Destroy orphaned and duplicate lambdas",
+	Long: `Find the resources nobody uses anymore and destroy the ones selected:
+
+  - ENDPOINT lambdas no endpoint routes to
+  - older lambdas sharing their name with a newer one, unless an endpoint
+    still routes to them
+  - runtimes no lambda uses, which are only reported: the API cannot delete
+    runtimes
+
+Runtimes used by destroyed lambdas become unused, running gc again finds
+them.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		live, err := drift.Fetch(cmd.Context(), apiClient)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		candidates := gc.Find(live, gc.Options{OlderThan: gcOlderThan})
+		if len(candidates) == 0 {
+			fmt.Println("Nothing to garbage collect")
+			return
+		}
+
+		if gcDryRun {
+			listing.WritePlain(os.Stdout, gcColumns, gcRows(candidates))
+			fmt.Println("\nDry run, nothing has been destroyed")
+			return
+		}
+
+		if !isInteractive() && !gcYes {
+			fmt.Println("Error: refusing to destroy without selection, pass --yes to destroy every candidate")
+			os.Exit(1)
+		}
+
+		destroyed := &destroyedLambdas{}
+		m := &lambda.LambdaGCModel{
+			Candidates: candidates,
+			Destroyer:  &lambdaOps{ctx: cmd.Context(), client: apiClient, destroyed: destroyed},
+			Yes:        gcYes,
+		}
+
+		p := tea.NewProgram(lambda.InitLambdaGCModel(m), programOptions()...)
+		fm, err := p.Run()
+		destroyed.warnRecord()
+		if err != nil {
+			fmt.Printf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		if r, ok := fm.(interface{ GetErr() error }); ok && r.GetErr() != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(gcCmd)
+
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "only print what would be destroyed")
+	gcCmd.Flags().BoolVarP(&gcYes, "yes", "y", false, "destroy every candidate without selecting")
+	gcCmd.Flags().DurationVar(&gcOlderThan, "older-than", 0, "only collect resources created at least this long ago, such as 720h")
+}
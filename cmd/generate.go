@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onpremless/opcli/drift"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/manifest"
+	"github.com/spf13/cobra"
+)
+
+var generateManifestOutput string
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate files from the server state",
+}
+
+var generateManifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Generate a manifest of all runtimes, lambdas and endpoints",
+	Long: `Generate a single YAML manifest of the runtimes, lambdas and endpoints of the
+server, to be checked into git and managed declaratively from then on.
+
+Runtimes and lambdas are keyed by name, lambdas reference their runtime by
+name and list the endpoints routing to them. Of duplicate names, the newest
+resource is kept. The sources of runtimes and lambdas built or deployed from
+this machine are taken from the history, relative to the manifest directory;
+the others are set to TODO, to be filled in.
+
+The manifest can be passed to lambda deploy-all and drift in place of the
+directories holding lambda.yaml and runtime.yaml files.
+
+The manifest is written to stdout unless --output is given, which refuses to
+replace an existing file.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		live, err := drift.Fetch(cmd.Context(), apiClient)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		store, err := history.Open()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		builds, err := store.Runtimes(currentContext.Server)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		deployments, err := store.Lambdas(currentContext.Server)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		base := "."
+		if generateManifestOutput != "" {
+			base = filepath.Dir(generateManifestOutput)
+		}
+		if abs, err := filepath.Abs(base); err == nil {
+			base = abs
+		}
+
+		stack, warnings := manifest.FromLive(live.Runtimes, live.Lambdas, live.Endpoints, builds, deployments, base)
+		header := []string{
+			fmt.Sprintf("Generated from %s on %s.", currentContext.Server, time.Now().UTC().Format(time.RFC3339)),
+		}
+		if stack.Placeholders() > 0 {
+			header = append(header, fmt.Sprintf("Sources set to %s were not built or deployed from this machine, fill them in.", manifest.SourcePlaceholder))
+		}
+
+		// Warnings go to stderr when the manifest goes to stdout
+		warn := os.Stderr
+		if generateManifestOutput == "" {
+			content, err := manifest.MarshalStack(stack, header)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err)
+				os.Exit(1)
+			}
+			os.Stdout.Write(content)
+		} else {
+			if err := manifest.WriteStack(generateManifestOutput, stack, header); err != nil {
+				fmt.Printf("Error: %s\n", err)
+				os.Exit(1)
+			}
+			warn = os.Stdout
+			fmt.Printf("Generated %s with %d runtime(s) and %d lambda(s)\n", generateManifestOutput, len(stack.Runtimes), len(stack.Lambdas))
+		}
+
+		for _, w := range warnings {
+			fmt.Fprintf(warn, "Warning: %s\n", w)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(generateCmd)
+	generateCmd.AddCommand(generateManifestCmd)
+
+	generateManifestCmd.Flags().StringVarP(&generateManifestOutput, "output", "o", "", "file to write the manifest to instead of stdout")
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/onpremless/opcli/bundle"
+	"github.com/onpremless/opcli/tui/listing"
+	"github.com/spf13/cobra"
+)
+
+var importDryRun bool
+var importSkipExisting bool
+var importRenamePrefix string
+
+var importColumns = []listing.Column{
+	{Key: "kind", Title: "Kind"},
+	{Key: "name", Title: "Name"},
+	{Key: "old", Title: "Old ID"},
+	{Key: "new", Title: "New ID"},
+	{Key: "action", Title: "Action"},
+	{Key: "reason", Title: "Reason"},
+}
+
+func importRows(plan []bundle.Mapping) []table.Row {
+	rows := []table.Row{}
+	for _, mp := range plan {
+		newID := mp.NewID
+		if newID == "" {
+			newID = "-"
+		}
+
+		rows = append(rows, table.Row{mp.Kind, mp.Name, mp.OldID, newID, mp.Action, mp.Reason})
+	}
+
+	return rows
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Recreate the resources of an export bundle",
+	Long: `Recreate the runtimes, lambdas and endpoints of an export bundle on the
+server of the current context, in dependency order, and print the mapping of
+their old IDs to the new ones.
+
+Resources whose name is already taken fail the import before any change is
+made, unless --skip-existing maps them to the existing ones. So do endpoints
+whose path is routed to another endpoint, unless --skip-existing leaves them
+out. Resources exported without their files are left out.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		b, err := bundle.Open(args[0])
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		defer b.Close()
+
+		plan, err := bundle.Plan(cmd.Context(), apiClient, b.Manifest, bundle.Options{
+			SkipExisting: importSkipExisting,
+			RenamePrefix: importRenamePrefix,
+		})
+		var conflict *bundle.ConflictError
+		if errors.As(err, &conflict) {
+			listing.WritePlain(os.Stdout, importColumns, importRows(plan))
+			fmt.Printf("\nError: %s\n", err)
+			if len(conflict.Conflicts) > 0 {
+				fmt.Println("Use --skip-existing to keep them, or --rename-prefix to import under other names")
+			}
+			if len(conflict.Paths) > 0 {
+				fmt.Println("Use --skip-existing to leave out the endpoints of routed paths")
+			}
+			b.Close()
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			b.Close()
+			os.Exit(1)
+		}
+
+		if !importDryRun {
+			err = bundle.Import(cmd.Context(), apiClient, b, plan)
+		}
+
+		listing.WritePlain(os.Stdout, importColumns, importRows(plan))
+
+		if err != nil {
+			fmt.Printf("\nError: %s\n", err)
+			b.Close()
+			os.Exit(1)
+		}
+
+		unavailable := 0
+		for _, mp := range plan {
+			if mp.Action == bundle.ActionUnavailable {
+				unavailable++
+			}
+		}
+
+		if importDryRun {
+			fmt.Println("\nDry run, nothing was imported")
+		}
+		if unavailable > 0 {
+			fmt.Printf("\nWarning: %d resource(s) could not be imported\n", unavailable)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "print the plan without importing anything")
+	importCmd.Flags().BoolVar(&importSkipExisting, "skip-existing", false, "map resources that already exist to the existing ones")
+	importCmd.Flags().StringVar(&importRenamePrefix, "rename-prefix", "", "prefix prepended to the name of every imported resource")
+}
@@ -2,13 +2,27 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/deploy"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/hooks"
+	"github.com/onpremless/opcli/manifest"
 	"github.com/onpremless/opcli/ops"
+	"github.com/onpremless/opcli/promote"
+	"github.com/onpremless/opcli/templates"
 	"github.com/onpremless/opcli/tui/lambda"
+	"github.com/onpremless/opcli/tui/listing"
+	"github.com/onpremless/opcli/vcs"
 	"github.com/spf13/cobra"
 )
 
@@ -23,14 +37,33 @@ var lambdaCmd = &cobra.Command{
 var lambdaName string
 var lambdaRuntime string
 var lambdaType string
+var lambdaDestroyYes bool
+var lambdaDestroyDryRun bool
+var lambdaDestroyCascade bool
+var lambdaPreBuild string
+var lambdaPostDeploy string
+var lambdaDeployAllParallel int
+var lambdaDeployAllChangedSince string
+var lambdaDevEndpointName string
+var lambdaDevEndpointPath string
+var lambdaDevDebounce time.Duration
+var lambdaDevPollInterval time.Duration
+var lambdaListShowGit bool
+var lambdaPromoteFrom string
+var lambdaPromoteTo string
+var lambdaPromoteEndpoints bool
 
 type lambdaOps struct {
-	ctx context.Context
+	ctx    context.Context
+	client ops.API
+	// destroyed collects the lambdas Destroy destroyed, it must be set to
+	// destroy lambdas
+	destroyed *destroyedLambdas
 }
 
 func (op *lambdaOps) Create(name string, runtime string, lambdaType string, path string) tea.Cmd {
 	return func() tea.Msg {
-		l, err := ops.CreateLambda(op.ctx, ops.CreateLambdaM{
+		l, err := op.client.CreateLambda(op.ctx, ops.CreateLambdaM{
 			Name:       name,
 			Runtime:    runtime,
 			LambdaType: lambdaType,
@@ -47,7 +80,7 @@ func (op *lambdaOps) Create(name string, runtime string, lambdaType string, path
 
 func (op *lambdaOps) List() tea.Cmd {
 	return func() tea.Msg {
-		lambdas, err := ops.ListLambdas(op.ctx)
+		lambdas, err := op.client.ListLambdas(op.ctx)
 
 		return lambda.LambdaListResponseMsg{
 			Resp: &lambda.LambdaListResponse{
@@ -60,7 +93,7 @@ func (op *lambdaOps) List() tea.Cmd {
 
 func (op *lambdaOps) Start(id string) tea.Cmd {
 	return func() tea.Msg {
-		l, err := ops.StartLambda(op.ctx, id)
+		l, err := op.client.StartLambda(op.ctx, id)
 
 		return lambda.LambdaStartResponseMsg{
 			Resp: &lambda.LambdaStartResponse{
@@ -73,7 +106,10 @@ func (op *lambdaOps) Start(id string) tea.Cmd {
 
 func (op *lambdaOps) Destroy(id string) tea.Cmd {
 	return func() tea.Msg {
-		err := ops.DestroyLambda(op.ctx, id)
+		err := op.client.DestroyLambda(op.ctx, id)
+		if err == nil {
+			op.destroyed.add(id)
+		}
 
 		return lambda.LambdaDestroyResponseMsg{
 			Resp: &lambda.LambdaDestroyResponse{
@@ -83,11 +119,561 @@ func (op *lambdaOps) Destroy(id string) tea.Cmd {
 	}
 }
 
+func (op *lambdaOps) Describe(id string) tea.Cmd {
+	return func() tea.Msg {
+		resp := &lambda.LambdaDescribeResponse{}
+		resp.Lambda, resp.Err = op.client.GetLambda(op.ctx, id)
+		if resp.Err != nil {
+			return lambda.LambdaDescribeResponseMsg{Resp: resp}
+		}
+
+		// The runtime is informational only, it may have been removed since
+		resp.Runtime, _ = op.client.GetRuntime(op.ctx, resp.Lambda.Runtime)
+
+		endpts, err := op.client.ListEndpoints(op.ctx)
+		if err != nil {
+			resp.Err = err
+			return lambda.LambdaDescribeResponseMsg{Resp: resp}
+		}
+
+		for _, e := range endpts {
+			if e.Lambda == id {
+				resp.Endpoints = append(resp.Endpoints, e)
+			}
+		}
+
+		return lambda.LambdaDescribeResponseMsg{Resp: resp}
+	}
+}
+
+func (op *lambdaOps) DeleteEndpoints(ids []string) tea.Cmd {
+	return func() tea.Msg {
+		for _, id := range ids {
+			if err := op.client.DeleteEndpoint(op.ctx, id); err != nil {
+				return lambda.EndpointsDeleteResponseMsg{
+					Resp: &lambda.EndpointsDeleteResponse{Err: err},
+				}
+			}
+		}
+
+		return lambda.EndpointsDeleteResponseMsg{
+			Resp: &lambda.EndpointsDeleteResponse{},
+		}
+	}
+}
+
+type lambdaDevOps struct {
+	ctx          context.Context
+	client       ops.API
+	input        ops.CreateLambdaM
+	endpointName string
+	endpointPath string
+}
+
+func (op *lambdaDevOps) Digest(path string) tea.Cmd {
+	return func() tea.Msg {
+		digest, err := ops.SourceDigest(path)
+
+		return lambda.LambdaDevDigestMsg{Digest: digest, Err: err}
+	}
+}
+
+func (op *lambdaDevOps) Deploy(path string, previous []*api.Lambda) tea.Cmd {
+	return func() tea.Msg {
+		digest, err := ops.SourceDigest(path)
+		if err != nil {
+			return lambda.LambdaDevDeployResponseMsg{
+				Resp: &lambda.LambdaDevDeployResponse{Err: err},
+			}
+		}
+
+		l, err := op.client.DeployLambda(op.ctx, op.input, path)
+		if err != nil {
+			return lambda.LambdaDevDeployResponseMsg{
+				Resp: &lambda.LambdaDevDeployResponse{Err: err},
+			}
+		}
+
+		// Printing would garble the watcher, failures are reported with the
+		// response instead
+		resp := &lambda.LambdaDevDeployResponse{Lambda: l}
+		if err := recordDeployment(l, path, digest); err != nil {
+			resp.Err = fmt.Errorf("failed to record deployment of %s: %w", l.Id, err)
+		}
+
+		if op.endpointPath != "" {
+			resp.Endpoint, err = op.client.RouteEndpoint(op.ctx, op.endpointName, op.endpointPath, l.Id)
+			if err != nil {
+				resp.Err = errors.Join(resp.Err, fmt.Errorf("failed to route endpoint: %w", err))
+				return lambda.LambdaDevDeployResponseMsg{Resp: resp}
+			}
+		}
+
+		for _, p := range previous {
+			if err := op.client.DestroyLambda(op.ctx, p.Id); err != nil {
+				resp.Err = errors.Join(resp.Err, fmt.Errorf("failed to destroy previous lambda %s: %w", p.Id, err))
+				continue
+			}
+
+			resp.Destroyed = append(resp.Destroyed, p.Id)
+			if err := recordDestroy(p.Id); err != nil {
+				resp.Err = errors.Join(resp.Err, fmt.Errorf("failed to record destruction of %s: %w", p.Id, err))
+			}
+		}
+
+		return lambda.LambdaDevDeployResponseMsg{Resp: resp}
+	}
+}
+
+// lambdaDevOptions resolves what lambda dev deploys from the flags, falling
+// back to the lambda.yaml of the sources directory.
+func lambdaDevOptions(cmd *cobra.Command, dir string) (*lambdaDevOps, error) {
+	cfg, err := manifest.LoadLambda(dir)
+	if os.IsNotExist(err) {
+		cfg, err = &manifest.Lambda{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	name := firstNonEmpty(lambdaName, cfg.Name, filepath.Base(abs))
+	ltype := firstNonEmpty(lambdaType, cfg.Type, "ENDPOINT")
+
+	runtimeRef := firstNonEmpty(lambdaRuntime, cfg.Runtime)
+	if runtimeRef == "" {
+		return nil, fmt.Errorf("no runtime given, pass --runtime or set it in %s", manifest.LambdaFile)
+	}
+
+	rt, err := apiClient.FindRuntime(cmd.Context(), runtimeRef)
+	if err != nil {
+		return nil, err
+	}
+
+	op := &lambdaDevOps{
+		ctx:    cmd.Context(),
+		client: apiClient,
+		input: ops.CreateLambdaM{
+			Name:       name,
+			Runtime:    rt.Id,
+			LambdaType: ltype,
+		},
+	}
+
+	if ltype == "ENDPOINT" {
+		op.endpointName, op.endpointPath = name, "/"+name
+		if cfg.Endpoint != nil {
+			op.endpointName = firstNonEmpty(cfg.Endpoint.Name, op.endpointName)
+			op.endpointPath = firstNonEmpty(cfg.Endpoint.Path, op.endpointPath)
+		}
+	}
+	op.endpointName = firstNonEmpty(lambdaDevEndpointName, op.endpointName)
+	op.endpointPath = firstNonEmpty(lambdaDevEndpointPath, op.endpointPath)
+	if op.endpointPath != "" && op.endpointName == "" {
+		op.endpointName = name
+	}
+
+	return op, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+var lambdaDevCmd = &cobra.Command{
+	Use:   "dev <dir>",
+	Short: "Watch a sources directory and redeploy the lambda on changes",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		op, err := lambdaDevOptions(cmd, args[0])
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		m := &lambda.LambdaDevModel{
+			Path:         args[0],
+			Digester:     op,
+			Deployer:     op,
+			Debounce:     lambdaDevDebounce,
+			PollInterval: lambdaDevPollInterval,
+		}
+
+		p := tea.NewProgram(lambda.InitLambdaDevModel(m), programOptions()...)
+		if _, err := p.Run(); err != nil {
+			fmt.Printf("Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+type lambdaHookOps struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	dir    string
+	env    hooks.Env
+	done   chan struct{}
+}
+
+func (op *lambdaHookOps) RunHook(stage string, command string) <-chan tea.Msg {
+	msgs := make(chan tea.Msg)
+	lines := make(chan string)
+
+	var err error
+	op.done = make(chan struct{})
+	go func() {
+		err = hooks.Run(op.ctx, hooks.Hook{
+			Stage:   stage,
+			Command: command,
+			Dir:     op.dir,
+			Env:     op.env,
+		}, lines)
+		close(op.done)
+	}()
+
+	go func() {
+		// Once stopped nobody reads msgs anymore, the output is drained
+		// so that the hook can exit.
+		for line := range lines {
+			select {
+			case msgs <- lambda.LambdaHookOutputMsg{Line: line}:
+			case <-op.ctx.Done():
+			}
+		}
+		<-op.done
+		select {
+		case msgs <- lambda.LambdaHookDoneMsg{Err: err}:
+		case <-op.ctx.Done():
+		}
+	}()
+
+	return msgs
+}
+
+// stop kills the hook if it is still running, like when the program was
+// quit before it finished, and waits for it to exit.
+func (op *lambdaHookOps) stop() {
+	op.cancel()
+	if op.done != nil {
+		<-op.done
+	}
+}
+
+// lambdaHooks resolves the hook commands from the flags, falling back to the
+// lambda.yaml of the sources directory.
+func lambdaHooks(dir string) (*manifest.Lambda, manifest.Hooks, error) {
+	cfg, err := manifest.LoadLambda(dir)
+	if os.IsNotExist(err) {
+		cfg, err = &manifest.Lambda{}, nil
+	}
+	if err != nil {
+		return nil, manifest.Hooks{}, err
+	}
+
+	h := manifest.Hooks{}
+	if cfg.Hooks != nil {
+		h = *cfg.Hooks
+	}
+	h.PreBuild = firstNonEmpty(lambdaPreBuild, h.PreBuild)
+	h.PostDeploy = firstNonEmpty(lambdaPostDeploy, h.PostDeploy)
+
+	return cfg, h, nil
+}
+
+// runLambdaHook runs the hook command in its own program, exiting on
+// failure so that the deploy is aborted.
+func runLambdaHook(cmd *cobra.Command, stage string, command string, dir string, env hooks.Env) {
+	if command == "" {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	op := &lambdaHookOps{ctx: ctx, cancel: cancel, dir: dir, env: env}
+	m := &lambda.LambdaHookModel{
+		Stage:   stage,
+		Command: command,
+		Runner:  op,
+	}
+
+	fm, err := tea.NewProgram(lambda.InitLambdaHookModel(m), programOptions()...).Run()
+	op.stop()
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	if r, ok := fm.(interface{ GetErr() error }); ok && r.GetErr() != nil {
+		os.Exit(1)
+	}
+}
+
+// preBuild runs the pre-build hook of the sources directory and returns
+// the hooks along with the digest of the sources to be packaged.
+func preBuild(cmd *cobra.Command, dir string) (manifest.Hooks, string) {
+	cfg, h, err := lambdaHooks(dir)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	env := hooks.Env{
+		Name:    firstNonEmpty(lambdaName, cfg.Name),
+		Runtime: firstNonEmpty(lambdaRuntime, cfg.Runtime),
+	}
+	if h.PreBuild != "" {
+		env.Digest, err = ops.SourceDigest(dir)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	runLambdaHook(cmd, hooks.PreBuild, h.PreBuild, dir, env)
+
+	digest, err := ops.SourceDigest(dir)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	return h, digest
+}
+
+// recordDeployment adds the lambda to the local deployment history along
+// with the git state of its sources.
+func recordDeployment(l *api.Lambda, dir string, digest string) error {
+	store, err := history.Open()
+	if err != nil {
+		return err
+	}
+
+	return store.Add(history.New(currentContext.Server, l, dir, digest))
+}
+
+func warnRecordDeployment(l *api.Lambda, dir string, digest string) {
+	if err := recordDeployment(l, dir, digest); err != nil {
+		fmt.Printf("Warning: failed to record deployment of %s: %s\n", l.Id, err)
+	}
+}
+
+// recordDestroy records the destroyed lambda in the local history, so that
+// it is no longer part of the last applied state.
+func recordDestroy(id string) error {
+	store, err := history.Open()
+	if err != nil {
+		return err
+	}
+
+	return store.Destroy(currentContext.Server, id)
+}
+
+// destroyedLambdas collects the lambdas destroyed while a program runs. They
+// are recorded once it exits, so that warnings don't garble its output.
+type destroyedLambdas struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (d *destroyedLambdas) add(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.ids = append(d.ids, id)
+}
+
+func (d *destroyedLambdas) warnRecord() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, id := range d.ids {
+		if err := recordDestroy(id); err != nil {
+			fmt.Printf("Warning: failed to record destruction of %s: %s\n", id, err)
+		}
+	}
+	d.ids = nil
+}
+
+var deployResultColumns = []listing.Column{
+	{Key: "kind", Title: "Kind"},
+	{Key: "name", Title: "Name"},
+	{Key: "dir", Title: "Dir"},
+	{Key: "status", Title: "Status"},
+	{Key: "id", Title: "ID"},
+	{Key: "duration", Title: "Duration"},
+	{Key: "error", Title: "Error"},
+}
+
+func deployResultRows(results []deploy.Result) []table.Row {
+	rows := []table.Row{}
+	for _, r := range results {
+		kind, status, id, errMsg := "lambda", deploy.StatusDone, "", ""
+		if r.Target.Runtime != nil {
+			kind = "runtime"
+		}
+		if r.Lambda != nil {
+			id = r.Lambda.Id
+		}
+		if r.Runtime != nil {
+			id = r.Runtime.Id
+		}
+		if r.Err != nil {
+			status = deploy.StatusFailed
+			errMsg = strings.ReplaceAll(r.Err.Error(), "\n", " ")
+		}
+
+		rows = append(rows, table.Row{
+			kind,
+			r.Target.Name(),
+			r.Target.Dir,
+			status,
+			id,
+			r.Duration.Round(100 * time.Millisecond).String(),
+			errMsg,
+		})
+	}
+
+	return rows
+}
+
+// runDeployAll deploys the targets, showing the progress TUI on terminals,
+// prints the summary table and exits non-zero if any deploy failed.
+func runDeployAll(cmd *cobra.Command, targets []deploy.Target, parallel int) {
+	if len(targets) == 0 {
+		fmt.Println("Nothing to deploy")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	updates := make(chan deploy.Update)
+	resultsCh := make(chan []deploy.Result, 1)
+	go func() {
+		resultsCh <- deploy.Run(ctx, apiClient, targets, parallel, updates)
+	}()
+
+	if isTerminal() {
+		m := &lambda.LambdaBatchModel{
+			Targets:  targets,
+			Parallel: parallel,
+			Updates:  updates,
+		}
+		fm, err := tea.NewProgram(lambda.InitLambdaBatchModel(m), programOptions()...).Run()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		if r, ok := fm.(interface{ Interrupted() bool }); ok && r.Interrupted() {
+			cancel()
+		}
+	}
+
+	// Drain what the TUI did not consume, or everything when there is none
+	for u := range updates {
+		if !isTerminal() && u.Line == "" {
+			fmt.Printf("%s: %s\n", targets[u.Index].Name(), u.Status)
+		}
+	}
+
+	results := <-resultsCh
+	for _, r := range results {
+		if r.Lambda != nil {
+			warnRecordDeployment(r.Lambda, r.Target.Dir, r.Digest)
+		}
+		if r.Runtime != nil && r.Target.Runtime != nil {
+			warnRecordRuntimeBuild(r.Runtime, r.Target.Dir, r.Target.Runtime.Dockerfile, r.Target.Runtime.BuildArgs, r.Digest)
+		}
+	}
+
+	fmt.Println()
+	listing.WritePlain(os.Stdout, deployResultColumns, deployResultRows(results))
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("\n%d of %d deploy(s) failed\n", failed, len(results))
+		os.Exit(1)
+	}
+}
+
+// changedTargets narrows the lambdas down to the ones changed since the git
+// ref, adding the runtimes changed since then along with their dependents.
+func changedTargets(ctx context.Context, patterns []string, lambdas []deploy.Target, ref string) ([]deploy.Target, error) {
+	runtimes, err := deploy.DiscoverRuntimes(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := "."
+	for _, t := range lambdas {
+		if t.Dir != "" {
+			dir = t.Dir
+			break
+		}
+	}
+
+	changed, err := vcs.ChangedFiles(dir, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := apiClient.ListRuntimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return deploy.Changed(runtimes, lambdas, changed, known)
+}
+
+var lambdaDeployAllCmd = &cobra.Command{
+	Use:   "deploy-all <glob|dir|stack file>...",
+	Short: "Deploy every lambda directory holding a lambda.yaml",
+	Long: `Deploy every lambda directory holding a lambda.yaml, and every lambda of the
+stack files given, such as the one written by generate manifest.
+
+With --changed-since only the lambdas whose directory changed since the git
+revision are deployed. Runtime build contexts (directories holding a
+runtime.yaml) that changed are rebuilt first, and every lambda using them is
+redeployed as well.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		targets, err := deploy.Discover(args)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		if lambdaDeployAllChangedSince != "" {
+			targets, err = changedTargets(cmd.Context(), args, targets, lambdaDeployAllChangedSince)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		runDeployAll(cmd, targets, lambdaDeployAllParallel)
+	},
+}
+
 func lambdaCreateProgram(cmd *cobra.Command, args []string) *tea.Program {
 	var runtime *api.Runtime
 	if lambdaRuntime != "" {
 		var err error
-		runtime, err = ops.GetRuntime(cmd.Context(), lambdaRuntime)
+		runtime, err = apiClient.GetRuntime(cmd.Context(), lambdaRuntime)
 		if err != nil {
 			fmt.Printf("Failed to get runtime: %s", err)
 			os.Exit(1)
@@ -95,12 +681,14 @@ func lambdaCreateProgram(cmd *cobra.Command, args []string) *tea.Program {
 	}
 
 	m := &lambda.LambdaCreateModel{
-		Name:          lambdaName,
-		Runtime:       runtime,
-		LambdaType:    lambdaType,
-		Path:          args[0],
-		LambdaCreator: &lambdaOps{ctx: cmd.Context()},
-		RuntimeLister: &runtimeOps{ctx: cmd.Context()},
+		Name:           lambdaName,
+		Runtime:        runtime,
+		LambdaType:     lambdaType,
+		Path:           args[0],
+		LambdaCreator:  &lambdaOps{ctx: cmd.Context(), client: apiClient},
+		RuntimeLister:  &runtimeOps{ctx: cmd.Context(), client: apiClient},
+		LambdaLister:   &lambdaOps{ctx: cmd.Context(), client: apiClient},
+		RuntimeCreator: &runtimeOps{ctx: cmd.Context(), client: apiClient},
 	}
 
 	return tea.NewProgram(lambda.InitLambdaCreateModel(m))
@@ -111,12 +699,18 @@ var lambdaCreateCmd = &cobra.Command{
 	Short: "Create new lambda",
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		p := lambdaCreateProgram(cmd, args)
+		_, digest := preBuild(cmd, args[0])
 
-		if _, err := p.Run(); err != nil {
+		p := lambdaCreateProgram(cmd, args)
+		m, err := p.Run()
+		if err != nil {
 			fmt.Printf("Error: %s", err)
 			os.Exit(1)
 		}
+
+		if cm, ok := m.(interface{ GetLambda() *api.Lambda }); ok && cm.GetLambda() != nil {
+			warnRecordDeployment(cm.GetLambda(), args[0], digest)
+		}
 	},
 }
 
@@ -124,20 +718,75 @@ var lambdaListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List lambdas",
 	Run: func(cmd *cobra.Command, args []string) {
+		filter, sort := listOptions(lambda.LambdaListColumns(lambdaListShowGit))
+
+		var deployments map[string]history.Deployment
+		if lambdaListShowGit {
+			store, err := history.Open()
+			if err == nil {
+				deployments, err = store.Lambdas(currentContext.Server)
+			}
+			if err != nil {
+				fmt.Printf("Error: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
 		m := &lambda.LambdaListModel{
 			Lister: &lambdaOps{
-				ctx: cmd.Context(),
+				ctx:    cmd.Context(),
+				client: apiClient,
 			},
+			RuntimeLister: &runtimeOps{ctx: cmd.Context(), client: apiClient},
+			Filter:        filter,
+			Sort:          sort,
+			Plain:         !isTerminal(),
+			ShowGit:       lambdaListShowGit,
+			Deployments:   deployments,
 		}
-		p := tea.NewProgram(lambda.InitLambdaListModel(m))
 
-		if _, err := p.Run(); err != nil {
+		if err := runList(lambda.InitLambdaListModel(m)); err != nil {
 			fmt.Printf("Error: %s", err)
 			os.Exit(1)
 		}
 	},
 }
 
+var lambdaDescribeCmd = &cobra.Command{
+	Use:   "describe [id]",
+	Short: "Describe lambda, including the git state it has been deployed from",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := history.Open()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		d, err := store.Latest(currentContext.Server, args[0])
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		m := &lambda.LambdaDescribeModel{
+			LambdaID:   args[0],
+			Describer:  &lambdaOps{ctx: cmd.Context(), client: apiClient},
+			Deployment: d,
+		}
+
+		fm, err := tea.NewProgram(lambda.InitLambdaDescribeModel(m), programOptions()...).Run()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		if r, ok := fm.(interface{ GetErr() error }); ok && r.GetErr() != nil {
+			os.Exit(1)
+		}
+	},
+}
+
 var lambdaStartCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Start lambda",
@@ -145,7 +794,7 @@ var lambdaStartCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		m := &lambda.LambdaStartModel{
 			LambdaID: args[0],
-			Starter:  &lambdaOps{ctx: cmd.Context()},
+			Starter:  &lambdaOps{ctx: cmd.Context(), client: apiClient},
 		}
 
 		p := tea.NewProgram(lambda.InitLambdaStartModel(m))
@@ -161,6 +810,8 @@ var lambdaDeployCmd = &cobra.Command{
 	Short: "Deploy lambda, aka create + start",
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		h, digest := preBuild(cmd, args[0])
+
 		p := lambdaCreateProgram(cmd, args)
 		m, err := p.Run()
 		if err != nil {
@@ -168,57 +819,239 @@ var lambdaDeployCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		cm := m.(*lambda.LambdaCreateModel)
-		l := cm.GetLambda()
+		var l *api.Lambda
+		if cm, ok := m.(interface{ GetLambda() *api.Lambda }); ok {
+			l = cm.GetLambda()
+		}
 		if l == nil {
 			// The error has already been printed as part of the previous program output
 			os.Exit(1)
 		}
+		warnRecordDeployment(l, args[0], digest)
 
 		sm := &lambda.LambdaStartModel{
-			LambdaID: cm.GetLambda().Id,
-			Starter:  &lambdaOps{ctx: cmd.Context()},
+			LambdaID: l.Id,
+			Starter:  &lambdaOps{ctx: cmd.Context(), client: apiClient},
 		}
 
 		p = tea.NewProgram(lambda.InitLambdaStartModel(sm))
-		if _, err := p.Run(); err != nil {
+		fm, err := p.Run()
+		if err != nil {
 			fmt.Printf("Error: %s", err)
 			os.Exit(1)
 		}
+
+		if r, ok := fm.(interface{ GetErr() error }); ok && r.GetErr() != nil {
+			os.Exit(1)
+		}
+
+		runLambdaHook(cmd, hooks.PostDeploy, h.PostDeploy, args[0], hooks.Env{
+			Name:    l.Name,
+			ID:      l.Id,
+			Runtime: l.Runtime,
+			Digest:  digest,
+		})
 	},
 }
 
 var lambdaDestroyCmd = &cobra.Command{
-	Use:   "destroy",
+	Use:   "destroy [id]",
 	Short: "Destroy lambda",
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if !isInteractive() && !lambdaDestroyYes && !lambdaDestroyDryRun {
+			fmt.Println("Error: refusing to destroy without confirmation, pass --yes to skip it")
+			os.Exit(1)
+		}
+
+		destroyed := &destroyedLambdas{}
 		m := &lambda.LambdaDestroyModel{
-			LambdaID:  args[0],
-			Destroyer: &lambdaOps{ctx: cmd.Context()},
+			LambdaID:         args[0],
+			Destroyer:        &lambdaOps{ctx: cmd.Context(), client: apiClient, destroyed: destroyed},
+			Describer:        &lambdaOps{ctx: cmd.Context(), client: apiClient},
+			EndpointsDeleter: &lambdaOps{ctx: cmd.Context(), client: apiClient},
+			Yes:              lambdaDestroyYes,
+			DryRun:           lambdaDestroyDryRun,
+			Cascade:          lambdaDestroyCascade,
 		}
 
-		p := tea.NewProgram(lambda.InitLambdaDestroyModel(m))
-		if err := p.Start(); err != nil {
+		p := tea.NewProgram(lambda.InitLambdaDestroyModel(m), programOptions()...)
+		fm, err := p.Run()
+		destroyed.warnRecord()
+		if err != nil {
 			fmt.Printf("Error: %s", err)
 			os.Exit(1)
 		}
+
+		if r, ok := fm.(interface{ GetErr() error }); ok && r.GetErr() != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+var lambdaPromoteCmd = &cobra.Command{
+	Use:   "promote <id|name>",
+	Short: "Deploy a lambda of one context to another",
+	Long: `Deploy a lambda of one context to another, such as from staging to prod.
+
+The lambda is packaged again from the sources it was deployed from on this
+machine, which must not have changed since. Its runtime is looked up by name
+in the target context, and built from its recorded sources when missing.
+With --endpoints the endpoints routing to the lambda are re-created as well.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if lambdaPromoteTo == "" {
+			fmt.Println("Error: --to is required")
+			os.Exit(1)
+		}
+
+		fromContext, from, err := contextClient(lambdaPromoteFrom)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		toContext, to, err := contextClient(lambdaPromoteTo)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		if fromContext.Server == toContext.Server {
+			fmt.Printf("Error: both contexts point to %s\n", toContext.Server)
+			os.Exit(1)
+		}
+
+		store, err := history.Open()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		_, err = promote.Promote(cmd.Context(), from, to, store, args[0], promote.Options{
+			From:      fromContext.Server,
+			To:        toContext.Server,
+			Endpoints: lambdaPromoteEndpoints,
+			Report: func(msg string) {
+				fmt.Println(msg)
+			},
+		})
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var lambdaInitCmd = &cobra.Command{
+	Use:   "init [template] <dir>",
+	Short: "Scaffold a lambda project from a built-in template",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := args[len(args)-1]
+
+		var tmpl string
+		var err error
+		if len(args) > 1 {
+			tmpl = args[0]
+		} else {
+			tmpl, err = pickTemplate(templates.LambdaKind, "Lambda templates")
+		}
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		name := lambdaName
+		if name == "" {
+			name = filepath.Base(abs)
+		}
+		rt := lambdaRuntime
+		if rt == "" {
+			rt = tmpl
+		}
+		ltype := lambdaType
+		if ltype == "" {
+			ltype = "ENDPOINT"
+		}
+
+		manifestPath := filepath.Join(dir, manifest.LambdaFile)
+		if _, err := os.Stat(manifestPath); err == nil {
+			fmt.Printf("Error: file already exists: %s\n", manifestPath)
+			os.Exit(1)
+		}
+
+		written, err := templates.Render(templates.LambdaKind, tmpl, dir, templates.Data{Name: name, Runtime: rt})
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		l := manifest.Lambda{Name: name, Runtime: rt, Type: ltype}
+		if ltype == "ENDPOINT" {
+			l.Endpoint = &manifest.Endpoint{Name: name, Path: "/" + name}
+		}
+
+		if err := manifest.Write(manifestPath, l); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		printScaffolded(append(written, manifestPath))
 	},
 }
 
 func init() {
 	RootCmd.AddCommand(lambdaCmd)
+	lambdaCmd.AddCommand(lambdaInitCmd)
+	lambdaCmd.AddCommand(lambdaDevCmd)
+	lambdaCmd.AddCommand(lambdaDeployAllCmd)
 	lambdaCmd.AddCommand(lambdaDeployCmd)
 	lambdaCmd.AddCommand(lambdaCreateCmd)
 	lambdaCmd.AddCommand(lambdaListCmd)
+	lambdaCmd.AddCommand(lambdaDescribeCmd)
 	lambdaCmd.AddCommand(lambdaStartCmd)
 	lambdaCmd.AddCommand(lambdaDestroyCmd)
+	lambdaCmd.AddCommand(lambdaPromoteCmd)
 
 	lambdaCreateCmd.Flags().StringVarP(&lambdaName, "name", "n", "", "name")
 	lambdaCreateCmd.Flags().StringVarP(&lambdaRuntime, "runtime", "r", "", "runtime")
 	lambdaCreateCmd.Flags().StringVarP(&lambdaType, "type", "t", "", "type of lambda (ENDPOINT | INTERNAL)")
+	lambdaCreateCmd.Flags().StringVar(&lambdaPreBuild, "pre-build", "", "command run in the sources directory before packaging (default from lambda.yaml)")
 
 	lambdaDeployCmd.Flags().StringVarP(&lambdaName, "name", "n", "", "name")
 	lambdaDeployCmd.Flags().StringVarP(&lambdaRuntime, "runtime", "r", "", "runtime")
 	lambdaDeployCmd.Flags().StringVarP(&lambdaType, "type", "e", "", "type of lambda (ENDPOINT | INTERNAL)")
+	lambdaDeployCmd.Flags().StringVar(&lambdaPreBuild, "pre-build", "", "command run in the sources directory before packaging (default from lambda.yaml)")
+	lambdaDeployCmd.Flags().StringVar(&lambdaPostDeploy, "post-deploy", "", "command run in the sources directory once the lambda is started (default from lambda.yaml)")
+
+	addListFlags(lambdaListCmd)
+	lambdaListCmd.Flags().BoolVar(&lambdaListShowGit, "show-git", false, "show the git state each lambda has been deployed from")
+
+	lambdaDeployAllCmd.Flags().IntVarP(&lambdaDeployAllParallel, "parallel", "p", 4, "maximum number of concurrent deploys")
+	lambdaDeployAllCmd.Flags().StringVar(&lambdaDeployAllChangedSince, "changed-since", "", "only deploy what changed since the git revision")
+
+	lambdaDevCmd.Flags().StringVarP(&lambdaName, "name", "n", "", "lambda name (default from lambda.yaml or the directory name)")
+	lambdaDevCmd.Flags().StringVarP(&lambdaRuntime, "runtime", "r", "", "runtime ID or name (default from lambda.yaml)")
+	lambdaDevCmd.Flags().StringVarP(&lambdaType, "type", "t", "", "type of lambda (ENDPOINT | INTERNAL)")
+	lambdaDevCmd.Flags().StringVar(&lambdaDevEndpointName, "endpoint-name", "", "name of the endpoint kept pointed at the lambda")
+	lambdaDevCmd.Flags().StringVar(&lambdaDevEndpointPath, "endpoint-path", "", "path of the endpoint kept pointed at the lambda")
+	lambdaDevCmd.Flags().DurationVar(&lambdaDevDebounce, "debounce", lambda.DefaultDevDebounce, "how long the sources have to stay unchanged before deploying")
+	lambdaDevCmd.Flags().DurationVar(&lambdaDevPollInterval, "poll-interval", lambda.DefaultDevPollInterval, "how often the sources are checked for changes")
+
+	lambdaPromoteCmd.Flags().StringVar(&lambdaPromoteFrom, "from", "", "context to promote from (default the current context)")
+	lambdaPromoteCmd.Flags().StringVar(&lambdaPromoteTo, "to", "", "context to promote to")
+	lambdaPromoteCmd.Flags().BoolVar(&lambdaPromoteEndpoints, "endpoints", false, "re-create the endpoints routing to the lambda")
+
+	lambdaInitCmd.Flags().StringVarP(&lambdaName, "name", "n", "", "lambda name (default directory name)")
+	lambdaInitCmd.Flags().StringVarP(&lambdaRuntime, "runtime", "r", "", "runtime name (default template name)")
+	lambdaInitCmd.Flags().StringVarP(&lambdaType, "type", "t", "", "type of lambda (ENDPOINT | INTERNAL)")
+
+	lambdaDestroyCmd.Flags().BoolVarP(&lambdaDestroyYes, "yes", "y", false, "skip confirmation")
+	lambdaDestroyCmd.Flags().BoolVar(&lambdaDestroyDryRun, "dry-run", false, "only print what would be destroyed")
+	lambdaDestroyCmd.Flags().BoolVar(&lambdaDestroyCascade, "cascade", false, "delete endpoints routing to the lambda as well")
 }
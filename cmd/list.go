@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
+	"github.com/onpremless/opcli/tui/listing"
+	"github.com/spf13/cobra"
+)
+
+var listSortBy string
+var listFilter string
+
+func addListFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&listSortBy, "sort-by", "", "column to sort by, prefix with - for descending order")
+	cmd.Flags().StringVar(&listFilter, "filter", "", "comma separated column filters, e.g. name=hello,type=ENDPOINT")
+}
+
+func listOptions(cols []listing.Column) (listing.Filter, listing.Sort) {
+	filter, err := listing.ParseFilter(listFilter)
+	if err == nil {
+		err = filter.Validate(cols)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	sort, err := listing.ParseSort(cols, listSortBy)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	return filter, sort
+}
+
+func isTerminal() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// isInteractive reports whether stdin is a terminal, so that the programs
+// can read keys from it.
+func isInteractive() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// programOptions disables terminal input when stdin is not a terminal, so
+// that non-interactive flows can run in pipes and CI jobs.
+func programOptions() []tea.ProgramOption {
+	if isInteractive() {
+		return nil
+	}
+
+	return []tea.ProgramOption{tea.WithInput(nil)}
+}
+
+// runList runs a list program. When stdout is not a terminal the renderer is
+// disabled and only the final plain table is printed, so that the output can
+// be piped and parsed.
+func runList(m tea.Model) error {
+	if isTerminal() {
+		_, err := tea.NewProgram(m).Run()
+		return err
+	}
+
+	fm, err := tea.NewProgram(m, tea.WithInput(nil), tea.WithoutRenderer()).Run()
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(fm.View())
+	return nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onpremless/opcli/opcsrv/fake"
+	"github.com/spf13/cobra"
+)
+
+var mockServerAddr string
+var mockServerTaskLatency time.Duration
+var mockServerRuntimes []string
+var mockServerFailures []string
+
+// parseFailure parses "METHOD PATH [STATUS [MESSAGE]]", "*" matching any
+// method.
+func parseFailure(spec string) (fake.Failure, error) {
+	fields := strings.Fields(spec)
+	if len(fields) < 2 {
+		return fake.Failure{}, fmt.Errorf("invalid failure %q, expected \"METHOD PATH [STATUS [MESSAGE]]\"", spec)
+	}
+
+	f := fake.Failure{Method: fields[0], Path: fields[1], Message: "injected failure"}
+	if f.Method == "*" {
+		f.Method = ""
+	}
+
+	if len(fields) > 2 {
+		status, err := strconv.Atoi(fields[2])
+		if err != nil || status < 400 || status > 599 {
+			return fake.Failure{}, fmt.Errorf("invalid failure status %q, expected 4xx or 5xx", fields[2])
+		}
+		f.Status = status
+	}
+	if len(fields) > 3 {
+		f.Message = strings.Join(fields[3:], " ")
+	}
+
+	return f, nil
+}
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Serve an in-memory fake of the onpremless API",
+	Long: `Serve an in-memory fake of the onpremless API, for trying the CLI or
+testing scripts without a real server. Nothing is built or run, lambdas are
+marked RUNNING once started.
+
+Failures are injected with --fail "METHOD PATH [STATUS [MESSAGE]]", matching
+requests whose path starts with PATH ("*" matches any method). Without a
+status the start and destroy tasks of the matching requests fail instead,
+other requests are left alone:
+
+  opcli mock-server --runtime go --fail "POST /endpoint 500" --fail "POST /lambda/ "`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		srv := fake.New(fake.Options{TaskLatency: mockServerTaskLatency})
+
+		for _, spec := range mockServerFailures {
+			f, err := parseFailure(spec)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err)
+				os.Exit(1)
+			}
+			srv.Fail(f)
+		}
+
+		for _, name := range mockServerRuntimes {
+			rt := srv.AddRuntime(name)
+			fmt.Printf("Runtime %s (%s)\n", rt.Name, rt.Id)
+		}
+
+		fmt.Printf("Serving the onpremless API on http://%s\n", mockServerAddr)
+		if err := http.ListenAndServe(mockServerAddr, srv); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(mockServerCmd)
+
+	mockServerCmd.Flags().StringVar(&mockServerAddr, "addr", "127.0.0.1:8081", "address to listen on")
+	mockServerCmd.Flags().DurationVar(&mockServerTaskLatency, "task-latency", 0, "how long start and destroy tasks stay pending")
+	mockServerCmd.Flags().StringArrayVar(&mockServerRuntimes, "runtime", nil, "runtime created on startup, may be repeated")
+	mockServerCmd.Flags().StringArrayVar(&mockServerFailures, "fail", nil, "failure to inject, may be repeated")
+}
@@ -3,12 +3,73 @@ package cmd
 import (
 	"os"
 
+	"github.com/onpremless/opcli/config"
+	"github.com/onpremless/opcli/ops"
 	"github.com/spf13/cobra"
 )
 
+var contextName string
+
+// currentContext is the context selected with --context, $OPCLI_CONTEXT or
+// the config file, resolved before any command runs.
+var currentContext config.Context
+
+// apiClient talks to the server of the current context.
+var apiClient ops.API
+
 var RootCmd = &cobra.Command{
 	Use:   "cli",
 	Short: "Onpremless client",
+	// Errors past flag parsing are not about usage
+	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		name := contextName
+		if name == "" {
+			name = os.Getenv("OPCLI_CONTEXT")
+		}
+
+		currentContext, err = cfg.Context(name)
+		if err != nil {
+			return err
+		}
+
+		apiClient = newAPIClient(currentContext)
+
+		return nil
+	},
+}
+
+func newAPIClient(c config.Context) *ops.Client {
+	return ops.NewClient(
+		ops.WithBaseURL(c.Server),
+		ops.WithToken(c.Token),
+		ops.WithRetry(ops.DefaultRetryPolicy),
+	)
+}
+
+// contextClient returns the named context and a client to its server, the
+// current ones when name is empty.
+func contextClient(name string) (config.Context, ops.API, error) {
+	if name == "" {
+		return currentContext, apiClient, nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return config.Context{}, nil, err
+	}
+
+	c, err := cfg.Context(name)
+	if err != nil {
+		return config.Context{}, nil, err
+	}
+
+	return c, newAPIClient(c), nil
 }
 
 func Execute() {
@@ -20,4 +81,5 @@ func Execute() {
 
 func init() {
 	RootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	RootCmd.PersistentFlags().StringVar(&contextName, "context", "", "context to use (default the current context)")
 }
@@ -2,10 +2,18 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/dockerfile"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/manifest"
 	"github.com/onpremless/opcli/ops"
+	"github.com/onpremless/opcli/templates"
 	"github.com/onpremless/opcli/tui/runtime"
 	"github.com/spf13/cobra"
 )
@@ -18,15 +26,77 @@ var runtimeCmd = &cobra.Command{
 	},
 }
 
-var runtimeName string
+var (
+	runtimeName       string
+	runtimeDockerfile string
+	runtimeBuildArgs  []string
+	runtimeLintMode   string
+	runtimeLintOutput string
+)
+
+const (
+	lintOff   = "off"
+	lintWarn  = "warn"
+	lintError = "error"
+)
+
+func lintRuntime(path string, file string) (string, []dockerfile.Finding, error) {
+	name, err := ops.DockerfilePath(path, file)
+	if err != nil {
+		return "", nil, err
+	}
+
+	content, err := os.Open(name)
+	if err != nil {
+		return "", nil, err
+	}
+	defer content.Close()
+
+	findings, err := dockerfile.Lint(content)
+	return name, findings, err
+}
+
+// checkLint lints the runtime Dockerfile according to --lint: warn reports
+// the findings and fails on errors only, error fails on warnings as well.
+func checkLint(path string) error {
+	switch runtimeLintMode {
+	case lintOff:
+		return nil
+	case lintWarn, lintError:
+	default:
+		return fmt.Errorf("invalid --lint value %q, expected off, warn or error", runtimeLintMode)
+	}
+
+	name, findings, err := lintRuntime(path, runtimeDockerfile)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range findings {
+		fmt.Fprintln(os.Stderr, f.Format(name))
+	}
+
+	if dockerfile.HasErrors(findings) || runtimeLintMode == lintError && dockerfile.HasWarnings(findings) {
+		return fmt.Errorf("%s did not pass linting, see the findings above", name)
+	}
+
+	return nil
+}
 
 type runtimeOps struct {
-	ctx context.Context
+	ctx        context.Context
+	client     ops.API
+	dockerfile string
+	buildArgs  map[string]string
 }
 
 func (op *runtimeOps) Create(name string, path string) tea.Cmd {
 	return func() tea.Msg {
-		rt, err := ops.CreateRuntime(op.ctx, name, path)
+		rt, err := op.client.CreateRuntime(op.ctx, ops.CreateRuntimeM{
+			Name:       name,
+			Dockerfile: op.dockerfile,
+			BuildArgs:  op.buildArgs,
+		}, path)
 
 		return runtime.RuntimeCreateResponseMsg{
 			Resp: &runtime.RuntimeCreateResponse{
@@ -39,7 +109,7 @@ func (op *runtimeOps) Create(name string, path string) tea.Cmd {
 
 func (op *runtimeOps) List() tea.Cmd {
 	return func() tea.Msg {
-		rt, err := ops.ListRuntimes(op.ctx)
+		rt, err := op.client.ListRuntimes(op.ctx)
 
 		return runtime.RuntimeListResponseMsg{
 			Resp: &runtime.RuntimeListResponse{
@@ -51,46 +121,185 @@ func (op *runtimeOps) List() tea.Cmd {
 }
 
 var runtimeCreateCmd = &cobra.Command{
-	Use:   "create",
+	Use:   "create <dockerfile|context dir>",
 	Short: "Create",
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		buildArgs, err := ops.ParseBuildArgs(runtimeBuildArgs)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		if err := checkLint(args[0]); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		// The sources may change while the runtime builds
+		digest, err := ops.SourceDigest(args[0])
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
 		m := &runtime.RuntimeCreateModel{
 			Name: runtimeName,
 			Path: args[0],
 			Creator: &runtimeOps{
-				ctx: cmd.Context(),
+				ctx:        cmd.Context(),
+				client:     apiClient,
+				dockerfile: runtimeDockerfile,
+				buildArgs:  buildArgs,
 			},
 		}
 		p := tea.NewProgram(runtime.InitRuntimeCreateModel(m))
 
-		if _, err := p.Run(); err != nil {
+		final, err := p.Run()
+		if err != nil {
 			fmt.Printf("Error: %s", err)
+			os.Exit(1)
+		}
+
+		if cm, ok := final.(runtime.RuntimeCreateModel); ok && cm.GetRuntime() != nil {
+			warnRecordRuntimeBuild(cm.GetRuntime(), cm.Path, runtimeDockerfile, buildArgs, digest)
 		}
 	},
 }
 
+// recordRuntimeBuild adds the runtime to the local history, so that it can
+// be exported along with its Dockerfile.
+func recordRuntimeBuild(rt *api.Runtime, path string, dockerfile string, buildArgs map[string]string, digest string) error {
+	store, err := history.Open()
+	if err != nil {
+		return err
+	}
+
+	return store.AddRuntime(history.NewRuntimeBuild(currentContext.Server, rt, path, dockerfile, buildArgs, digest))
+}
+
+func warnRecordRuntimeBuild(rt *api.Runtime, path string, dockerfile string, buildArgs map[string]string, digest string) {
+	if err := recordRuntimeBuild(rt, path, dockerfile, buildArgs, digest); err != nil {
+		fmt.Printf("Warning: failed to record build of runtime %s: %s\n", rt.Id, err)
+	}
+}
+
 var runtimeListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List",
 	Run: func(cmd *cobra.Command, args []string) {
+		filter, sort := listOptions(runtime.RuntimeColumns)
 		m := &runtime.RuntimeListModel{
 			Lister: &runtimeOps{
-				ctx: cmd.Context(),
+				ctx:    cmd.Context(),
+				client: apiClient,
 			},
+			Filter: filter,
+			Sort:   sort,
+			Plain:  !isTerminal(),
 		}
-		p := tea.NewProgram(runtime.InitRuntimeListModel(m))
 
-		if _, err := p.Run(); err != nil {
+		if err := runList(runtime.InitRuntimeListModel(m)); err != nil {
 			fmt.Printf("Error: %s", err)
 		}
 	},
 }
 
+var runtimeLintCmd = &cobra.Command{
+	Use:   "lint <dockerfile|context dir>",
+	Short: "Lint a runtime Dockerfile",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, findings, err := lintRuntime(args[0], runtimeDockerfile)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		switch runtimeLintOutput {
+		case "json":
+			j, _ := json.MarshalIndent(findings, "", "  ")
+			fmt.Println(string(j))
+		case "text":
+			for _, f := range findings {
+				fmt.Println(f.Format(name))
+			}
+		default:
+			fmt.Printf("Error: invalid --output value %q, expected text or json\n", runtimeLintOutput)
+			os.Exit(1)
+		}
+
+		if dockerfile.HasErrors(findings) {
+			os.Exit(1)
+		}
+	},
+}
+
+var runtimeInitCmd = &cobra.Command{
+	Use:   "init [template] [dir]",
+	Short: "Scaffold a runtime Dockerfile from a built-in template",
+	Args:  cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) > 1 {
+			dir = args[1]
+		}
+
+		var tmpl string
+		var err error
+		if len(args) > 0 {
+			tmpl = args[0]
+		} else {
+			tmpl, err = pickTemplate(templates.RuntimeKind, "Runtime templates")
+		}
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		name := runtimeName
+		if name == "" {
+			name = tmpl
+		}
+
+		manifestPath := filepath.Join(dir, manifest.RuntimeFile)
+		if _, err := os.Stat(manifestPath); err == nil {
+			fmt.Printf("Error: file already exists: %s\n", manifestPath)
+			os.Exit(1)
+		}
+
+		written, err := templates.Render(templates.RuntimeKind, tmpl, dir, templates.Data{Name: name})
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		rt := manifest.Runtime{Name: name}
+		if err := manifest.Write(manifestPath, rt); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		printScaffolded(append(written, manifestPath))
+	},
+}
+
 func init() {
 	RootCmd.AddCommand(runtimeCmd)
 	runtimeCmd.AddCommand(runtimeCreateCmd)
 	runtimeCmd.AddCommand(runtimeListCmd)
+	runtimeCmd.AddCommand(runtimeInitCmd)
+	runtimeCmd.AddCommand(runtimeLintCmd)
 
 	runtimeCreateCmd.Flags().StringVarP(&runtimeName, "name", "n", "", "name")
+	runtimeCreateCmd.Flags().StringVarP(&runtimeDockerfile, "dockerfile", "f", "", "Dockerfile inside the build context directory (default \"Dockerfile\")")
+	runtimeCreateCmd.Flags().StringArrayVar(&runtimeBuildArgs, "build-arg", nil, "build arg as KEY=VAL, may be repeated")
+	runtimeCreateCmd.Flags().StringVar(&runtimeLintMode, "lint", lintWarn, "Dockerfile linting (off | warn | error)")
+
+	runtimeLintCmd.Flags().StringVarP(&runtimeDockerfile, "dockerfile", "f", "", "Dockerfile inside the build context directory (default \"Dockerfile\")")
+	runtimeLintCmd.Flags().StringVarP(&runtimeLintOutput, "output", "o", "text", "output format (text | json)")
+
+	addListFlags(runtimeListCmd)
+
+	runtimeInitCmd.Flags().StringVarP(&runtimeName, "name", "n", "", "runtime name (default template name)")
 }
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/templates"
+	"github.com/onpremless/opcli/tui/scaffold"
+)
+
+// pickTemplate asks for one of the kind templates interactively.
+func pickTemplate(kind string, title string) (string, error) {
+	list, err := templates.List(kind)
+	if err != nil {
+		return "", err
+	}
+
+	if !isTerminal() {
+		return "", errors.New("no template given, pass one of the template names")
+	}
+
+	m := &scaffold.TemplatePickerModel{
+		Title:     title,
+		Templates: list,
+	}
+
+	fm, err := tea.NewProgram(scaffold.InitTemplatePickerModel(m)).Run()
+	if err != nil {
+		return "", err
+	}
+
+	t := fm.(interface{ GetTemplate() *templates.Template }).GetTemplate()
+	if t == nil {
+		return "", errors.New("no template selected")
+	}
+
+	return t.Name, nil
+}
+
+func printScaffolded(written []string) {
+	for _, name := range written {
+		fmt.Printf("Created %s\n", name)
+	}
+}
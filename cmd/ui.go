@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/tui/dashboard"
+	"github.com/spf13/cobra"
+)
+
+var uiRefreshInterval time.Duration
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Interactive dashboard for browsing and managing resources",
+	Run: func(cmd *cobra.Command, args []string) {
+		destroyed := &destroyedLambdas{}
+		m := &dashboard.DashboardModel{
+			RuntimeLister:   &runtimeOps{ctx: cmd.Context(), client: apiClient},
+			LambdaLister:    &lambdaOps{ctx: cmd.Context(), client: apiClient},
+			EndpointLister:  &endpointOps{ctx: cmd.Context(), client: apiClient},
+			Starter:         &lambdaOps{ctx: cmd.Context(), client: apiClient},
+			Destroyer:       &lambdaOps{ctx: cmd.Context(), client: apiClient, destroyed: destroyed},
+			EndpointCreator: &endpointOps{ctx: cmd.Context(), client: apiClient},
+			RefreshInterval: uiRefreshInterval,
+		}
+
+		p := tea.NewProgram(dashboard.InitDashboardModel(m), tea.WithAltScreen())
+		_, err := p.Run()
+		destroyed.warnRecord()
+		if err != nil {
+			fmt.Printf("Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(uiCmd)
+
+	uiCmd.Flags().DurationVar(&uiRefreshInterval, "refresh", dashboard.DefaultRefreshInterval, "auto-refresh interval")
+}
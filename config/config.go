@@ -0,0 +1,135 @@
+// Package config stores the contexts opcli can talk to: named pairs of an
+// onpremless API server and the gateway serving its endpoints.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultContext = "default"
+	DefaultServer  = "http://localhost:8081"
+	DefaultGateway = "http://localhost:8080"
+)
+
+// Context is a single onpremless installation.
+type Context struct {
+	// Server is the base URL of the API server
+	Server string `yaml:"server"`
+	// Gateway is the base URL endpoints are served under
+	Gateway string `yaml:"gateway"`
+	// Token authenticates API requests as a bearer token
+	Token string `yaml:"token,omitempty"`
+}
+
+type Config struct {
+	CurrentContext string             `yaml:"current-context"`
+	Contexts       map[string]Context `yaml:"contexts"`
+}
+
+// Path returns the config file location, $OPCLI_CONFIG if set.
+func Path() (string, error) {
+	if p := os.Getenv("OPCLI_CONFIG"); p != "" {
+		return p, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "opcli", "config.yaml"), nil
+}
+
+// Load reads the config file. A missing file yields a config with a single
+// default context pointing to localhost. When the current context is unset
+// or no longer exists, the default context is current, or the first one by
+// name if there is no default context.
+func Load() (*Config, error) {
+	p, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	content, err := os.ReadFile(p)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(content, cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+
+	if cfg.Contexts == nil {
+		cfg.Contexts = map[string]Context{}
+	}
+	if _, ok := cfg.Contexts[DefaultContext]; !ok && len(cfg.Contexts) == 0 {
+		cfg.Contexts[DefaultContext] = Context{Server: DefaultServer, Gateway: DefaultGateway}
+	}
+	if _, ok := cfg.Contexts[cfg.CurrentContext]; !ok {
+		cfg.CurrentContext = DefaultContext
+		if _, ok := cfg.Contexts[DefaultContext]; !ok {
+			cfg.CurrentContext = cfg.Names()[0]
+		}
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) Save() error {
+	p, err := Path()
+	if err != nil {
+		return err
+	}
+
+	content, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	// Contexts may hold tokens
+	return os.WriteFile(p, content, 0600)
+}
+
+// Context returns the named context, the current one when name is empty.
+func (c *Config) Context(name string) (Context, error) {
+	if name == "" {
+		name = c.CurrentContext
+	}
+
+	ctx, ok := c.Contexts[name]
+	if !ok {
+		return Context{}, fmt.Errorf("unknown context: %s", name)
+	}
+
+	if ctx.Server == "" {
+		ctx.Server = DefaultServer
+	}
+	if ctx.Gateway == "" {
+		ctx.Gateway = DefaultGateway
+	}
+
+	return ctx, nil
+}
+
+// Names returns the context names, sorted.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
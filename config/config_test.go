@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCurrentContextFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no file", "", DefaultContext},
+		{"unset", "contexts:\n  prod: {server: http://prod}\n", "prod"},
+		{"deleted", "current-context: gone\ncontexts:\n  prod: {}\n  default: {}\n", DefaultContext},
+		{"first by name", "current-context: gone\ncontexts:\n  prod: {}\n  dev: {}\n", "dev"},
+		{"kept", "current-context: prod\ncontexts:\n  prod: {}\n  default: {}\n", "prod"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			t.Setenv("OPCLI_CONFIG", path)
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.CurrentContext != tt.want {
+				t.Errorf("CurrentContext = %q, want %q", cfg.CurrentContext, tt.want)
+			}
+			if _, err := cfg.Context(""); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
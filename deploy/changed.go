@@ -0,0 +1,71 @@
+package deploy
+
+import (
+	"path/filepath"
+	"strings"
+
+	api "github.com/onpremless/go-client"
+)
+
+// Changed narrows the runtime and lambda targets down to the ones holding
+// any of the changed files, given as absolute paths, plus the lambdas built
+// with a changed runtime, referenced by name or by the ID of one of the
+// known runtimes of the server. Changed runtimes come first in the result.
+func Changed(runtimes []Target, lambdas []Target, changed []string, known []api.Runtime) ([]Target, error) {
+	target := []Target{}
+	changedRuntimes := map[string]bool{}
+	for _, t := range runtimes {
+		ok, err := holdsAny(t.Dir, changed)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			changedRuntimes[t.Runtime.Name] = true
+			target = append(target, t)
+		}
+	}
+
+	for _, rt := range known {
+		if changedRuntimes[rt.Name] {
+			changedRuntimes[rt.Id] = true
+		}
+	}
+
+	for _, t := range lambdas {
+		ok, err := holdsAny(t.Dir, changed)
+		if err != nil {
+			return nil, err
+		}
+		if ok || changedRuntimes[t.Lambda.Runtime] {
+			target = append(target, t)
+		}
+	}
+
+	return target, nil
+}
+
+func holdsAny(dir string, files []string) (bool, error) {
+	// Sources not set in a stack file cannot change
+	if dir == "" {
+		return false, nil
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+
+	// Resolve symlinks so that paths match the ones reported by git
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+
+	prefix := abs + string(filepath.Separator)
+	for _, f := range files {
+		if f == abs || strings.HasPrefix(f, prefix) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
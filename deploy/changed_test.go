@@ -0,0 +1,91 @@
+package deploy
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/manifest"
+	"github.com/onpremless/opcli/vcs"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %s", args, out)
+	}
+}
+
+func writeLambda(t *testing.T, dir string, l manifest.Lambda) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Write(filepath.Join(dir, manifest.LambdaFile), l); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "handler.sh"), []byte("echo "+l.Name+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangedSinceCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q")
+
+	rt := filepath.Join(dir, "runtimes", "go")
+	os.MkdirAll(rt, 0755)
+	os.WriteFile(filepath.Join(rt, "Dockerfile"), []byte("FROM golang\n"), 0644)
+	if err := manifest.Write(filepath.Join(rt, manifest.RuntimeFile), manifest.Runtime{Name: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	writeLambda(t, filepath.Join(dir, "lambdas", "hello"), manifest.Lambda{Name: "hello", Runtime: "go"})
+	writeLambda(t, filepath.Join(dir, "lambdas", "pinned"), manifest.Lambda{Name: "pinned", Runtime: "runtime-7"})
+	writeLambda(t, filepath.Join(dir, "lambdas", "other"), manifest.Lambda{Name: "other", Runtime: "python"})
+	writeLambda(t, filepath.Join(dir, "lambdas", "héllo"), manifest.Lambda{Name: "héllo", Runtime: "python"})
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-q", "-m", "initial")
+
+	os.WriteFile(filepath.Join(rt, "Dockerfile"), []byte("FROM golang:1.21\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "lambdas", "héllo", "handler.sh"), []byte("echo edited\n"), 0644)
+	writeLambda(t, filepath.Join(dir, "lambdas", "new"), manifest.Lambda{Name: "new", Runtime: "python"})
+
+	runtimes, err := DiscoverRuntimes([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lambdas, err := Discover([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := vcs.ChangedFiles(dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	known := []api.Runtime{{Id: "runtime-7", Name: "go"}, {Id: "runtime-8", Name: "python"}}
+	targets, err := Changed(runtimes, lambdas, changed, known)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, t := range targets {
+		names = append(names, t.Name())
+	}
+	want := []string{"go", "hello", "héllo", "new", "pinned"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Changed() = %q, want %q (changed files %q)", names, want, changed)
+	}
+}
@@ -0,0 +1,503 @@
+// Package deploy deploys many lambda source directories, each described by
+// its lambda.yaml or by a stack file, with bounded parallelism.
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/hooks"
+	"github.com/onpremless/opcli/manifest"
+	"github.com/onpremless/opcli/ops"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusBuilding   = "building"
+	StatusDeploying  = "deploying"
+	StatusRouting    = "routing"
+	StatusPostDeploy = "post-deploy"
+	StatusDone       = "done"
+	StatusFailed     = "failed"
+)
+
+// skipDirs are never descended into while discovering lambdas.
+var skipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// Target is a lambda sources directory, or a runtime build context when
+// Runtime is set.
+type Target struct {
+	// Dir is empty for targets of a stack file whose sources are not set
+	Dir     string
+	Lambda  manifest.Lambda
+	Runtime *manifest.Runtime
+}
+
+func (t Target) Name() string {
+	if t.Runtime != nil {
+		return t.Runtime.Name
+	}
+
+	return t.Lambda.Name
+}
+
+// Discover finds the lambda directories, the ones holding a lambda.yaml,
+// matched by the patterns. A pattern naming a directory is searched
+// recursively, one naming a file is read as a stack file, see
+// manifest.Stack, anything else is treated as a glob.
+func Discover(patterns []string) ([]Target, error) {
+	dirs, err := discover(patterns, manifest.LambdaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []Target{}
+	seen := map[string]string{}
+	for _, file := range stackFiles(patterns) {
+		s, err := manifest.LoadStack(file)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(s.Lambdas))
+		for name := range s.Lambdas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if other, ok := seen[name]; ok {
+				return nil, fmt.Errorf("lambda %s is declared by both %s and %s", name, other, file)
+			}
+			seen[name] = file
+
+			l := s.Lambdas[name]
+			targets = append(targets, Target{
+				Dir:    stackSource(file, l.Source),
+				Lambda: manifest.Lambda{Name: name, Runtime: l.Runtime, Type: l.Type, Endpoints: l.Endpoints},
+			})
+		}
+	}
+
+	for _, dir := range dirs {
+		l, err := manifest.LoadLambda(dir)
+		if err != nil {
+			return nil, err
+		}
+		if l.Name == "" {
+			l.Name = filepath.Base(dir)
+		}
+		if other, ok := seen[l.Name]; ok {
+			return nil, fmt.Errorf("lambda %s is declared by both %s and %s", l.Name, other, dir)
+		}
+		seen[l.Name] = dir
+
+		targets = append(targets, Target{Dir: dir, Lambda: *l})
+	}
+
+	return targets, nil
+}
+
+// DiscoverRuntimes finds the runtime build contexts, the directories holding
+// a runtime.yaml, the same way Discover finds lambdas.
+func DiscoverRuntimes(patterns []string) ([]Target, error) {
+	dirs, err := discover(patterns, manifest.RuntimeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []Target{}
+	seen := map[string]string{}
+	for _, file := range stackFiles(patterns) {
+		s, err := manifest.LoadStack(file)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(s.Runtimes))
+		for name := range s.Runtimes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if other, ok := seen[name]; ok {
+				return nil, fmt.Errorf("runtime %s is declared by both %s and %s", name, other, file)
+			}
+			seen[name] = file
+
+			rt := s.Runtimes[name]
+			targets = append(targets, Target{
+				Dir:     stackSource(file, rt.Source),
+				Runtime: &manifest.Runtime{Name: name, Dockerfile: rt.Dockerfile, BuildArgs: rt.BuildArgs},
+			})
+		}
+	}
+
+	for _, dir := range dirs {
+		rt, err := manifest.LoadRuntime(dir)
+		if err != nil {
+			return nil, err
+		}
+		if rt.Name == "" {
+			rt.Name = filepath.Base(dir)
+		}
+		if other, ok := seen[rt.Name]; ok {
+			return nil, fmt.Errorf("runtime %s is declared by both %s and %s", rt.Name, other, dir)
+		}
+		seen[rt.Name] = dir
+
+		targets = append(targets, Target{Dir: dir, Runtime: rt})
+	}
+
+	return targets, nil
+}
+
+// stackFiles returns the patterns naming a regular file.
+func stackFiles(patterns []string) []string {
+	files := []string{}
+	for _, pattern := range patterns {
+		if info, err := os.Stat(pattern); err == nil && info.Mode().IsRegular() {
+			files = append(files, pattern)
+		}
+	}
+
+	return files
+}
+
+// stackSource resolves the source of a stack file entry relative to the
+// file, empty if it is not set.
+func stackSource(file string, source string) string {
+	if source == "" || source == manifest.SourcePlaceholder {
+		return ""
+	}
+	if filepath.IsAbs(source) {
+		return source
+	}
+
+	return filepath.Join(filepath.Dir(file), source)
+}
+
+func discover(patterns []string, file string) ([]string, error) {
+	dirs := map[string]bool{}
+	for _, pattern := range patterns {
+		if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+			if err := discoverDir(pattern, file, dirs); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if info, err := os.Stat(pattern); err == nil && info.Mode().IsRegular() {
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if _, err := os.Stat(filepath.Join(m, file)); err == nil {
+				dirs[filepath.Clean(m)] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func discoverDir(root string, file string, dirs map[string]bool) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+			return filepath.SkipDir
+		}
+
+		if _, err := os.Stat(filepath.Join(path, file)); err == nil {
+			dirs[filepath.Clean(path)] = true
+			// Lambdas and runtimes do not nest
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+}
+
+// Update reports the progress of the target at Index.
+type Update struct {
+	Index  int
+	Status string
+	// Line is a line of hook output, set with the building and post-deploy
+	// statuses
+	Line string
+	Err  error
+}
+
+type Result struct {
+	Target    Target
+	Runtime   *api.Runtime
+	Lambda    *api.Lambda
+	Endpoints []api.Endpoint
+	Digest    string
+	Duration  time.Duration
+	Err       error
+}
+
+// Run deploys the targets with at most parallel deploys at a time. Runtime
+// targets are built first, so that the lambdas deployed afterwards use the
+// new runtimes. Updates are sent to updates, if not nil, which is closed once
+// all deploys finish.
+func Run(ctx context.Context, client ops.API, targets []Target, parallel int, updates chan<- Update) []Result {
+	if parallel <= 0 {
+		parallel = 1
+	}
+
+	results := make([]Result, len(targets))
+	runtimes := &runtimeCache{client: client, created: map[string]*api.Runtime{}, failed: map[string]bool{}}
+
+	runtimeTargets, lambdaTargets := []int{}, []int{}
+	for i, t := range targets {
+		if t.Runtime != nil {
+			runtimeTargets = append(runtimeTargets, i)
+		} else {
+			lambdaTargets = append(lambdaTargets, i)
+		}
+	}
+
+	for _, phase := range [][]int{runtimeTargets, lambdaTargets} {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, parallel)
+		for _, i := range phase {
+			wg.Add(1)
+			go func(i int, t Target) {
+				defer wg.Done()
+
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				report := func(u Update) {
+					u.Index = i
+					if updates != nil {
+						updates <- u
+					}
+				}
+
+				start := time.Now()
+				if t.Runtime != nil {
+					results[i] = buildRuntime(ctx, client, t, runtimes, report)
+				} else {
+					results[i] = deployTarget(ctx, client, t, runtimes, report)
+				}
+				results[i].Target = t
+				results[i].Duration = time.Since(start)
+
+				if results[i].Err != nil {
+					report(Update{Status: StatusFailed, Err: results[i].Err})
+				} else {
+					report(Update{Status: StatusDone})
+				}
+			}(i, targets[i])
+		}
+		wg.Wait()
+	}
+
+	if updates != nil {
+		close(updates)
+	}
+
+	return results
+}
+
+func buildRuntime(ctx context.Context, client ops.API, t Target, runtimes *runtimeCache, report func(Update)) Result {
+	res := Result{}
+	if t.Dir == "" {
+		res.Err = fmt.Errorf("sources of runtime %s are not set", t.Runtime.Name)
+		runtimes.add(t.Runtime.Name, nil)
+		return res
+	}
+
+	report(Update{Status: StatusBuilding})
+
+	res.Digest, res.Err = ops.SourceDigest(t.Dir)
+	if res.Err != nil {
+		return res
+	}
+
+	res.Runtime, res.Err = client.CreateRuntime(ctx, ops.CreateRuntimeM{
+		Name:       t.Runtime.Name,
+		Dockerfile: t.Runtime.Dockerfile,
+		BuildArgs:  t.Runtime.BuildArgs,
+	}, t.Dir)
+	runtimes.add(t.Runtime.Name, res.Runtime)
+
+	return res
+}
+
+func deployTarget(ctx context.Context, client ops.API, t Target, runtimes *runtimeCache, report func(Update)) Result {
+	res := Result{}
+	h := manifest.Hooks{}
+	if t.Lambda.Hooks != nil {
+		h = *t.Lambda.Hooks
+	}
+
+	if t.Dir == "" {
+		res.Err = fmt.Errorf("sources of lambda %s are not set", t.Lambda.Name)
+		return res
+	}
+	if t.Lambda.Runtime == "" {
+		res.Err = fmt.Errorf("no runtime set in %s", filepath.Join(t.Dir, manifest.LambdaFile))
+		return res
+	}
+
+	env := hooks.Env{Name: t.Lambda.Name, Runtime: t.Lambda.Runtime}
+	if h.PreBuild != "" {
+		report(Update{Status: StatusBuilding})
+		env.Digest, res.Err = ops.SourceDigest(t.Dir)
+		if res.Err != nil {
+			return res
+		}
+		if res.Err = runHook(ctx, hooks.PreBuild, h.PreBuild, t.Dir, env, StatusBuilding, report); res.Err != nil {
+			return res
+		}
+	}
+
+	report(Update{Status: StatusDeploying})
+
+	res.Digest, res.Err = ops.SourceDigest(t.Dir)
+	if res.Err != nil {
+		return res
+	}
+
+	rt, err := runtimes.find(ctx, t.Lambda.Runtime)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	ltype := t.Lambda.Type
+	if ltype == "" {
+		ltype = "ENDPOINT"
+	}
+
+	res.Lambda, res.Err = client.DeployLambda(ctx, ops.CreateLambdaM{
+		Name:       t.Lambda.Name,
+		Runtime:    rt.Id,
+		LambdaType: ltype,
+	}, t.Dir)
+	if res.Err != nil {
+		return res
+	}
+
+	for _, e := range t.Lambda.Routes() {
+		report(Update{Status: StatusRouting})
+
+		endpoint, err := client.RouteEndpoint(ctx, e.Name, e.Path, res.Lambda.Id)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		res.Endpoints = append(res.Endpoints, *endpoint)
+	}
+
+	if h.PostDeploy != "" {
+		report(Update{Status: StatusPostDeploy})
+		env = hooks.Env{Name: res.Lambda.Name, ID: res.Lambda.Id, Runtime: res.Lambda.Runtime, Digest: res.Digest}
+		res.Err = runHook(ctx, hooks.PostDeploy, h.PostDeploy, t.Dir, env, StatusPostDeploy, report)
+	}
+
+	return res
+}
+
+func runHook(ctx context.Context, stage string, command string, dir string, env hooks.Env, status string, report func(Update)) error {
+	lines := make(chan string)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for line := range lines {
+			report(Update{Status: status, Line: line})
+		}
+	}()
+
+	err := hooks.Run(ctx, hooks.Hook{Stage: stage, Command: command, Dir: dir, Env: env}, lines)
+	<-forwarded
+
+	return err
+}
+
+// runtimeCache lists the runtimes once for all the deploys of a run, and
+// keeps track of the runtimes built during it, which take precedence over
+// older runtimes of the same name.
+type runtimeCache struct {
+	client ops.API
+
+	once     sync.Once
+	runtimes []api.Runtime
+	err      error
+
+	mu      sync.Mutex
+	created map[string]*api.Runtime
+	failed  map[string]bool
+}
+
+// add records the outcome of a runtime build, rt is nil if it failed.
+func (c *runtimeCache) add(name string, rt *api.Runtime) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if rt == nil {
+		c.failed[name] = true
+		return
+	}
+
+	c.created[name] = rt
+}
+
+func (c *runtimeCache) find(ctx context.Context, ref string) (*api.Runtime, error) {
+	c.mu.Lock()
+	rt, failed := c.created[ref], c.failed[ref]
+	c.mu.Unlock()
+
+	if failed {
+		return nil, fmt.Errorf("runtime %s failed to build", ref)
+	}
+	if rt != nil {
+		return rt, nil
+	}
+
+	c.once.Do(func() {
+		c.runtimes, c.err = c.client.ListRuntimes(ctx)
+	})
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	for i, rt := range c.runtimes {
+		if rt.Id == ref || rt.Name == ref {
+			return &c.runtimes[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("runtime not found: %s", ref)
+}
@@ -0,0 +1,71 @@
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/onpremless/opcli/manifest"
+)
+
+func TestDiscoverStackFile(t *testing.T) {
+	dir := t.TempDir()
+	stack := &manifest.Stack{
+		Runtimes: map[string]manifest.StackRuntime{
+			"go":    {Source: "runtimes/go/Dockerfile", BuildArgs: map[string]string{"VERSION": "1.21"}},
+			"shell": {Source: manifest.SourcePlaceholder},
+		},
+		Lambdas: map[string]manifest.StackLambda{
+			"hello": {Source: "lambdas/hello", Runtime: "go", Type: "ENDPOINT", Endpoints: []manifest.Endpoint{{Name: "hello", Path: "/hello"}, {Path: "/hi"}}},
+			"cron":  {Source: manifest.SourcePlaceholder, Runtime: "shell", Type: "INTERNAL"},
+		},
+	}
+	file := filepath.Join(dir, "opcli.yaml")
+	if err := manifest.WriteStack(file, stack, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Per directory manifests are discovered along with the stack file
+	other := filepath.Join(dir, "other")
+	os.MkdirAll(other, 0755)
+	if err := manifest.Write(filepath.Join(other, manifest.LambdaFile), manifest.Lambda{Name: "other", Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	lambdas, err := Discover([]string{file, other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Target{
+		{Dir: "", Lambda: manifest.Lambda{Name: "cron", Runtime: "shell", Type: "INTERNAL"}},
+		{Dir: filepath.Join(dir, "lambdas/hello"), Lambda: manifest.Lambda{Name: "hello", Runtime: "go", Type: "ENDPOINT", Endpoints: stack.Lambdas["hello"].Endpoints}},
+		{Dir: other, Lambda: manifest.Lambda{Name: "other", Runtime: "go"}},
+	}
+	if !reflect.DeepEqual(lambdas, want) {
+		t.Errorf("Discover() =\n%+v\nwant\n%+v", lambdas, want)
+	}
+
+	routes := lambdas[1].Lambda.Routes()
+	if wantRoutes := []manifest.Endpoint{{Name: "hello", Path: "/hello"}, {Name: "hello", Path: "/hi"}}; !reflect.DeepEqual(routes, wantRoutes) {
+		t.Errorf("Routes() = %+v, want %+v", routes, wantRoutes)
+	}
+
+	runtimes, err := DiscoverRuntimes([]string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runtimes) != 2 || runtimes[0].Dir != filepath.Join(dir, "runtimes/go/Dockerfile") || runtimes[0].Runtime.BuildArgs["VERSION"] != "1.21" || runtimes[1].Dir != "" {
+		t.Errorf("DiscoverRuntimes() = %+v", runtimes)
+	}
+
+	if _, err := Discover([]string{file, file}); err == nil {
+		t.Error("discovered a lambda declared twice")
+	}
+
+	res := deployTarget(context.Background(), nil, lambdas[0], nil, func(Update) {})
+	if res.Err == nil {
+		t.Error("deployed a lambda whose sources are not set")
+	}
+}
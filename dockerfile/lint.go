@@ -0,0 +1,270 @@
+package dockerfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Finding is a single lint result. Line is 0 for findings about the
+// Dockerfile as a whole.
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+}
+
+// Format renders the finding as a compiler-style line for the named file.
+func (f Finding) Format(name string) string {
+	if f.Line == 0 {
+		return fmt.Sprintf("%s: %s [%s] %s", name, f.Severity, f.Rule, f.Message)
+	}
+
+	return fmt.Sprintf("%s:%d: %s [%s] %s", name, f.Line, f.Severity, f.Rule, f.Message)
+}
+
+// HasErrors reports whether any of the findings is an error.
+func HasErrors(findings []Finding) bool {
+	return hasSeverity(findings, SeverityError)
+}
+
+// HasWarnings reports whether any of the findings is an error or a warning.
+func HasWarnings(findings []Finding) bool {
+	return hasSeverity(findings, SeverityError) || hasSeverity(findings, SeverityWarning)
+}
+
+func hasSeverity(findings []Finding, severity string) bool {
+	for _, f := range findings {
+		if f.Severity == severity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lint parses the Dockerfile and checks it. Syntax errors are reported as
+// findings rather than returned.
+func Lint(r io.Reader) ([]Finding, error) {
+	parsed, err := Parse(r)
+
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return []Finding{{
+			Rule:     "syntax",
+			Severity: SeverityError,
+			Line:     syntaxErr.Line,
+			Message:  syntaxErr.Msg,
+		}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return LintInstructions(parsed), nil
+}
+
+func LintInstructions(parsed []Instruction) []Finding {
+	findings := []Finding{}
+	findings = append(findings, lintFrom(parsed)...)
+	findings = append(findings, lintAdd(parsed)...)
+	findings = append(findings, lintUser(parsed)...)
+	findings = append(findings, lintPackages(parsed)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+
+	return findings
+}
+
+func lintFrom(parsed []Instruction) []Finding {
+	findings := []Finding{}
+	stages := map[string]bool{}
+	seenFrom := false
+
+	for _, i := range parsed {
+		if i.Cmd != "FROM" {
+			if !seenFrom && i.Cmd != "ARG" {
+				findings = append(findings, Finding{
+					Rule:     "missing-from",
+					Severity: SeverityError,
+					Line:     i.Line,
+					Message:  fmt.Sprintf("%s before the first FROM, only ARG may precede it", i.Cmd),
+				})
+			}
+			continue
+		}
+		seenFrom = true
+
+		_, args := i.Flags()
+		if len(args) == 0 {
+			continue
+		}
+
+		image := args[0]
+		previousStage := stages[strings.ToLower(image)]
+		if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+			stages[strings.ToLower(args[2])] = true
+		}
+
+		if previousStage || image == "scratch" || strings.Contains(image, "$") || strings.Contains(image, "@") {
+			continue
+		}
+
+		tag := ""
+		if slash := strings.LastIndex(image, "/"); strings.LastIndex(image, ":") > slash {
+			tag = image[strings.LastIndex(image, ":")+1:]
+		}
+
+		if tag == "" || tag == "latest" {
+			findings = append(findings, Finding{
+				Rule:     "latest-tag",
+				Severity: SeverityWarning,
+				Line:     i.Line,
+				Message:  fmt.Sprintf("image %s is not pinned to a version tag", image),
+			})
+		}
+	}
+
+	if !seenFrom {
+		findings = append(findings, Finding{
+			Rule:     "missing-from",
+			Severity: SeverityError,
+			Message:  "no FROM instruction",
+		})
+	}
+
+	return findings
+}
+
+func lintAdd(parsed []Instruction) []Finding {
+	findings := []Finding{}
+	for _, i := range parsed {
+		if i.Cmd != "ADD" {
+			continue
+		}
+
+		_, args := i.Flags()
+		for _, src := range args[:max(len(args)-1, 0)] {
+			if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+				findings = append(findings, Finding{
+					Rule:     "remote-add",
+					Severity: SeverityWarning,
+					Line:     i.Line,
+					Message:  fmt.Sprintf("ADD of remote URL %s, download it with RUN and verify its checksum instead", src),
+				})
+			}
+		}
+	}
+
+	return findings
+}
+
+func lintUser(parsed []Instruction) []Finding {
+	var user *Instruction
+	for n, i := range parsed {
+		switch i.Cmd {
+		case "FROM":
+			user = nil
+		case "USER":
+			user = &parsed[n]
+		}
+	}
+
+	if user == nil {
+		return []Finding{{
+			Rule:     "root-user",
+			Severity: SeverityWarning,
+			Message:  "the final stage runs as root, add a USER instruction",
+		}}
+	}
+
+	name, _, _ := strings.Cut(user.Args, ":")
+	if name == "root" || name == "0" {
+		return []Finding{{
+			Rule:     "root-user",
+			Severity: SeverityWarning,
+			Line:     user.Line,
+			Message:  "the final stage runs as root",
+		}}
+	}
+
+	return nil
+}
+
+var (
+	aptInstallRe = regexp.MustCompile(`\b(apt-get|apt)\s+(?:-\S+\s+)*install\b`)
+	apkAddRe     = regexp.MustCompile(`\bapk\s+(?:-\S+\s+)*add\b`)
+	yumInstallRe = regexp.MustCompile(`\b(yum|dnf|microdnf)\s+(?:-\S+\s+)*install\b`)
+	pipInstallRe = regexp.MustCompile(`\bpip3?\s+(?:-\S+\s+)*install\b`)
+	rpmVersionRe = regexp.MustCompile(`-\d`)
+)
+
+type packageManager struct {
+	re *regexp.Regexp
+	// pinned reports whether a package argument carries a version
+	pinned func(pkg string) bool
+}
+
+var packageManagers = []packageManager{
+	{aptInstallRe, func(pkg string) bool { return strings.Contains(pkg, "=") }},
+	{apkAddRe, func(pkg string) bool { return strings.Contains(pkg, "=") }},
+	{yumInstallRe, func(pkg string) bool { return rpmVersionRe.MatchString(pkg) }},
+	{pipInstallRe, func(pkg string) bool { return strings.ContainsAny(pkg, "=<>~") }},
+}
+
+func lintPackages(parsed []Instruction) []Finding {
+	findings := []Finding{}
+	for _, i := range parsed {
+		if i.Cmd != "RUN" {
+			continue
+		}
+
+		for _, command := range splitCommands(i.Args) {
+			for _, pm := range packageManagers {
+				loc := pm.re.FindStringIndex(command)
+				if loc == nil {
+					continue
+				}
+
+				unpinned := []string{}
+				for _, arg := range strings.Fields(command[loc[1]:]) {
+					if strings.HasPrefix(arg, "-") || strings.ContainsAny(arg, "/.$") && !strings.ContainsAny(arg, "=<>~") {
+						continue
+					}
+					if !pm.pinned(arg) {
+						unpinned = append(unpinned, arg)
+					}
+				}
+
+				if len(unpinned) > 0 {
+					findings = append(findings, Finding{
+						Rule:     "unpinned-packages",
+						Severity: SeverityInfo,
+						Line:     i.Line,
+						Message:  fmt.Sprintf("packages without a pinned version: %s", strings.Join(unpinned, ", ")),
+					})
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+var commandSeparatorRe = regexp.MustCompile(`&&|\|\||;|\|`)
+
+func splitCommands(script string) []string {
+	return commandSeparatorRe.Split(script, -1)
+}
@@ -0,0 +1,103 @@
+package dockerfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Finding
+	}{
+		{
+			name:    "clean",
+			content: "ARG VERSION=3.19\nFROM alpine:${VERSION}\nRUN apk add curl=8.5.0-r0\nUSER app\n",
+			want:    []Finding{},
+		},
+		{
+			name:    "syntax",
+			content: "FROM alpine:3.19\nRUN echo \\\n",
+			want: []Finding{
+				{Rule: "syntax", Severity: SeverityError, Line: 2, Message: "unterminated line continuation"},
+			},
+		},
+		{
+			name:    "missing-from",
+			content: "RUN echo hi\nUSER app\n",
+			want: []Finding{
+				{Rule: "missing-from", Severity: SeverityError, Line: 0, Message: "no FROM instruction"},
+				{Rule: "missing-from", Severity: SeverityError, Line: 1, Message: "RUN before the first FROM, only ARG may precede it"},
+				{Rule: "missing-from", Severity: SeverityError, Line: 2, Message: "USER before the first FROM, only ARG may precede it"},
+			},
+		},
+		{
+			name:    "latest-tag",
+			content: "FROM golang AS build\nFROM registry.local:5000/base:latest\nFROM build\nFROM scratch\nUSER app\n",
+			want: []Finding{
+				{Rule: "latest-tag", Severity: SeverityWarning, Line: 1, Message: "image golang is not pinned to a version tag"},
+				{Rule: "latest-tag", Severity: SeverityWarning, Line: 2, Message: "image registry.local:5000/base:latest is not pinned to a version tag"},
+			},
+		},
+		{
+			name:    "remote-add",
+			content: "FROM alpine:3.19\nADD https://example.com/tool.tgz ./local.tgz /opt/\nUSER app\n",
+			want: []Finding{
+				{Rule: "remote-add", Severity: SeverityWarning, Line: 2, Message: "ADD of remote URL https://example.com/tool.tgz, download it with RUN and verify its checksum instead"},
+			},
+		},
+		{
+			name:    "root-user missing",
+			content: "FROM alpine:3.19 AS build\nUSER app\nFROM alpine:3.19\n",
+			want: []Finding{
+				{Rule: "root-user", Severity: SeverityWarning, Line: 0, Message: "the final stage runs as root, add a USER instruction"},
+			},
+		},
+		{
+			name:    "root-user explicit",
+			content: "FROM alpine:3.19\nUSER 0:0\n",
+			want: []Finding{
+				{Rule: "root-user", Severity: SeverityWarning, Line: 2, Message: "the final stage runs as root"},
+			},
+		},
+		{
+			name:    "unpinned-packages",
+			content: "FROM debian:12\nRUN apt-get update && apt-get install -y curl git=1:2.39.2-1\nRUN pip install requests flask==3.0.0 -r ./requirements.txt\nRUN dnf install -y make gcc-13.2\nUSER app\n",
+			want: []Finding{
+				{Rule: "unpinned-packages", Severity: SeverityInfo, Line: 2, Message: "packages without a pinned version: curl"},
+				{Rule: "unpinned-packages", Severity: SeverityInfo, Line: 3, Message: "packages without a pinned version: requests"},
+				{Rule: "unpinned-packages", Severity: SeverityInfo, Line: 4, Message: "packages without a pinned version: make"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lint(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("Lint() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasSeverity(t *testing.T) {
+	info := []Finding{{Severity: SeverityInfo}}
+	warning := append(info, Finding{Severity: SeverityWarning})
+	errs := append(warning, Finding{Severity: SeverityError})
+
+	if HasWarnings(info) || HasErrors(info) {
+		t.Errorf("info findings reported as warnings or errors")
+	}
+	if !HasWarnings(warning) || HasErrors(warning) {
+		t.Errorf("warning findings: HasWarnings = %v, HasErrors = %v", HasWarnings(warning), HasErrors(warning))
+	}
+	if !HasWarnings(errs) || !HasErrors(errs) {
+		t.Errorf("error findings: HasWarnings = %v, HasErrors = %v", HasWarnings(errs), HasErrors(errs))
+	}
+}
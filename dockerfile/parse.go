@@ -0,0 +1,129 @@
+// Package dockerfile parses Dockerfiles and lints them before they are sent
+// to the server, where build failures are slow to surface.
+package dockerfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var instructions = map[string]bool{
+	"ADD": true, "ARG": true, "CMD": true, "COPY": true, "ENTRYPOINT": true,
+	"ENV": true, "EXPOSE": true, "FROM": true, "HEALTHCHECK": true, "LABEL": true,
+	"MAINTAINER": true, "ONBUILD": true, "RUN": true, "SHELL": true,
+	"STOPSIGNAL": true, "USER": true, "VOLUME": true, "WORKDIR": true,
+}
+
+// Instruction is a single, possibly multi-line, Dockerfile instruction.
+type Instruction struct {
+	// Cmd is the upper-cased instruction keyword
+	Cmd  string
+	Args string
+	// Line is the 1-based line the instruction starts on
+	Line int
+}
+
+// Flags returns the leading --flag arguments and the rest of the args.
+func (i Instruction) Flags() ([]string, []string) {
+	fields := strings.Fields(i.Args)
+	flags := []string{}
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		flags = append(flags, fields[0])
+		fields = fields[1:]
+	}
+
+	return flags, fields
+}
+
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Parse splits a Dockerfile into instructions, joining continuation lines
+// and dropping comments. Heredocs are not supported.
+func Parse(r io.Reader) ([]Instruction, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	target := []Instruction{}
+	var current *Instruction
+	var parts []string
+	escape := `\`
+	lineNo := 0
+	directives := true
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "#") {
+			if directives && current == nil {
+				if key, value, ok := strings.Cut(strings.TrimSpace(trimmed[1:]), "="); ok && strings.EqualFold(strings.TrimSpace(key), "escape") {
+					escape = strings.TrimSpace(value)
+					if escape != `\` && escape != "`" {
+						return nil, &SyntaxError{lineNo, fmt.Sprintf("invalid escape token %q", escape)}
+					}
+				}
+			}
+			continue
+		}
+		directives = false
+
+		if trimmed == "" {
+			continue
+		}
+
+		continued := strings.HasSuffix(line, escape)
+		if continued {
+			line = strings.TrimSuffix(line, escape)
+		}
+
+		if current == nil {
+			keyword, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+			current = &Instruction{Cmd: strings.ToUpper(keyword), Line: lineNo}
+			parts = []string{strings.TrimSpace(args)}
+		} else {
+			parts = append(parts, strings.TrimSpace(line))
+		}
+
+		if continued {
+			continue
+		}
+
+		current.Args = strings.TrimSpace(strings.Join(parts, " "))
+		if err := validate(*current); err != nil {
+			return nil, err
+		}
+
+		target = append(target, *current)
+		current = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if current != nil {
+		return nil, &SyntaxError{current.Line, "unterminated line continuation"}
+	}
+
+	return target, nil
+}
+
+func validate(i Instruction) error {
+	if !instructions[i.Cmd] {
+		return &SyntaxError{i.Line, fmt.Sprintf("unknown instruction %s", i.Cmd)}
+	}
+	if i.Args == "" {
+		return &SyntaxError{i.Line, fmt.Sprintf("%s requires at least one argument", i.Cmd)}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package dockerfile
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Instruction
+	}{
+		{
+			name:    "instructions",
+			content: "FROM alpine:3.19\n\n# a comment\nrun echo hi\n",
+			want: []Instruction{
+				{Cmd: "FROM", Args: "alpine:3.19", Line: 1},
+				{Cmd: "RUN", Args: "echo hi", Line: 4},
+			},
+		},
+		{
+			name:    "continuations",
+			content: "FROM alpine:3.19\nRUN apk add \\\n    curl \\\n    # skipped\n    git\nUSER app\n",
+			want: []Instruction{
+				{Cmd: "FROM", Args: "alpine:3.19", Line: 1},
+				{Cmd: "RUN", Args: "apk add curl git", Line: 2},
+				{Cmd: "USER", Args: "app", Line: 6},
+			},
+		},
+		{
+			name:    "escape directive",
+			content: "# escape=`\nFROM mcr.microsoft.com/windows:ltsc2022\nCOPY . C:\\app\\\nRUN dir `\n    C:\\app\n",
+			want: []Instruction{
+				{Cmd: "FROM", Args: "mcr.microsoft.com/windows:ltsc2022", Line: 2},
+				{Cmd: "COPY", Args: `. C:\app\`, Line: 3},
+				{Cmd: "RUN", Args: `dir C:\app`, Line: 4},
+			},
+		},
+		{
+			name:    "escape directive after an instruction",
+			content: "FROM alpine:3.19\n# escape=`\nRUN echo `\n",
+			want: []Instruction{
+				{Cmd: "FROM", Args: "alpine:3.19", Line: 1},
+				{Cmd: "RUN", Args: "echo `", Line: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    SyntaxError
+	}{
+		{"unterminated continuation", "FROM alpine:3.19\nRUN echo \\\n", SyntaxError{2, "unterminated line continuation"}},
+		{"invalid escape token", "# escape=x\nFROM alpine:3.19\n", SyntaxError{1, `invalid escape token "x"`}},
+		{"unknown instruction", "FROM alpine:3.19\nRUNN echo hi\n", SyntaxError{2, "unknown instruction RUNN"}},
+		{"missing arguments", "FROM alpine:3.19\nWORKDIR\n", SyntaxError{2, "WORKDIR requires at least one argument"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.content))
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse() error = %v, want a syntax error", err)
+			}
+			if *syntaxErr != tt.want {
+				t.Errorf("Parse() error = %+v, want %+v", *syntaxErr, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstructionFlags(t *testing.T) {
+	flags, args := Instruction{Cmd: "COPY", Args: "--from=build --chown=app /out /app"}.Flags()
+
+	if want := []string{"--from=build", "--chown=app"}; !reflect.DeepEqual(flags, want) {
+		t.Errorf("flags = %v, want %v", flags, want)
+	}
+	if want := []string{"/out", "/app"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
@@ -0,0 +1,315 @@
+// Package drift compares the resources a server should hold, as described
+// by manifests or by the local history, with the ones it actually holds.
+package drift
+
+import (
+	"context"
+	"sort"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/deploy"
+	"github.com/onpremless/opcli/history"
+)
+
+const (
+	KindRuntime  = "runtime"
+	KindLambda   = "lambda"
+	KindEndpoint = "endpoint"
+)
+
+const (
+	// Missing resources are desired but not on the server
+	Missing = "missing"
+	// Unmanaged resources are on the server but not desired
+	Unmanaged = "unmanaged"
+	// Changed resources are on the server with other fields than desired
+	Changed = "changed"
+)
+
+const defaultLambdaType = "ENDPOINT"
+
+type Drift struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	ID    string `json:"id,omitempty"`
+	Drift string `json:"drift"`
+	Field string `json:"field,omitempty"`
+	Want  string `json:"want,omitempty"`
+	Got   string `json:"got,omitempty"`
+}
+
+type Lambda struct {
+	Name string
+	// Runtime is the name or ID of the runtime
+	Runtime string
+	Type    string
+}
+
+type Endpoint struct {
+	Name string
+	Path string
+	// Lambda is the name of the lambda the endpoint routes to
+	Lambda string
+}
+
+// Desired is the state the server should be in.
+type Desired struct {
+	// Runtimes are names or IDs
+	Runtimes []string
+	Lambdas  []Lambda
+	// Endpoints is nil when the desired endpoints are unknown, leaving them
+	// out of the comparison
+	Endpoints []Endpoint
+}
+
+// FromManifests describes the runtime build contexts and lambda directories
+// discovered by the deploy package, from per directory files or stack files.
+// Runtimes the lambdas use are desired as well.
+func FromManifests(runtimes []deploy.Target, lambdas []deploy.Target) Desired {
+	d := Desired{Runtimes: []string{}, Lambdas: []Lambda{}, Endpoints: []Endpoint{}}
+
+	for _, t := range runtimes {
+		d.Runtimes = append(d.Runtimes, t.Runtime.Name)
+	}
+
+	for _, t := range lambdas {
+		ltype := t.Lambda.Type
+		if ltype == "" {
+			ltype = defaultLambdaType
+		}
+
+		d.Lambdas = append(d.Lambdas, Lambda{Name: t.Lambda.Name, Runtime: t.Lambda.Runtime, Type: ltype})
+		if t.Lambda.Runtime != "" {
+			d.Runtimes = append(d.Runtimes, t.Lambda.Runtime)
+		}
+
+		for _, e := range t.Lambda.Routes() {
+			d.Endpoints = append(d.Endpoints, Endpoint{Name: e.Name, Path: e.Path, Lambda: t.Lambda.Name})
+		}
+	}
+
+	return d
+}
+
+// FromHistory describes the last applied state: the latest build of every
+// runtime and the latest deployment of every lambda recorded for a server,
+// unless that lambda has been destroyed since, by ID. The history does not
+// record endpoints.
+func FromHistory(builds []history.RuntimeBuild, deployments []history.Deployment, destroyed map[string]bool) Desired {
+	d := Desired{Runtimes: []string{}, Lambdas: []Lambda{}}
+
+	for _, b := range builds {
+		d.Runtimes = append(d.Runtimes, b.Name)
+	}
+
+	latest := map[string]history.Deployment{}
+	for _, dep := range deployments {
+		latest[dep.Name] = dep
+	}
+	for _, dep := range latest {
+		if destroyed[dep.LambdaID] {
+			continue
+		}
+		d.Lambdas = append(d.Lambdas, Lambda{Name: dep.Name, Runtime: dep.Runtime, Type: dep.LambdaType})
+		d.Runtimes = append(d.Runtimes, dep.Runtime)
+	}
+
+	return d
+}
+
+// Live is the state the server is in.
+type Live struct {
+	Runtimes  []api.Runtime
+	Lambdas   []api.Lambda
+	Endpoints []api.Endpoint
+}
+
+// Lister lists the resources of a server, implemented by ops.Client.
+type Lister interface {
+	ListRuntimes(ctx context.Context) ([]api.Runtime, error)
+	ListLambdas(ctx context.Context) ([]api.Lambda, error)
+	ListEndpoints(ctx context.Context) ([]api.Endpoint, error)
+}
+
+func Fetch(ctx context.Context, lister Lister) (Live, error) {
+	var live Live
+	var err error
+
+	if live.Runtimes, err = lister.ListRuntimes(ctx); err != nil {
+		return live, err
+	}
+	if live.Lambdas, err = lister.ListLambdas(ctx); err != nil {
+		return live, err
+	}
+	if live.Endpoints, err = lister.ListEndpoints(ctx); err != nil {
+		return live, err
+	}
+
+	return live, nil
+}
+
+// Compare lists the drift of live from desired, runtimes first, then
+// lambdas and endpoints, each sorted by name. Resources are matched by
+// name, and fields compared with the newest of live duplicates: older
+// duplicates are left to garbage collection rather than reported.
+func Compare(desired Desired, live Live) []Drift {
+	drifts := []Drift{}
+
+	runtimes := append([]api.Runtime{}, live.Runtimes...)
+	sort.SliceStable(runtimes, func(i, j int) bool { return runtimes[i].CreatedAt > runtimes[j].CreatedAt })
+	lambdas := append([]api.Lambda{}, live.Lambdas...)
+	sort.SliceStable(lambdas, func(i, j int) bool { return lambdas[i].CreatedAt > lambdas[j].CreatedAt })
+
+	runtimeNames := map[string]string{}
+	for _, rt := range runtimes {
+		runtimeNames[rt.Id] = rt.Name
+	}
+	lambdaByID := map[string]api.Lambda{}
+	newestLambda := map[string]api.Lambda{}
+	for _, l := range lambdas {
+		lambdaByID[l.Id] = l
+		if _, ok := newestLambda[l.Name]; !ok {
+			newestLambda[l.Name] = l
+		}
+	}
+
+	// Runtimes
+	managed := map[string]bool{}
+	for _, ref := range unique(desired.Runtimes) {
+		found := false
+		for _, rt := range runtimes {
+			if rt.Id == ref || rt.Name == ref {
+				managed[rt.Id] = true
+				found = true
+			}
+		}
+		if !found {
+			drifts = append(drifts, Drift{Kind: KindRuntime, Name: ref, Drift: Missing})
+		}
+	}
+	for _, rt := range sortedRuntimes(runtimes) {
+		if !managed[rt.Id] {
+			drifts = append(drifts, Drift{Kind: KindRuntime, Name: rt.Name, ID: rt.Id, Drift: Unmanaged})
+		}
+	}
+
+	// Lambdas
+	wantLambdas := map[string]Lambda{}
+	lambdaNames := []string{}
+	for _, l := range desired.Lambdas {
+		wantLambdas[l.Name] = l
+		lambdaNames = append(lambdaNames, l.Name)
+	}
+	for _, name := range unique(lambdaNames) {
+		want := wantLambdas[name]
+		got, ok := newestLambda[name]
+		if !ok {
+			drifts = append(drifts, Drift{Kind: KindLambda, Name: name, Drift: Missing})
+			continue
+		}
+
+		if want.Runtime != "" && want.Runtime != got.Runtime && want.Runtime != runtimeNames[got.Runtime] {
+			drifts = append(drifts, Drift{Kind: KindLambda, Name: name, ID: got.Id, Drift: Changed, Field: "runtime", Want: want.Runtime, Got: runtimeLabel(runtimeNames, got.Runtime)})
+		}
+		if want.Type != "" && want.Type != got.LambdaType {
+			drifts = append(drifts, Drift{Kind: KindLambda, Name: name, ID: got.Id, Drift: Changed, Field: "type", Want: want.Type, Got: got.LambdaType})
+		}
+	}
+	for _, l := range sortedLambdas(lambdas) {
+		if _, ok := wantLambdas[l.Name]; !ok {
+			drifts = append(drifts, Drift{Kind: KindLambda, Name: l.Name, ID: l.Id, Drift: Unmanaged})
+		}
+	}
+
+	if desired.Endpoints == nil {
+		return drifts
+	}
+
+	// Endpoints
+	wantEndpoints := map[string]Endpoint{}
+	endpointNames := []string{}
+	for _, e := range desired.Endpoints {
+		wantEndpoints[e.Name] = e
+		endpointNames = append(endpointNames, e.Name)
+	}
+	liveEndpoints := map[string]api.Endpoint{}
+	liveNames := []string{}
+	for _, e := range live.Endpoints {
+		liveEndpoints[e.Name] = e
+		liveNames = append(liveNames, e.Name)
+	}
+
+	for _, name := range unique(endpointNames) {
+		want := wantEndpoints[name]
+		got, ok := liveEndpoints[name]
+		if !ok {
+			drifts = append(drifts, Drift{Kind: KindEndpoint, Name: name, Drift: Missing})
+			continue
+		}
+
+		if want.Path != got.Path {
+			drifts = append(drifts, Drift{Kind: KindEndpoint, Name: name, ID: got.Id, Drift: Changed, Field: "path", Want: want.Path, Got: got.Path})
+		}
+		if target, ok := newestLambda[want.Lambda]; !ok || target.Id != got.Lambda {
+			drifts = append(drifts, Drift{Kind: KindEndpoint, Name: name, ID: got.Id, Drift: Changed, Field: "target", Want: want.Lambda, Got: lambdaLabel(lambdaByID, newestLambda, got.Lambda)})
+		}
+	}
+	for _, name := range unique(liveNames) {
+		if _, ok := wantEndpoints[name]; !ok {
+			drifts = append(drifts, Drift{Kind: KindEndpoint, Name: name, ID: liveEndpoints[name].Id, Drift: Unmanaged})
+		}
+	}
+
+	return drifts
+}
+
+func runtimeLabel(names map[string]string, id string) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+
+	return id + " (missing)"
+}
+
+// lambdaLabel names the lambda an endpoint routes to, telling apart the
+// older duplicates of a name.
+func lambdaLabel(byID map[string]api.Lambda, newest map[string]api.Lambda, id string) string {
+	l, ok := byID[id]
+	if !ok {
+		return id + " (missing)"
+	}
+	if newest[l.Name].Id != id {
+		return l.Name + " (outdated " + id + ")"
+	}
+
+	return l.Name
+}
+
+// unique returns the strings sorted, without duplicates.
+func unique(strs []string) []string {
+	seen := map[string]bool{}
+	res := []string{}
+	for _, s := range strs {
+		if !seen[s] {
+			seen[s] = true
+			res = append(res, s)
+		}
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+func sortedRuntimes(runtimes []api.Runtime) []api.Runtime {
+	sorted := append([]api.Runtime{}, runtimes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	return sorted
+}
+
+func sortedLambdas(lambdas []api.Lambda) []api.Lambda {
+	sorted := append([]api.Lambda{}, lambdas...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	return sorted
+}
@@ -0,0 +1,172 @@
+package drift
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/deploy"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/manifest"
+)
+
+func TestCompare(t *testing.T) {
+	live := Live{
+		Runtimes: []api.Runtime{
+			{Id: "runtime-1", Name: "go", CreatedAt: 1},
+			{Id: "runtime-2", Name: "python", CreatedAt: 2},
+			{Id: "runtime-3", Name: "go", CreatedAt: 3},
+		},
+		Lambdas: []api.Lambda{
+			{Id: "lambda-4", Name: "hello", Runtime: "runtime-1", LambdaType: "ENDPOINT", CreatedAt: 4},
+			{Id: "lambda-5", Name: "hello", Runtime: "runtime-3", LambdaType: "ENDPOINT", CreatedAt: 5},
+			{Id: "lambda-6", Name: "worker", Runtime: "runtime-2", LambdaType: "ENDPOINT", CreatedAt: 6},
+			{Id: "lambda-7", Name: "manual", Runtime: "runtime-3", LambdaType: "INTERNAL", CreatedAt: 7},
+		},
+		Endpoints: []api.Endpoint{
+			{Id: "endpoint-8", Name: "hello", Path: "/hello", Lambda: "lambda-4"},
+			{Id: "endpoint-9", Name: "worker", Path: "/work", Lambda: "lambda-6"},
+			{Id: "endpoint-10", Name: "manual", Path: "/manual", Lambda: "lambda-7"},
+		},
+	}
+
+	desired := Desired{
+		Runtimes: []string{"go", "node"},
+		Lambdas: []Lambda{
+			{Name: "hello", Runtime: "go", Type: "ENDPOINT"},
+			{Name: "worker", Runtime: "go", Type: "INTERNAL"},
+			{Name: "missing", Runtime: "go", Type: "ENDPOINT"},
+		},
+		Endpoints: []Endpoint{
+			{Name: "hello", Path: "/hello", Lambda: "hello"},
+			{Name: "worker", Path: "/worker", Lambda: "worker"},
+		},
+	}
+
+	want := []Drift{
+		{Kind: KindRuntime, Name: "node", Drift: Missing},
+		{Kind: KindRuntime, Name: "python", ID: "runtime-2", Drift: Unmanaged},
+		{Kind: KindLambda, Name: "missing", Drift: Missing},
+		{Kind: KindLambda, Name: "worker", ID: "lambda-6", Drift: Changed, Field: "runtime", Want: "go", Got: "python"},
+		{Kind: KindLambda, Name: "worker", ID: "lambda-6", Drift: Changed, Field: "type", Want: "INTERNAL", Got: "ENDPOINT"},
+		{Kind: KindLambda, Name: "manual", ID: "lambda-7", Drift: Unmanaged},
+		{Kind: KindEndpoint, Name: "hello", ID: "endpoint-8", Drift: Changed, Field: "target", Want: "hello", Got: "hello (outdated lambda-4)"},
+		{Kind: KindEndpoint, Name: "worker", ID: "endpoint-9", Drift: Changed, Field: "path", Want: "/worker", Got: "/work"},
+		{Kind: KindEndpoint, Name: "manual", ID: "endpoint-10", Drift: Unmanaged},
+	}
+
+	if got := Compare(desired, live); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	desired.Endpoints = nil
+	for _, d := range Compare(desired, live) {
+		if d.Kind == KindEndpoint {
+			t.Errorf("endpoints compared although unknown: %+v", d)
+		}
+	}
+}
+
+func TestCompareNoDrift(t *testing.T) {
+	live := Live{
+		Runtimes:  []api.Runtime{{Id: "runtime-1", Name: "go"}},
+		Lambdas:   []api.Lambda{{Id: "lambda-2", Name: "hello", Runtime: "runtime-1", LambdaType: "ENDPOINT"}},
+		Endpoints: []api.Endpoint{{Id: "endpoint-3", Name: "hello", Path: "/hello", Lambda: "lambda-2"}},
+	}
+	desired := Desired{
+		Runtimes:  []string{"runtime-1"},
+		Lambdas:   []Lambda{{Name: "hello", Runtime: "runtime-1", Type: "ENDPOINT"}},
+		Endpoints: []Endpoint{{Name: "hello", Path: "/hello", Lambda: "hello"}},
+	}
+
+	if got := Compare(desired, live); len(got) != 0 {
+		t.Errorf("Compare() = %+v, want no drift", got)
+	}
+}
+
+func TestFromHistoryDropsDestroyed(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("OPCLI_CONFIG", filepath.Join(dir, "config.yaml"))
+	store, err := history.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deploy := func(id string, name string) {
+		t.Helper()
+		l := &api.Lambda{Id: id, Name: name, Runtime: "runtime-1", LambdaType: "ENDPOINT"}
+		if err := store.Add(history.New("http://server", l, dir, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deploy("lambda-2", "hello")
+	deploy("lambda-3", "gone")
+	deploy("lambda-4", "redeployed")
+	store.Destroy("http://server", "lambda-3")
+	store.Destroy("http://server", "lambda-4")
+	store.Destroy("http://other", "lambda-2")
+	deploy("lambda-5", "redeployed")
+
+	deployments, _ := store.List("http://server")
+	destroyed, err := store.Destroyed("http://server")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desired := FromHistory(nil, deployments, destroyed)
+	names := []string{}
+	for _, l := range desired.Lambdas {
+		names = append(names, l.Name)
+	}
+	sort.Strings(names)
+	if want := []string{"hello", "redeployed"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("desired lambdas = %v, want %v", names, want)
+	}
+
+	live := Live{
+		Runtimes: []api.Runtime{{Id: "runtime-1", Name: "go"}},
+		Lambdas: []api.Lambda{
+			{Id: "lambda-2", Name: "hello", Runtime: "runtime-1", LambdaType: "ENDPOINT"},
+			{Id: "lambda-5", Name: "redeployed", Runtime: "runtime-1", LambdaType: "ENDPOINT"},
+		},
+	}
+	if got := Compare(desired, live); len(got) != 0 {
+		t.Errorf("Compare() = %+v, want no drift after destroying", got)
+	}
+}
+
+func TestCompareGeneratedStack(t *testing.T) {
+	live := Live{
+		Runtimes: []api.Runtime{{Id: "runtime-1", Name: "go", CreatedAt: 1}},
+		Lambdas: []api.Lambda{
+			{Id: "lambda-2", Name: "hello", Runtime: "runtime-1", LambdaType: "ENDPOINT", CreatedAt: 2},
+			{Id: "lambda-3", Name: "cron", Runtime: "runtime-1", LambdaType: "INTERNAL", CreatedAt: 3},
+		},
+		Endpoints: []api.Endpoint{
+			{Id: "endpoint-4", Name: "hello", Path: "/hello", Lambda: "lambda-2"},
+			{Id: "endpoint-5", Name: "hi", Path: "/hi", Lambda: "lambda-2"},
+		},
+	}
+
+	stack, _ := manifest.FromLive(live.Runtimes, live.Lambdas, live.Endpoints, nil, nil, "")
+	file := filepath.Join(t.TempDir(), "opcli.yaml")
+	if err := manifest.WriteStack(file, stack, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	runtimes, err := deploy.DiscoverRuntimes([]string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lambdas, err := deploy.Discover([]string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Compare(FromManifests(runtimes, lambdas), live); len(got) != 0 {
+		t.Errorf("Compare() = %+v, want no drift from a generated stack", got)
+	}
+}
@@ -0,0 +1,137 @@
+// Package gc finds the resources of a server nobody uses anymore: ENDPOINT
+// lambdas no endpoint routes to, older duplicates of lambda names and
+// runtimes no lambda is built from.
+package gc
+
+import (
+	"sort"
+	"time"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/drift"
+	"github.com/onpremless/opcli/ops"
+)
+
+const (
+	KindRuntime = "runtime"
+	KindLambda  = "lambda"
+)
+
+const (
+	// ReasonOrphaned lambdas are ENDPOINT lambdas no endpoint routes to
+	ReasonOrphaned = "orphaned"
+	// ReasonDuplicate lambdas are older than another lambda of the same name
+	ReasonDuplicate = "duplicate"
+	// ReasonUnused runtimes are used by no lambda
+	ReasonUnused = "unused"
+)
+
+const endpointLambdaType = "ENDPOINT"
+
+// Candidate is a resource that can be garbage collected.
+type Candidate struct {
+	Kind      string `json:"kind"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+	Reason    string `json:"reason"`
+	// Details explains the reason, such as which lambda supersedes a
+	// duplicate
+	Details string `json:"details,omitempty"`
+	// Removable tells whether the API can delete the resource, it has no
+	// way to delete runtimes
+	Removable bool `json:"removable"`
+}
+
+type Options struct {
+	// OlderThan leaves out the resources created more recently, or at an
+	// unknown time
+	OlderThan time.Duration
+	// Now is the time ages are computed from, the current time when zero
+	Now time.Time
+}
+
+// Find lists the candidates of live, lambdas first, then runtimes, each
+// sorted by name and creation time. Of a lambda name only the newest is
+// kept, unless an endpoint still routes to an older one: those are left
+// out rather than break the routing. Runtimes used by candidate lambdas are
+// not candidates yet, running Find again once they are gone finds them.
+func Find(live drift.Live, opts Options) []Candidate {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	oldEnough := func(ts int64) bool {
+		if opts.OlderThan == 0 {
+			return true
+		}
+		return ts != 0 && now.Sub(ops.Timestamp(ts)) >= opts.OlderThan
+	}
+
+	routed := map[string]bool{}
+	for _, e := range live.Endpoints {
+		routed[e.Lambda] = true
+	}
+
+	lambdas := append([]api.Lambda{}, live.Lambdas...)
+	sort.SliceStable(lambdas, func(i, j int) bool {
+		if lambdas[i].Name != lambdas[j].Name {
+			return lambdas[i].Name < lambdas[j].Name
+		}
+		return lambdas[i].CreatedAt < lambdas[j].CreatedAt
+	})
+
+	newest := map[string]api.Lambda{}
+	for _, l := range lambdas {
+		newest[l.Name] = l
+	}
+
+	candidates := []Candidate{}
+	used := map[string]bool{}
+	for _, l := range lambdas {
+		used[l.Runtime] = true
+		if !oldEnough(l.CreatedAt) {
+			continue
+		}
+
+		c := Candidate{Kind: KindLambda, ID: l.Id, Name: l.Name, CreatedAt: l.CreatedAt, Removable: true}
+		switch {
+		case routed[l.Id]:
+			continue
+		case newest[l.Name].Id != l.Id:
+			c.Reason = ReasonDuplicate
+			c.Details = "superseded by " + newest[l.Name].Id
+		case l.LambdaType == endpointLambdaType:
+			c.Reason = ReasonOrphaned
+			c.Details = "no endpoint routes to it"
+		default:
+			continue
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	runtimes := append([]api.Runtime{}, live.Runtimes...)
+	sort.SliceStable(runtimes, func(i, j int) bool {
+		if runtimes[i].Name != runtimes[j].Name {
+			return runtimes[i].Name < runtimes[j].Name
+		}
+		return runtimes[i].CreatedAt < runtimes[j].CreatedAt
+	})
+	for _, rt := range runtimes {
+		if used[rt.Id] || !oldEnough(rt.CreatedAt) {
+			continue
+		}
+
+		candidates = append(candidates, Candidate{
+			Kind:      KindRuntime,
+			ID:        rt.Id,
+			Name:      rt.Name,
+			CreatedAt: rt.CreatedAt,
+			Reason:    ReasonUnused,
+			Details:   "no lambda uses it, the API cannot delete runtimes",
+		})
+	}
+
+	return candidates
+}
@@ -0,0 +1,55 @@
+package gc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/drift"
+)
+
+var testLive = drift.Live{
+	Runtimes: []api.Runtime{
+		{Id: "runtime-1", Name: "go", CreatedAt: 1000},
+		{Id: "runtime-2", Name: "node", CreatedAt: 1000},
+		{Id: "runtime-3", Name: "python", CreatedAt: 9000},
+	},
+	Lambdas: []api.Lambda{
+		{Id: "lambda-4", Name: "hello", Runtime: "runtime-1", LambdaType: "ENDPOINT", CreatedAt: 2000},
+		{Id: "lambda-5", Name: "hello", Runtime: "runtime-1", LambdaType: "ENDPOINT", CreatedAt: 3000},
+		{Id: "lambda-6", Name: "hello", Runtime: "runtime-1", LambdaType: "ENDPOINT", CreatedAt: 9500},
+		{Id: "lambda-7", Name: "orphan", Runtime: "runtime-1", LambdaType: "ENDPOINT", CreatedAt: 4000},
+		{Id: "lambda-8", Name: "worker", Runtime: "runtime-1", LambdaType: "INTERNAL", CreatedAt: 5000},
+	},
+	Endpoints: []api.Endpoint{
+		{Id: "endpoint-9", Name: "hello", Path: "/hello", Lambda: "lambda-6"},
+		{Id: "endpoint-10", Name: "legacy", Path: "/legacy", Lambda: "lambda-4"},
+	},
+}
+
+func TestFind(t *testing.T) {
+	want := []Candidate{
+		{Kind: KindLambda, ID: "lambda-5", Name: "hello", CreatedAt: 3000, Reason: ReasonDuplicate, Details: "superseded by lambda-6", Removable: true},
+		{Kind: KindLambda, ID: "lambda-7", Name: "orphan", CreatedAt: 4000, Reason: ReasonOrphaned, Details: "no endpoint routes to it", Removable: true},
+		{Kind: KindRuntime, ID: "runtime-2", Name: "node", CreatedAt: 1000, Reason: ReasonUnused, Details: "no lambda uses it, the API cannot delete runtimes"},
+		{Kind: KindRuntime, ID: "runtime-3", Name: "python", CreatedAt: 9000, Reason: ReasonUnused, Details: "no lambda uses it, the API cannot delete runtimes"},
+	}
+
+	if got := Find(testLive, Options{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFindOlderThan(t *testing.T) {
+	opts := Options{OlderThan: 6500 * time.Second, Now: time.Unix(10000, 0)}
+
+	got := Find(testLive, opts)
+	ids := []string{}
+	for _, c := range got {
+		ids = append(ids, c.ID)
+	}
+	if want := []string{"lambda-5", "runtime-2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Find() = %v, want %v", ids, want)
+	}
+}
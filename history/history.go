@@ -0,0 +1,319 @@
+// Package history keeps a local record of the lambdas deployed and the
+// runtimes built from this machine: where their sources came from and the
+// git state they were in. The API server has no room for such metadata.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/config"
+	"github.com/onpremless/opcli/ops"
+	"github.com/onpremless/opcli/vcs"
+)
+
+const (
+	fileName          = "history.jsonl"
+	runtimesFileName  = "runtimes.jsonl"
+	destroyedFileName = "destroyed.jsonl"
+)
+
+// Deployment is a lambda created from a sources directory.
+type Deployment struct {
+	// Server is the API server the lambda has been created on, lambda IDs
+	// are only unique per server
+	Server     string    `json:"server"`
+	LambdaID   string    `json:"lambda_id"`
+	Name       string    `json:"name"`
+	Runtime    string    `json:"runtime"`
+	LambdaType string    `json:"lambda_type"`
+	Source     string    `json:"source"`
+	Digest     string    `json:"digest,omitempty"`
+	Git        *vcs.Info `json:"git,omitempty"`
+	DeployedAt time.Time `json:"deployed_at"`
+}
+
+// New describes the lambda deployed from dir. Git metadata is left out when
+// dir is not part of a git working tree.
+func New(server string, l *api.Lambda, dir string, digest string) Deployment {
+	d := Deployment{
+		Server:     server,
+		LambdaID:   l.Id,
+		Name:       l.Name,
+		Runtime:    l.Runtime,
+		LambdaType: l.LambdaType,
+		Source:     dir,
+		Digest:     digest,
+		DeployedAt: time.Now().UTC(),
+	}
+
+	if abs, err := filepath.Abs(dir); err == nil {
+		d.Source = abs
+	}
+
+	d.Git, _ = vcs.Describe(dir)
+
+	return d
+}
+
+// RuntimeBuild is a runtime created from a Dockerfile or a build context
+// directory.
+type RuntimeBuild struct {
+	Server     string            `json:"server"`
+	RuntimeID  string            `json:"runtime_id"`
+	Name       string            `json:"name"`
+	Source     string            `json:"source"`
+	Dockerfile string            `json:"dockerfile,omitempty"`
+	BuildArgs  map[string]string `json:"build_args,omitempty"`
+	Digest     string            `json:"digest,omitempty"`
+	Git        *vcs.Info         `json:"git,omitempty"`
+	BuiltAt    time.Time         `json:"built_at"`
+}
+
+// NewRuntimeBuild describes the runtime built from path, see New.
+func NewRuntimeBuild(server string, rt *api.Runtime, path string, dockerfile string, buildArgs map[string]string, digest string) RuntimeBuild {
+	b := RuntimeBuild{
+		Server:     server,
+		RuntimeID:  rt.Id,
+		Name:       rt.Name,
+		Source:     path,
+		Dockerfile: dockerfile,
+		BuildArgs:  buildArgs,
+		Digest:     digest,
+		BuiltAt:    time.Now().UTC(),
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		b.Source = abs
+	}
+
+	dir := b.Source
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	b.Git, _ = vcs.Describe(dir)
+
+	return b
+}
+
+// CheckSources makes sure the sources of the deployment can be packaged
+// again as they were deployed.
+func (d Deployment) CheckSources() error {
+	return checkSources(d.Source, d.Digest)
+}
+
+// CheckSources makes sure the sources of the build can be packaged again as
+// they were built.
+func (b RuntimeBuild) CheckSources() error {
+	return checkSources(b.Source, b.Digest)
+}
+
+// checkSources compares the digest of the sources to the recorded one.
+// Records without a digest cannot be verified and are refused.
+func checkSources(source string, digest string) error {
+	if digest == "" {
+		return fmt.Errorf("no digest recorded for the sources in %s, they cannot be verified", source)
+	}
+
+	current, err := ops.SourceDigest(source)
+	if err != nil {
+		return fmt.Errorf("sources unavailable: %w", err)
+	}
+
+	if current != digest {
+		return fmt.Errorf("sources in %s changed since they were deployed", source)
+	}
+
+	return nil
+}
+
+// Store is a set of append-only files of deployments, runtime builds and
+// destroyed lambdas, one JSON document per line, kept next to the config
+// file.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func Open() (*Store, error) {
+	p, err := config.Path()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{dir: filepath.Dir(p)}, nil
+}
+
+func (s *Store) Add(d Deployment) error {
+	return s.append(fileName, d)
+}
+
+// List returns the deployments made to server, oldest first. Lines that
+// cannot be decoded are skipped.
+func (s *Store) List(server string) ([]Deployment, error) {
+	deployments := []Deployment{}
+	err := s.scan(fileName, func(line []byte) {
+		var d Deployment
+		if err := json.Unmarshal(line, &d); err != nil {
+			return
+		}
+		if d.Server == server {
+			deployments = append(deployments, d)
+		}
+	})
+
+	return deployments, err
+}
+
+// Lambdas returns the latest deployment of each lambda of server, by
+// lambda ID.
+func (s *Store) Lambdas(server string) (map[string]Deployment, error) {
+	deployments, err := s.List(server)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]Deployment{}
+	for _, d := range deployments {
+		latest[d.LambdaID] = d
+	}
+
+	return latest, nil
+}
+
+// Latest returns the deployment the lambda has been created by, nil when it
+// was not deployed from this machine.
+func (s *Store) Latest(server string, lambdaID string) (*Deployment, error) {
+	latest, err := s.Lambdas(server)
+	if err != nil {
+		return nil, err
+	}
+
+	d, ok := latest[lambdaID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &d, nil
+}
+
+// Destruction is the tombstone of a destroyed lambda.
+type Destruction struct {
+	Server      string    `json:"server"`
+	LambdaID    string    `json:"lambda_id"`
+	DestroyedAt time.Time `json:"destroyed_at"`
+}
+
+// Destroy records that the lambda has been destroyed, its deployments are
+// kept.
+func (s *Store) Destroy(server string, lambdaID string) error {
+	return s.append(destroyedFileName, Destruction{Server: server, LambdaID: lambdaID, DestroyedAt: time.Now().UTC()})
+}
+
+// Destroyed returns the IDs of the lambdas of server recorded as destroyed.
+func (s *Store) Destroyed(server string) (map[string]bool, error) {
+	destroyed := map[string]bool{}
+	err := s.scan(destroyedFileName, func(line []byte) {
+		var d Destruction
+		if err := json.Unmarshal(line, &d); err != nil {
+			return
+		}
+		if d.Server == server {
+			destroyed[d.LambdaID] = true
+		}
+	})
+
+	return destroyed, err
+}
+
+func (s *Store) AddRuntime(b RuntimeBuild) error {
+	return s.append(runtimesFileName, b)
+}
+
+// ListRuntimes returns the runtimes built on server, oldest first.
+func (s *Store) ListRuntimes(server string) ([]RuntimeBuild, error) {
+	builds := []RuntimeBuild{}
+	err := s.scan(runtimesFileName, func(line []byte) {
+		var b RuntimeBuild
+		if err := json.Unmarshal(line, &b); err != nil {
+			return
+		}
+		if b.Server == server {
+			builds = append(builds, b)
+		}
+	})
+
+	return builds, err
+}
+
+// Runtimes returns the latest build of each runtime of server, by runtime
+// ID.
+func (s *Store) Runtimes(server string) (map[string]RuntimeBuild, error) {
+	builds, err := s.ListRuntimes(server)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]RuntimeBuild{}
+	for _, b := range builds {
+		latest[b.RuntimeID] = b
+	}
+
+	return latest, nil
+}
+
+func (s *Store) append(name string, v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(s.dir, name), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(v)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// scan calls fn with every line of the file, which may not exist yet.
+func (s *Store) scan(name string, fn func(line []byte)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		fn(scanner.Bytes())
+	}
+
+	return scanner.Err()
+}
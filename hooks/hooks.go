@@ -0,0 +1,102 @@
+// Package hooks runs the user commands declared around a lambda deploy.
+package hooks
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	PreBuild   = "pre-build"
+	PostDeploy = "post-deploy"
+)
+
+const waitDelay = 2 * time.Second
+
+// Env holds what a hook gets to know about the lambda. Fields that are not
+// known yet, like the ID before the lambda is created, are left empty.
+type Env struct {
+	Name    string
+	ID      string
+	Runtime string
+	Digest  string
+}
+
+func (e Env) vars() []string {
+	return []string{
+		"OPCLI_LAMBDA_NAME=" + e.Name,
+		"OPCLI_LAMBDA_ID=" + e.ID,
+		"OPCLI_LAMBDA_RUNTIME=" + e.Runtime,
+		"OPCLI_LAMBDA_DIGEST=" + e.Digest,
+	}
+}
+
+type Hook struct {
+	// Stage is PreBuild or PostDeploy
+	Stage   string
+	Command string
+	// Dir is the lambda sources directory the command runs in
+	Dir string
+	Env Env
+}
+
+type ExitError struct {
+	Stage string
+	Err   error
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("%s hook failed: %v", e.Stage, e.Err)
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Run runs the hook with sh -c, sending its combined output to out line by
+// line. out is closed once the command exits. A non-zero exit is reported as
+// an *ExitError.
+func Run(ctx context.Context, h Hook, out chan<- string) error {
+	defer close(out)
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Dir = h.Dir
+	cmd.Env = append(os.Environ(), h.Env.vars()...)
+	killGroup(cmd)
+	// Processes that left the group may still hold the output open once
+	// the hook is killed; stop waiting for them after a while.
+	cmd.WaitDelay = waitDelay
+
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			out <- scanner.Text()
+		}
+		io.Copy(io.Discard, pr)
+	}()
+
+	err := cmd.Run()
+	pw.Close()
+	wg.Wait()
+
+	if err != nil {
+		return &ExitError{Stage: h.Stage, Err: err}
+	}
+
+	return nil
+}
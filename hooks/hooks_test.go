@@ -0,0 +1,120 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func run(ctx context.Context, h Hook) ([]string, error) {
+	out := make(chan string)
+	lines := []string{}
+	done := make(chan struct{})
+	go func() {
+		for line := range out {
+			lines = append(lines, line)
+		}
+		close(done)
+	}()
+
+	err := Run(ctx, h, out)
+	<-done
+
+	return lines, err
+}
+
+func TestRunEnv(t *testing.T) {
+	dir := t.TempDir()
+	h := Hook{
+		Stage:   PostDeploy,
+		Command: `pwd; echo "$OPCLI_LAMBDA_NAME $OPCLI_LAMBDA_ID $OPCLI_LAMBDA_RUNTIME $OPCLI_LAMBDA_DIGEST"`,
+		Dir:     dir,
+		Env:     Env{Name: "hello", ID: "lambda-1", Runtime: "go", Digest: "sha256:aaaa"},
+	}
+
+	lines, err := run(context.Background(), h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{dir, "hello lambda-1 go sha256:aaaa"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("output = %q, want %q", lines, want)
+	}
+}
+
+func TestRunStreamsOutput(t *testing.T) {
+	dir := t.TempDir()
+	out := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- Run(context.Background(), Hook{
+			Stage:   PreBuild,
+			Command: "echo first; echo second >&2; while [ ! -f done ]; do sleep 0.01; done",
+			Dir:     dir,
+		}, out)
+	}()
+
+	// The hook waits for the done file, so the lines must arrive while it
+	// is still running.
+	for _, want := range []string{"first", "second"} {
+		select {
+		case line := <-out:
+			if line != want {
+				t.Errorf("line = %q, want %q", line, want)
+			}
+		case err := <-errs:
+			t.Fatalf("hook exited before streaming %q: %v", want, err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "done"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-out; ok {
+		t.Error("output was not closed")
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	lines, err := run(context.Background(), Hook{Stage: PreBuild, Command: "echo broken; exit 3"})
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Stage != PreBuild {
+		t.Fatalf("err = %v, want an ExitError of the pre-build stage", err)
+	}
+
+	var cmdErr *exec.ExitError
+	if !errors.As(err, &cmdErr) || cmdErr.ExitCode() != 3 {
+		t.Errorf("err = %v, want exit code 3", err)
+	}
+	if !reflect.DeepEqual(lines, []string{"broken"}) {
+		t.Errorf("output = %q", lines)
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := run(ctx, Hook{Stage: PreBuild, Command: "sleep 30; echo late"})
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Errorf("err = %v, want an ExitError", err)
+	}
+	if d := time.Since(start); d >= waitDelay {
+		t.Errorf("hook ran for %s after cancel", d)
+	}
+}
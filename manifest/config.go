@@ -0,0 +1,124 @@
+// Package manifest describes onpremless resources declaratively: the per
+// directory lambda.yaml and runtime.yaml files kept next to the sources, or
+// a single stack file describing them all.
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	LambdaFile  = "lambda.yaml"
+	RuntimeFile = "runtime.yaml"
+)
+
+type Endpoint struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+}
+
+// Hooks are shell commands run in the sources directory around a deploy.
+type Hooks struct {
+	PreBuild   string `yaml:"pre-build,omitempty"`
+	PostDeploy string `yaml:"post-deploy,omitempty"`
+}
+
+// Lambda is the lambda.yaml of a lambda sources directory.
+type Lambda struct {
+	Name string `yaml:"name"`
+	// Runtime is the name of the runtime the lambda is built with
+	Runtime  string    `yaml:"runtime"`
+	Type     string    `yaml:"type"`
+	Endpoint *Endpoint `yaml:"endpoint,omitempty"`
+	// Endpoints are further endpoints routing to the lambda
+	Endpoints []Endpoint `yaml:"endpoints,omitempty"`
+	Hooks     *Hooks     `yaml:"hooks,omitempty"`
+}
+
+// Routes returns the endpoints routing to the lambda, named after it
+// unless named otherwise. Endpoints without a path are left out.
+func (l Lambda) Routes() []Endpoint {
+	all := l.Endpoints
+	if l.Endpoint != nil {
+		all = append([]Endpoint{*l.Endpoint}, all...)
+	}
+
+	routes := []Endpoint{}
+	for _, e := range all {
+		if e.Path == "" {
+			continue
+		}
+		if e.Name == "" {
+			e.Name = l.Name
+		}
+		routes = append(routes, e)
+	}
+
+	return routes
+}
+
+// Runtime is the runtime.yaml of a runtime build context directory.
+type Runtime struct {
+	Name       string            `yaml:"name"`
+	Dockerfile string            `yaml:"dockerfile,omitempty"`
+	BuildArgs  map[string]string `yaml:"build-args,omitempty"`
+}
+
+func LoadLambda(dir string) (*Lambda, error) {
+	l := &Lambda{}
+	if err := load(filepath.Join(dir, LambdaFile), l); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func LoadRuntime(dir string) (*Runtime, error) {
+	rt := &Runtime{}
+	if err := load(filepath.Join(dir, RuntimeFile), rt); err != nil {
+		return nil, err
+	}
+
+	return rt, nil
+}
+
+// Write stores v as YAML, refusing to replace an existing file.
+func Write(name string, v any) error {
+	content, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return writeNew(name, content)
+}
+
+func writeNew(name string, content []byte) error {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return fmt.Errorf("file already exists: %s", name)
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(content)
+	return err
+}
+
+func load(name string, v any) error {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
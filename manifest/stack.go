@@ -0,0 +1,198 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/history"
+	"gopkg.in/yaml.v3"
+)
+
+// SourcePlaceholder stands for sources that are yet to be located.
+const SourcePlaceholder = "TODO"
+
+// Stack is a single manifest of all the runtimes and lambdas of a server,
+// as opposed to the per directory files, keyed by name. Lambdas reference
+// their runtime by name. Sources are relative to the stack file.
+type Stack struct {
+	Runtimes map[string]StackRuntime `yaml:"runtimes"`
+	Lambdas  map[string]StackLambda  `yaml:"lambdas"`
+}
+
+type StackRuntime struct {
+	// Source is the Dockerfile or the build context directory
+	Source     string            `yaml:"source"`
+	Dockerfile string            `yaml:"dockerfile,omitempty"`
+	BuildArgs  map[string]string `yaml:"build-args,omitempty"`
+}
+
+type StackLambda struct {
+	Source    string     `yaml:"source"`
+	Runtime   string     `yaml:"runtime"`
+	Type      string     `yaml:"type"`
+	Endpoints []Endpoint `yaml:"endpoints,omitempty"`
+}
+
+// Placeholders counts the runtimes and lambdas whose source is
+// SourcePlaceholder.
+func (s *Stack) Placeholders() int {
+	n := 0
+	for _, rt := range s.Runtimes {
+		if rt.Source == SourcePlaceholder {
+			n++
+		}
+	}
+	for _, l := range s.Lambdas {
+		if l.Source == SourcePlaceholder {
+			n++
+		}
+	}
+
+	return n
+}
+
+func LoadStack(name string) (*Stack, error) {
+	s := &Stack{}
+	if err := load(name, s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// FromLive describes the runtimes, lambdas and endpoints of a server. The
+// newest of duplicates stands for a name, and endpoints are listed under the
+// lambda they route to. Sources recorded in builds and deployments, by ID,
+// are made relative to base, the others are left to SourcePlaceholder.
+// Warnings tell about what the stack cannot express.
+func FromLive(runtimes []api.Runtime, lambdas []api.Lambda, endpoints []api.Endpoint, builds map[string]history.RuntimeBuild, deployments map[string]history.Deployment, base string) (*Stack, []string) {
+	s := &Stack{Runtimes: map[string]StackRuntime{}, Lambdas: map[string]StackLambda{}}
+	warnings := []string{}
+
+	runtimes = append([]api.Runtime{}, runtimes...)
+	sort.SliceStable(runtimes, func(i, j int) bool { return runtimes[i].CreatedAt > runtimes[j].CreatedAt })
+	lambdas = append([]api.Lambda{}, lambdas...)
+	sort.SliceStable(lambdas, func(i, j int) bool { return lambdas[i].CreatedAt > lambdas[j].CreatedAt })
+
+	runtimeNames := map[string]string{}
+	runtimeCount := map[string]int{}
+	for _, rt := range runtimes {
+		runtimeNames[rt.Id] = rt.Name
+		runtimeCount[rt.Name]++
+		if runtimeCount[rt.Name] > 1 {
+			continue
+		}
+
+		entry := StackRuntime{Source: SourcePlaceholder}
+		if b, ok := builds[rt.Id]; ok {
+			entry = StackRuntime{Source: relPath(base, b.Source), Dockerfile: b.Dockerfile, BuildArgs: b.BuildArgs}
+		}
+		s.Runtimes[rt.Name] = entry
+	}
+
+	newest := map[string]string{}
+	lambdaByID := map[string]api.Lambda{}
+	lambdaCount := map[string]int{}
+	for _, l := range lambdas {
+		lambdaByID[l.Id] = l
+		lambdaCount[l.Name]++
+		if lambdaCount[l.Name] > 1 {
+			continue
+		}
+		newest[l.Name] = l.Id
+
+		runtime, ok := runtimeNames[l.Runtime]
+		if !ok {
+			runtime = l.Runtime
+			warnings = append(warnings, fmt.Sprintf("lambda %s uses the missing runtime %s", l.Name, l.Runtime))
+		}
+
+		entry := StackLambda{Source: SourcePlaceholder, Runtime: runtime, Type: l.LambdaType}
+		if d, ok := deployments[l.Id]; ok {
+			entry.Source = relPath(base, d.Source)
+		}
+		s.Lambdas[l.Name] = entry
+	}
+
+	endpoints = append([]api.Endpoint{}, endpoints...)
+	sort.SliceStable(endpoints, func(i, j int) bool { return endpoints[i].Name < endpoints[j].Name })
+	for _, e := range endpoints {
+		l, ok := lambdaByID[e.Lambda]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("endpoint %s routes to the missing lambda %s, left out", e.Name, e.Lambda))
+			continue
+		}
+		if newest[l.Name] != l.Id {
+			warnings = append(warnings, fmt.Sprintf("endpoint %s routes to %s, an older duplicate of lambda %s, listed under the newest", e.Name, l.Id, l.Name))
+		}
+
+		entry := s.Lambdas[l.Name]
+		entry.Endpoints = append(entry.Endpoints, Endpoint{Name: e.Name, Path: e.Path})
+		s.Lambdas[l.Name] = entry
+	}
+
+	for _, name := range sortedCounts(runtimeCount) {
+		warnings = append(warnings, fmt.Sprintf("runtime %s has %d duplicates, the newest is kept", name, runtimeCount[name]))
+	}
+	for _, name := range sortedCounts(lambdaCount) {
+		warnings = append(warnings, fmt.Sprintf("lambda %s has %d duplicates, the newest is kept", name, lambdaCount[name]))
+	}
+
+	return s, warnings
+}
+
+// sortedCounts returns the names counted more than once, sorted.
+func sortedCounts(counts map[string]int) []string {
+	names := []string{}
+	for name, n := range counts {
+		if n > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func relPath(base string, path string) string {
+	if base == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(base, path); err == nil {
+		return rel
+	}
+
+	return path
+}
+
+// MarshalStack renders the stack as YAML, preceded by header as comment
+// lines.
+func MarshalStack(s *Stack, header []string) ([]byte, error) {
+	content, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []byte{}
+	for _, line := range header {
+		res = append(res, "# "+line+"\n"...)
+	}
+	if len(header) > 0 {
+		res = append(res, '\n')
+	}
+
+	return append(res, content...), nil
+}
+
+// WriteStack stores the stack with its header, refusing to replace an
+// existing file.
+func WriteStack(name string, s *Stack, header []string) error {
+	content, err := MarshalStack(s, header)
+	if err != nil {
+		return err
+	}
+
+	return writeNew(name, content)
+}
@@ -0,0 +1,86 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/history"
+)
+
+func TestFromLive(t *testing.T) {
+	runtimes := []api.Runtime{
+		{Id: "runtime-1", Name: "go", CreatedAt: 1},
+		{Id: "runtime-2", Name: "go", CreatedAt: 2},
+		{Id: "runtime-3", Name: "shell", CreatedAt: 3},
+	}
+	lambdas := []api.Lambda{
+		{Id: "lambda-4", Name: "hello", Runtime: "runtime-1", LambdaType: "ENDPOINT", CreatedAt: 4},
+		{Id: "lambda-5", Name: "hello", Runtime: "runtime-2", LambdaType: "ENDPOINT", CreatedAt: 5},
+		{Id: "lambda-6", Name: "cron", Runtime: "runtime-9", LambdaType: "INTERNAL", CreatedAt: 6},
+	}
+	endpoints := []api.Endpoint{
+		{Id: "endpoint-7", Name: "hello", Path: "/hello", Lambda: "lambda-5"},
+		{Id: "endpoint-8", Name: "hi", Path: "/hi", Lambda: "lambda-4"},
+		{Id: "endpoint-9", Name: "gone", Path: "/gone", Lambda: "lambda-10"},
+	}
+	builds := map[string]history.RuntimeBuild{
+		"runtime-2": {Source: "/src/runtimes/go", Dockerfile: "Dockerfile.prod", BuildArgs: map[string]string{"VERSION": "1.21"}},
+	}
+	deployments := map[string]history.Deployment{
+		"lambda-5": {Source: "/src/lambdas/hello"},
+	}
+
+	s, warnings := FromLive(runtimes, lambdas, endpoints, builds, deployments, "/src")
+
+	want := &Stack{
+		Runtimes: map[string]StackRuntime{
+			"go":    {Source: "runtimes/go", Dockerfile: "Dockerfile.prod", BuildArgs: map[string]string{"VERSION": "1.21"}},
+			"shell": {Source: SourcePlaceholder},
+		},
+		Lambdas: map[string]StackLambda{
+			"hello": {Source: "lambdas/hello", Runtime: "go", Type: "ENDPOINT", Endpoints: []Endpoint{{Name: "hello", Path: "/hello"}, {Name: "hi", Path: "/hi"}}},
+			"cron":  {Source: SourcePlaceholder, Runtime: "runtime-9", Type: "INTERNAL"},
+		},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("FromLive() =\n%+v\nwant\n%+v", s, want)
+	}
+
+	if n := s.Placeholders(); n != 2 {
+		t.Errorf("Placeholders() = %d, want 2", n)
+	}
+
+	wantWarnings := []string{
+		"lambda cron uses the missing runtime runtime-9",
+		"endpoint gone routes to the missing lambda lambda-10, left out",
+		"endpoint hi routes to lambda-4, an older duplicate of lambda hello, listed under the newest",
+		"runtime go has 2 duplicates, the newest is kept",
+		"lambda hello has 2 duplicates, the newest is kept",
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("warnings =\n%q\nwant\n%q", warnings, wantWarnings)
+	}
+
+	path := filepath.Join(t.TempDir(), "opcli.yaml")
+	if err := WriteStack(path, s, []string{"generated"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteStack(path, s, nil); err == nil {
+		t.Error("WriteStack replaced an existing file")
+	}
+	content, _ := os.ReadFile(path)
+	if string(content[:13]) != "# generated\n\n" {
+		t.Errorf("header missing from %q", content)
+	}
+
+	loaded, err := LoadStack(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, want) {
+		t.Errorf("LoadStack() =\n%+v\nwant\n%+v", loaded, want)
+	}
+}
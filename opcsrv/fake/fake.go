@@ -0,0 +1,465 @@
+// Package fake implements the onpremless API in memory, for tests and
+// offline demos. Nothing is built or run: lambdas are marked RUNNING once
+// their start task completes and uploads are only kept around.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/onpremless/go-client"
+)
+
+const (
+	TaskPending = "PENDING"
+	TaskDone    = "DONE"
+	TaskFailed  = "FAILED"
+
+	StatusCreated = "CREATED"
+	StatusRunning = "RUNNING"
+)
+
+type Options struct {
+	// TaskLatency is how long start and destroy tasks stay pending
+	TaskLatency time.Duration
+}
+
+// Failure makes the requests matching its method and path fail.
+type Failure struct {
+	// Method matches any method when empty
+	Method string
+	// Path is matched as a prefix of the request path
+	Path string
+	// Status is the HTTP status the request fails with. When zero the
+	// request is accepted but the task it starts ends FAILED, which only
+	// applies to starting and destroying lambdas: other requests are not
+	// matched, nor counted against Times.
+	Status  int
+	Message string
+	// Times is how many requests fail before the failure is cleared, every
+	// matching request fails when zero
+	Times int
+}
+
+type task struct {
+	status api.TaskStatus
+}
+
+// Server is an http.Handler serving the onpremless API.
+type Server struct {
+	opts Options
+
+	mu        sync.Mutex
+	seq       int
+	uploads   map[string][]byte
+	runtimes  map[string]*api.Runtime
+	lambdas   map[string]*api.Lambda
+	endpoints map[string]*api.Endpoint
+	tasks     map[string]*task
+	failures  []*Failure
+}
+
+func New(opts Options) *Server {
+	return &Server{
+		opts:      opts,
+		uploads:   map[string][]byte{},
+		runtimes:  map[string]*api.Runtime{},
+		lambdas:   map[string]*api.Lambda{},
+		endpoints: map[string]*api.Endpoint{},
+		tasks:     map[string]*task{},
+	}
+}
+
+// Fail injects a failure, see Failure.
+func (s *Server) Fail(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, &f)
+}
+
+// AddRuntime creates a runtime without going through an upload, to seed
+// the server.
+func (s *Server) AddRuntime(name string) api.Runtime {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	rt := &api.Runtime{Id: s.nextID("runtime"), Name: name, CreatedAt: now, UpdatedAt: now}
+	s.runtimes[rt.Id] = rt
+
+	return *rt
+}
+
+// Upload returns the content of an uploaded file.
+func (s *Server) Upload(id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, ok := s.uploads[id]
+	return content, ok
+}
+
+// Archive returns the content of the archive a lambda has been created
+// from.
+func (s *Server) Archive(lambdaID string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, ok := s.uploads[archiveKey(lambdaID)]
+	return content, ok
+}
+
+func (s *Server) Runtimes() []api.Runtime {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return values(s.runtimes)
+}
+
+func (s *Server) Lambdas() []api.Lambda {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return values(s.lambdas)
+}
+
+func (s *Server) Endpoints() []api.Endpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return values(s.endpoints)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Failures without a status apply to the tasks of these only
+	task := r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "lambda" && (parts[2] == "start" || parts[2] == "destroy")
+
+	failure := s.failure(r, task)
+	if failure != nil && failure.Status != 0 {
+		writeError(w, failure.Status, failure.Message)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 1 && parts[0] == "upload":
+		s.upload(w, r)
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "task":
+		s.getTask(w, parts[1])
+
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "runtime":
+		writeJSON(w, http.StatusOK, values(s.runtimes))
+	case r.Method == http.MethodPost && len(parts) == 1 && parts[0] == "runtime":
+		s.createRuntime(w, r)
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "runtime":
+		writeObject(w, s.runtimes, parts[1])
+
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "lambda":
+		writeJSON(w, http.StatusOK, values(s.lambdas))
+	case r.Method == http.MethodPost && len(parts) == 1 && parts[0] == "lambda":
+		s.createLambda(w, r)
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "lambda":
+		writeObject(w, s.lambdas, parts[1])
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "lambda" && parts[2] == "start":
+		s.lambdaTask(w, parts[1], failure, func(l *api.Lambda) {
+			l.Docker.Status = StatusRunning
+			l.UpdatedAt = time.Now().UnixMilli()
+		})
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "lambda" && parts[2] == "destroy":
+		s.lambdaTask(w, parts[1], failure, func(l *api.Lambda) {
+			delete(s.lambdas, l.Id)
+			delete(s.uploads, archiveKey(l.Id))
+		})
+
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "endpoint":
+		writeJSON(w, http.StatusOK, values(s.endpoints))
+	case r.Method == http.MethodPost && len(parts) == 1 && parts[0] == "endpoint":
+		s.createEndpoint(w, r)
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "endpoint":
+		writeObject(w, s.endpoints, parts[1])
+	case r.Method == http.MethodDelete && len(parts) == 2 && parts[0] == "endpoint":
+		if _, ok := s.endpoints[parts[1]]; !ok {
+			writeError(w, http.StatusNotFound, "endpoint not found")
+			return
+		}
+		delete(s.endpoints, parts[1])
+		writeJSON(w, http.StatusOK, map[string]string{})
+
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// failure returns the first injected failure matching the request, counting
+// it against its limit. Failures without a status only match task requests.
+func (s *Server) failure(r *http.Request, task bool) *Failure {
+	for i, f := range s.failures {
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+		if f.Status == 0 && !task {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+		}
+
+		return f
+	}
+
+	return nil
+}
+
+func (s *Server) nextID(kind string) string {
+	s.seq++
+	return fmt.Sprintf("%s-%d", kind, s.seq)
+}
+
+func (s *Server) upload(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("file is required: %s", err))
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id := s.nextID("upload")
+	s.uploads[id] = content
+
+	writeJSON(w, http.StatusOK, api.UploadResponse{Id: id})
+}
+
+func (s *Server) getTask(w http.ResponseWriter, id string) {
+	t, ok := s.tasks[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "task not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t.status)
+}
+
+func (s *Server) createRuntime(w http.ResponseWriter, r *http.Request) {
+	var req api.CreateRuntime
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if _, ok := s.uploads[req.Dockerfile]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("upload not found: %s", req.Dockerfile))
+		return
+	}
+	delete(s.uploads, req.Dockerfile)
+
+	now := time.Now().UnixMilli()
+	rt := &api.Runtime{Id: s.nextID("runtime"), Name: req.Name, CreatedAt: now, UpdatedAt: now}
+	s.runtimes[rt.Id] = rt
+
+	writeJSON(w, http.StatusOK, rt)
+}
+
+func (s *Server) createLambda(w http.ResponseWriter, r *http.Request) {
+	var req api.CreateLambda
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if req.LambdaType != "ENDPOINT" && req.LambdaType != "INTERNAL" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid lambda type: %q", req.LambdaType))
+		return
+	}
+	if _, ok := s.runtimes[req.Runtime]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("runtime not found: %s", req.Runtime))
+		return
+	}
+	archive, ok := s.uploads[req.Archive]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("upload not found: %s", req.Archive))
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	l := &api.Lambda{
+		Id:         s.nextID("lambda"),
+		Name:       req.Name,
+		Runtime:    req.Runtime,
+		LambdaType: req.LambdaType,
+		Docker:     api.Docker{Status: StatusCreated},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	s.lambdas[l.Id] = l
+
+	delete(s.uploads, req.Archive)
+	s.uploads[archiveKey(l.Id)] = archive
+
+	writeJSON(w, http.StatusOK, l)
+}
+
+// lambdaTask starts a task applying done to the lambda once it completes,
+// after the configured latency, unless failure makes it fail.
+func (s *Server) lambdaTask(w http.ResponseWriter, id string, failure *Failure, done func(l *api.Lambda)) {
+	if _, ok := s.lambdas[id]; !ok {
+		writeError(w, http.StatusNotFound, "lambda not found")
+		return
+	}
+
+	t := &task{status: api.TaskStatus{Status: TaskPending, StartedAt: time.Now().UnixMilli()}}
+	taskID := s.nextID("task")
+	s.tasks[taskID] = t
+
+	complete := func() {
+		finished := time.Now().UnixMilli()
+		t.status.FinishedAt = &finished
+
+		if failure != nil {
+			t.status.Status = TaskFailed
+			t.status.Details = map[string]interface{}{"error": failure.Message}
+			return
+		}
+
+		t.status.Status = TaskDone
+		if l, ok := s.lambdas[id]; ok {
+			done(l)
+		}
+	}
+
+	if s.opts.TaskLatency <= 0 {
+		complete()
+	} else {
+		time.AfterFunc(s.opts.TaskLatency, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			complete()
+		})
+	}
+
+	writeJSON(w, http.StatusOK, api.TaskResponse{Task: taskID})
+}
+
+func (s *Server) createEndpoint(w http.ResponseWriter, r *http.Request) {
+	var req api.CreateEndpoint
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Name == "" || req.Path == "" {
+		writeError(w, http.StatusBadRequest, "name and path are required")
+		return
+	}
+	if _, ok := s.lambdas[req.Lambda]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("lambda not found: %s", req.Lambda))
+		return
+	}
+	for _, e := range s.endpoints {
+		if e.Path == req.Path {
+			writeError(w, http.StatusConflict, fmt.Sprintf("path already routed by endpoint %s", e.Id))
+			return
+		}
+	}
+
+	now := time.Now().UnixMilli()
+	e := &api.Endpoint{
+		Id:        s.nextID("endpoint"),
+		Name:      req.Name,
+		Path:      req.Path,
+		Lambda:    req.Lambda,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.endpoints[e.Id] = e
+
+	writeJSON(w, http.StatusOK, e)
+}
+
+func archiveKey(lambdaID string) string {
+	return "archive/" + lambdaID
+}
+
+type object interface {
+	api.Runtime | api.Lambda | api.Endpoint
+}
+
+func writeObject[T object](w http.ResponseWriter, objects map[string]*T, id string) {
+	o, ok := objects[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, o)
+}
+
+// values returns copies of the objects in creation order, IDs embedding an
+// increasing sequence number.
+func values[T object](objects map[string]*T) []T {
+	type entry struct {
+		seq int
+		o   T
+	}
+
+	entries := make([]entry, 0, len(objects))
+	for id, o := range objects {
+		var seq int
+		fmt.Sscanf(id[strings.LastIndex(id, "-")+1:], "%d", &seq)
+		entries = append(entries, entry{seq, *o})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+
+	list := make([]T, len(entries))
+	for i, e := range entries {
+		list[i] = e.o
+	}
+
+	return list
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	if msg == "" {
+		msg = http.StatusText(code)
+	}
+
+	writeJSON(w, code, api.Error{Error: &msg})
+}
@@ -0,0 +1,138 @@
+package fake_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onpremless/opcli/opcsrv/fake"
+	"github.com/onpremless/opcli/ops"
+)
+
+func writeSources(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	fake.WriteFile(t, filepath.Join(dir, "handler.sh"), "echo hello\n")
+
+	return dir
+}
+
+func TestDeployAndRouteLambda(t *testing.T) {
+	srv, _, c := fake.NewTestClient(t, fake.Options{TaskLatency: 30 * time.Millisecond})
+	ctx := context.Background()
+
+	dockerfile := filepath.Join(t.TempDir(), "Dockerfile")
+	if err := os.WriteFile(dockerfile, []byte("FROM alpine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rt, err := c.CreateRuntime(ctx, ops.CreateRuntimeM{Name: "shell"}, dockerfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := c.DeployLambda(ctx, ops.CreateLambdaM{Name: "hello", Runtime: rt.Id, LambdaType: "ENDPOINT"}, writeSources(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Docker.Status != fake.StatusRunning {
+		t.Errorf("status = %s, want %s", l.Docker.Status, fake.StatusRunning)
+	}
+
+	archive, ok := srv.Archive(l.Id)
+	if !ok {
+		t.Fatal("archive was not kept")
+	}
+	header, err := tar.NewReader(bytes.NewReader(archive)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Name != "handler.sh" {
+		t.Errorf("archive holds %s, want handler.sh", header.Name)
+	}
+
+	if _, err := c.RouteEndpoint(ctx, "hello", "/hello", l.Id); err != nil {
+		t.Fatal(err)
+	}
+	e, err := c.FindEndpoint(ctx, "/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Lambda != l.Id {
+		t.Errorf("endpoint routes to %s, want %s", e.Lambda, l.Id)
+	}
+
+	if err := c.DestroyLambda(ctx, l.Id); err != nil {
+		t.Fatal(err)
+	}
+	if lambdas := srv.Lambdas(); len(lambdas) != 0 {
+		t.Errorf("lambdas left after destroy: %v", lambdas)
+	}
+}
+
+func TestCreateLambdaValidatesRuntime(t *testing.T) {
+	_, _, c := fake.NewTestClient(t, fake.Options{})
+
+	_, err := c.CreateLambda(context.Background(), ops.CreateLambdaM{Name: "hello", Runtime: "missing", LambdaType: "INTERNAL"}, writeSources(t))
+	if err == nil || !strings.Contains(err.Error(), "runtime not found") {
+		t.Errorf("err = %v, want runtime not found", err)
+	}
+}
+
+func TestInjectedHTTPFailure(t *testing.T) {
+	srv, _, c := fake.NewTestClient(t, fake.Options{})
+	srv.Fail(fake.Failure{Method: http.MethodGet, Path: "/lambda", Status: http.StatusInternalServerError, Times: 1})
+
+	if _, err := c.ListLambdas(context.Background()); err == nil {
+		t.Fatal("expected the first request to fail")
+	}
+	if _, err := c.ListLambdas(context.Background()); err != nil {
+		t.Fatalf("failure was not cleared: %v", err)
+	}
+}
+
+func TestInjectedTaskFailure(t *testing.T) {
+	srv, _, c := fake.NewTestClient(t, fake.Options{})
+	rt := srv.AddRuntime("shell")
+	srv.Fail(fake.Failure{Path: "/lambda/", Message: "image build failed"})
+
+	_, err := c.DeployLambda(context.Background(), ops.CreateLambdaM{Name: "hello", Runtime: rt.Id, LambdaType: "INTERNAL"}, writeSources(t))
+	if err == nil || !strings.Contains(err.Error(), "image build failed") {
+		t.Errorf("err = %v, want the injected task failure", err)
+	}
+
+	lambdas := srv.Lambdas()
+	if len(lambdas) != 1 || lambdas[0].Docker.Status != fake.StatusCreated {
+		t.Errorf("lambdas = %v, want a single lambda left CREATED", lambdas)
+	}
+}
+
+func TestInjectedTaskFailureSkipsOtherRoutes(t *testing.T) {
+	srv, _, c := fake.NewTestClient(t, fake.Options{})
+	rt := srv.AddRuntime("shell")
+	srv.Fail(fake.Failure{Path: "/lambda", Message: "container exited", Times: 1})
+
+	// Creating and polling the lambda leave the failure for the start task
+	input := ops.CreateLambdaM{Name: "hello", Runtime: rt.Id, LambdaType: "INTERNAL"}
+	if _, err := c.DeployLambda(context.Background(), input, writeSources(t)); err == nil || !strings.Contains(err.Error(), "container exited") {
+		t.Errorf("err = %v, want the injected task failure", err)
+	}
+	if _, err := c.DeployLambda(context.Background(), input, writeSources(t)); err != nil {
+		t.Errorf("failure was not cleared: %v", err)
+	}
+}
+
+func TestRetryPolicyRetriesIdempotentRequests(t *testing.T) {
+	srv, _, c := fake.NewTestClient(t, fake.Options{}, ops.WithRetry(ops.RetryPolicy{Attempts: 3, Backoff: time.Millisecond}))
+	srv.Fail(fake.Failure{Method: http.MethodGet, Path: "/runtime", Status: http.StatusServiceUnavailable, Times: 2})
+	if _, err := c.ListRuntimes(context.Background()); err != nil {
+		t.Errorf("request was not retried: %v", err)
+	}
+}
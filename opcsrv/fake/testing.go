@@ -0,0 +1,39 @@
+package fake
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onpremless/opcli/ops"
+)
+
+// NewTestClient serves a new fake server until the test ends and returns it
+// along with its URL and a client to it. Client options are applied after
+// the base URL and a short poll interval.
+func NewTestClient(t *testing.T, opts Options, clientOpts ...ops.Option) (*Server, string, *ops.Client) {
+	t.Helper()
+
+	srv := New(opts)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	clientOpts = append([]ops.Option{ops.WithBaseURL(ts.URL), ops.WithPollInterval(time.Millisecond)}, clientOpts...)
+
+	return srv, ts.URL, ops.NewClient(clientOpts...)
+}
+
+// WriteFile writes a source file to send to the fake server, creating its
+// directory.
+func WriteFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
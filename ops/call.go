@@ -0,0 +1,104 @@
+package ops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	api "github.com/onpremless/go-client"
+)
+
+// CallEndpointM is an HTTP request sent to an endpoint through the gateway.
+type CallEndpointM struct {
+	Method  string
+	Headers http.Header
+	Body    []byte
+}
+
+type CallResult struct {
+	Status   string
+	Code     int
+	Proto    string
+	Headers  http.Header
+	Body     []byte
+	Duration time.Duration
+}
+
+// EndpointURL joins the gateway base URL with the endpoint path.
+func EndpointURL(gateway string, path string) (string, error) {
+	base, err := url.Parse(gateway)
+	if err != nil {
+		return "", fmt.Errorf("invalid gateway URL %q: %v", gateway, err)
+	}
+
+	base.Path = strings.TrimSuffix(base.Path, "/") + "/" + strings.TrimPrefix(path, "/")
+	return base.String(), nil
+}
+
+// FindEndpoint looks an endpoint up by its name, or by its path when ref
+// starts with a slash.
+func (c *Client) FindEndpoint(ctx context.Context, ref string) (*api.Endpoint, error) {
+	endpoints, err := c.ListEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range endpoints {
+		if strings.HasPrefix(ref, "/") && e.Path == ref || e.Name == ref {
+			return &endpoints[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("endpoint not found: %s", ref)
+}
+
+// CallEndpoint sends req to the endpoint through the gateway with the HTTP
+// client of the client. Calls are not retried, lambdas may not be
+// idempotent.
+func (c *Client) CallEndpoint(ctx context.Context, gateway string, endpoint *api.Endpoint, req CallEndpointM) (*CallResult, error) {
+	target, err := EndpointURL(gateway, endpoint.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if req.Body != nil {
+		body = bytes.NewReader(req.Body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range req.Headers {
+		for _, v := range values {
+			httpReq.Header.Add(key, v)
+		}
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CallResult{
+		Status:   resp.Status,
+		Code:     resp.StatusCode,
+		Proto:    resp.Proto,
+		Headers:  resp.Header,
+		Body:     respBody,
+		Duration: time.Since(start),
+	}, nil
+}
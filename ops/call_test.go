@@ -0,0 +1,102 @@
+package ops_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/ops"
+)
+
+func TestEndpointURL(t *testing.T) {
+	tests := []struct {
+		gateway string
+		path    string
+		want    string
+	}{
+		{"http://gw.test", "/hello", "http://gw.test/hello"},
+		{"http://gw.test/", "/hello", "http://gw.test/hello"},
+		{"http://gw.test", "hello", "http://gw.test/hello"},
+		{"http://gw.test/api/", "/v1/hello", "http://gw.test/api/v1/hello"},
+		{"https://gw.test:8443?debug=1", "/hello", "https://gw.test:8443/hello?debug=1"},
+	}
+
+	for _, tt := range tests {
+		got, err := ops.EndpointURL(tt.gateway, tt.path)
+		if err != nil || got != tt.want {
+			t.Errorf("EndpointURL(%q, %q) = %q, %v, want %q", tt.gateway, tt.path, got, err, tt.want)
+		}
+	}
+
+	if _, err := ops.EndpointURL("http://[::1", "/hello"); err == nil {
+		t.Error("EndpointURL() accepted an invalid gateway URL")
+	}
+}
+
+func TestCallEndpoint(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Trace", r.Header.Get("X-Trace"))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(r.Method + " " + string(body)))
+	}))
+	defer ts.Close()
+
+	c := ops.NewClient()
+	endpoint := &api.Endpoint{Name: "hello", Path: "/hello"}
+
+	tests := []struct {
+		name string
+		req  ops.CallEndpointM
+		want string
+	}{
+		{"get", ops.CallEndpointM{Method: http.MethodGet}, "GET "},
+		{"post with body", ops.CallEndpointM{Method: http.MethodPost, Body: []byte(`{"a":1}`)}, `POST {"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.Headers = http.Header{"X-Trace": {"abc"}}
+
+			res, err := c.CallEndpoint(context.Background(), ts.URL+"/gw/", endpoint, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Code != http.StatusAccepted || res.Status != "202 Accepted" || res.Proto != "HTTP/1.1" {
+				t.Errorf("status = %d %q %s", res.Code, res.Status, res.Proto)
+			}
+			if res.Headers.Get("X-Path") != "/gw/hello" || res.Headers.Get("X-Trace") != "abc" {
+				t.Errorf("headers = %v", res.Headers)
+			}
+			if string(res.Body) != tt.want {
+				t.Errorf("body = %q, want %q", res.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallEndpointIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c := ops.NewClient(ops.WithRetry(ops.RetryPolicy{Attempts: 3, Backoff: time.Millisecond}))
+	res, err := c.CallEndpoint(context.Background(), ts.URL, &api.Endpoint{Path: "/hello"}, ops.CallEndpointM{Method: http.MethodGet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("got %d after %d call(s), want a single 503", res.Code, calls.Load())
+	}
+}
@@ -0,0 +1,130 @@
+package ops
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	api "github.com/onpremless/go-client"
+)
+
+const (
+	DefaultServer       = "http://localhost:8081"
+	DefaultPollInterval = time.Second
+)
+
+// API is what the CLI needs from an onpremless server, implemented by
+// Client.
+type API interface {
+	CreateRuntime(ctx context.Context, runtime CreateRuntimeM, path string) (*api.Runtime, error)
+	GetRuntime(ctx context.Context, id string) (*api.Runtime, error)
+	ListRuntimes(ctx context.Context) ([]api.Runtime, error)
+	FindRuntime(ctx context.Context, ref string) (*api.Runtime, error)
+
+	CreateLambda(ctx context.Context, lambda CreateLambdaM, path string) (*api.Lambda, error)
+	GetLambda(ctx context.Context, id string) (*api.Lambda, error)
+	ListLambdas(ctx context.Context) ([]api.Lambda, error)
+	FindLambda(ctx context.Context, ref string) (*api.Lambda, error)
+	StartLambda(ctx context.Context, id string) (*api.Lambda, error)
+	DestroyLambda(ctx context.Context, id string) error
+	DeployLambda(ctx context.Context, input CreateLambdaM, path string) (*api.Lambda, error)
+
+	CreateEndpoint(ctx context.Context, req *api.CreateEndpoint) (*api.Endpoint, error)
+	ListEndpoints(ctx context.Context) ([]api.Endpoint, error)
+	DeleteEndpoint(ctx context.Context, id string) error
+	RouteEndpoint(ctx context.Context, name string, path string, lambdaID string) (*api.Endpoint, error)
+	FindEndpoint(ctx context.Context, ref string) (*api.Endpoint, error)
+	CallEndpoint(ctx context.Context, gateway string, endpoint *api.Endpoint, req CallEndpointM) (*CallResult, error)
+}
+
+var _ API = (*Client)(nil)
+
+// Client talks to a single onpremless API server.
+type Client struct {
+	api *api.APIClient
+	// httpClient sends requests outside of the API, such as endpoint calls,
+	// which are never retried
+	httpClient   *http.Client
+	pollInterval time.Duration
+}
+
+type clientConfig struct {
+	baseURL      string
+	httpClient   *http.Client
+	token        string
+	retry        RetryPolicy
+	pollInterval time.Duration
+}
+
+type Option func(*clientConfig)
+
+// WithBaseURL sets the URL of the API server, DefaultServer by default.
+func WithBaseURL(url string) Option {
+	return func(c *clientConfig) {
+		c.baseURL = url
+	}
+}
+
+// WithHTTPClient sets the HTTP client requests are sent with,
+// http.DefaultClient by default.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *clientConfig) {
+		c.httpClient = client
+	}
+}
+
+// WithToken authenticates requests with a bearer token.
+func WithToken(token string) Option {
+	return func(c *clientConfig) {
+		c.token = token
+	}
+}
+
+// WithRetry retries failed idempotent requests, see RetryPolicy.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *clientConfig) {
+		c.retry = policy
+	}
+}
+
+// WithPollInterval sets how often pending tasks are polled,
+// DefaultPollInterval by default.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *clientConfig) {
+		c.pollInterval = interval
+	}
+}
+
+func NewClient(opts ...Option) *Client {
+	cfg := &clientConfig{
+		baseURL:      DefaultServer,
+		httpClient:   http.DefaultClient,
+		pollInterval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	config := api.NewConfiguration()
+	config.Servers = api.ServerConfigurations{
+		{
+			URL: cfg.baseURL,
+		},
+	}
+	if cfg.token != "" {
+		config.AddDefaultHeader("Authorization", "Bearer "+cfg.token)
+	}
+
+	config.HTTPClient = cfg.httpClient
+	if cfg.retry.Attempts > 1 {
+		client := *cfg.httpClient
+		client.Transport = &retryTransport{next: client.Transport, policy: cfg.retry}
+		config.HTTPClient = &client
+	}
+
+	return &Client{
+		api:          api.NewAPIClient(config),
+		httpClient:   cfg.httpClient,
+		pollInterval: cfg.pollInterval,
+	}
+}
@@ -4,51 +4,45 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	"sort"
 	"time"
 
 	api "github.com/onpremless/go-client"
 )
 
-var client *api.APIClient
-
-func init() {
-	config := api.NewConfiguration()
-	config.Servers = api.ServerConfigurations{
-		{
-			// XXX: WOAH JASON, NICE HARD-CODED URL!
-			URL: "http://localhost:8081",
-		},
-	}
-
-	client = api.NewAPIClient(config)
-}
-
-func upload(ctx context.Context, path string, isDir bool) (string, error) {
+func (c *Client) upload(ctx context.Context, path string, isDir bool) (string, error) {
 	if isDir {
 		var err error
-		path, err = tarPath(path)
+		path, err = PackageLambda(path)
 		if err != nil {
 			return "", err
 		}
+		defer os.Remove(path)
 	}
 
+	return c.uploadFile(ctx, path)
+}
+
+// PackageLambda writes the archive CreateLambda uploads for the src
+// directory to a temporary file, which the caller removes.
+func PackageLambda(src string) (string, error) {
+	return tarPath(src, nil)
+}
+
+func (c *Client) uploadFile(ctx context.Context, path string) (string, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
-	defer func() {
-		if isDir {
-			os.Remove(path)
-		}
-	}()
 
-	uploadResp, _, err := client.UploadAPI.Upload(ctx).File(file).Execute()
+	uploadResp, _, err := c.api.UploadAPI.Upload(ctx).File(file).Execute()
 	if err != nil {
 		return "", fmt.Errorf("error when calling `UploadApi.Upload``: %v", err)
 	}
@@ -56,9 +50,9 @@ func upload(ctx context.Context, path string, isDir bool) (string, error) {
 	return uploadResp.GetId(), nil
 }
 
-func pollTask(ctx context.Context, id string) (*api.TaskStatus, error) {
+func (c *Client) pollTask(ctx context.Context, id string) (*api.TaskStatus, error) {
 	for {
-		resp, _, err := client.TaskAPI.
+		resp, _, err := c.api.TaskAPI.
 			GetTask(ctx, id).
 			Execute()
 		if err != nil {
@@ -69,46 +63,132 @@ func pollTask(ctx context.Context, id string) (*api.TaskStatus, error) {
 			return resp, nil
 		}
 
-		time.Sleep(time.Second)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.pollInterval):
+		}
 	}
 }
 
-func tarPath(src string) (string, error) {
-	if _, err := os.Stat(src); os.IsNotExist(err) {
-		return "", fmt.Errorf("path does not exist: %s", src)
+// walkContext calls fn for every path of the src directory that is not
+// excluded by its .dockerignore, with name being the slash separated path
+// relative to src. Paths are visited in lexical order.
+func walkContext(src string, fn func(name string, file string, info os.FileInfo) error) error {
+	src, err := filepath.Abs(src)
+	if err != nil {
+		return err
 	}
 
-	src, err := filepath.Abs(src)
+	rules, err := loadIgnoreRules(src)
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(src, func(file string, info os.FileInfo, lerr error) error {
+		if lerr != nil {
+			return lerr
+		}
+
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		name := filepath.ToSlash(rel)
+		if rules.Ignored(name) {
+			if info.IsDir() && !rules.hasExceptions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return fn(name, file, info)
+	})
+}
+
+// SourceDigest hashes the paths, modes and contents of everything packaged
+// from the src directory, so that it changes whenever the package would. A
+// src file, such as a runtime Dockerfile, is hashed on its own.
+func SourceDigest(src string) (string, error) {
+	info, err := os.Stat(src)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("path does not exist: %s", src)
+	}
 	if err != nil {
 		return "", err
 	}
 
-	if !strings.HasSuffix(src, "/") {
-		src += "/"
+	hash := sha256.New()
+	if !info.IsDir() {
+		content, err := os.ReadFile(src)
+		if err != nil {
+			return "", err
+		}
+		hash.Write(content)
+
+		return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	err = walkContext(src, func(name string, file string, info os.FileInfo) error {
+		fmt.Fprintf(hash, "%s\x00%o\x00", name, info.Mode())
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		data, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer data.Close()
+
+		_, err = io.Copy(hash, data)
+		return err
+	})
+	if err != nil {
+		return "", err
 	}
 
-	lsrc := len(src)
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// tarPath packages the src directory into a temporary tar archive, leaving
+// out paths excluded by its .dockerignore. Entries of overrides replace (or
+// add) files at the given archive paths.
+func tarPath(src string, overrides map[string][]byte) (string, error) {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return "", fmt.Errorf("path does not exist: %s", src)
+	}
 
 	var buffer bytes.Buffer
 	writer := tar.NewWriter(&buffer)
 
-	err = filepath.Walk(src, func(file string, info os.FileInfo, lerr error) error {
+	err := walkContext(src, func(name string, file string, info os.FileInfo) error {
+		if _, ok := overrides[name]; ok {
+			return nil
+		}
+
 		header, err := tar.FileInfoHeader(info, file)
 		if err != nil {
 			return err
 		}
 
-		header.Name = filepath.ToSlash(file)[lsrc:]
+		header.Name = name
 
 		if err := writer.WriteHeader(header); err != nil {
 			return err
 		}
 
-		if !info.IsDir() {
+		if info.Mode().IsRegular() {
 			data, err := os.Open(file)
 			if err != nil {
 				return err
 			}
+			defer data.Close()
+
 			if _, err := io.Copy(writer, data); err != nil {
 				return err
 			}
@@ -116,11 +196,36 @@ func tarPath(src string) (string, error) {
 
 		return nil
 	})
-
 	if err != nil {
 		return "", err
 	}
 
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		content := overrides[name]
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: time.Now(),
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			return "", err
+		}
+		if _, err := writer.Write(content); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
 	file, err := os.CreateTemp("", "lambda-")
 	if err != nil {
 		return "", err
@@ -134,3 +239,13 @@ func tarPath(src string) (string, error) {
 
 	return file.Name(), nil
 }
+
+// Timestamp converts a server timestamp, tolerating both second and
+// millisecond precision.
+func Timestamp(ts int64) time.Time {
+	if ts > 1e12 {
+		return time.UnixMilli(ts)
+	}
+
+	return time.Unix(ts, 0)
+}
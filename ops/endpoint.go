@@ -7,8 +7,8 @@ import (
 	api "github.com/onpremless/go-client"
 )
 
-func CreateEndpoint(ctx context.Context, req *api.CreateEndpoint) (*api.Endpoint, error) {
-	createResp, _, err := client.EndpointAPI.
+func (c *Client) CreateEndpoint(ctx context.Context, req *api.CreateEndpoint) (*api.Endpoint, error) {
+	createResp, _, err := c.api.EndpointAPI.
 		CreateEndpoint(ctx).
 		CreateEndpoint(*req).
 		Execute()
@@ -19,8 +19,8 @@ func CreateEndpoint(ctx context.Context, req *api.CreateEndpoint) (*api.Endpoint
 	return createResp, nil
 }
 
-func ListEndpoints(ctx context.Context) ([]api.Endpoint, error) {
-	listResp, _, err := client.EndpointAPI.
+func (c *Client) ListEndpoints(ctx context.Context) ([]api.Endpoint, error) {
+	listResp, _, err := c.api.EndpointAPI.
 		ListEndpoints(ctx).
 		Execute()
 	if err != nil {
@@ -29,3 +29,44 @@ func ListEndpoints(ctx context.Context) ([]api.Endpoint, error) {
 
 	return listResp, nil
 }
+
+func (c *Client) DeleteEndpoint(ctx context.Context, id string) error {
+	_, err := c.api.EndpointAPI.
+		DeleteEndpoint(ctx, id).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("error when calling `EndpointApi.DeleteEndpoint``: %v", err)
+	}
+
+	return nil
+}
+
+// RouteEndpoint makes the endpoint with the given name and path route to the
+// lambda. Endpoints cannot be updated, so an existing endpoint with the same
+// name or path is replaced.
+func (c *Client) RouteEndpoint(ctx context.Context, name string, path string, lambdaID string) (*api.Endpoint, error) {
+	endpoints, err := c.ListEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range endpoints {
+		if e.Name != name && e.Path != path {
+			continue
+		}
+
+		if e.Name == name && e.Path == path && e.Lambda == lambdaID {
+			return &e, nil
+		}
+
+		if err := c.DeleteEndpoint(ctx, e.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.CreateEndpoint(ctx, &api.CreateEndpoint{
+		Name:   name,
+		Path:   path,
+		Lambda: lambdaID,
+	})
+}
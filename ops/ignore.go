@@ -0,0 +1,120 @@
+package ops
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const ignoreFile = ".dockerignore"
+
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+}
+
+// ignoreRules implements the subset of .dockerignore semantics needed for
+// packaging: glob patterns with "*", "?" and "**", matched against slash
+// separated paths relative to the context root, with "!" exceptions. The
+// last matching rule wins.
+type ignoreRules []ignoreRule
+
+func loadIgnoreRules(root string) (ignoreRules, error) {
+	file, err := os.Open(filepath.Join(root, ignoreFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules ignoreRules
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = strings.TrimSpace(line[1:])
+		}
+
+		line = strings.Trim(filepath.ToSlash(filepath.Clean(line)), "/")
+		rule.pattern, err = compileIgnorePattern(line)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+func compileIgnorePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// "**/" matches zero or more directories
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	// A pattern matching a directory excludes everything beneath it
+	b.WriteString("(/.*)?$")
+
+	return regexp.Compile(b.String())
+}
+
+// Ignored reports whether the slash separated path relative to the context
+// root is excluded.
+func (r ignoreRules) Ignored(rel string) bool {
+	ignored := false
+	for _, rule := range r {
+		if rule.pattern.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+// hasExceptions reports whether any rule re-includes paths, in which case
+// ignored directories still have to be walked.
+func (r ignoreRules) hasExceptions() bool {
+	for _, rule := range r {
+		if rule.negate {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package ops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadIgnoreRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		ignored map[string]bool
+	}{
+		{
+			name:  "globs",
+			rules: "# comments and blank lines are skipped\n\n*.log\nbuild?\n",
+			ignored: map[string]bool{
+				"app.log":      true,
+				"logs/app.log": false,
+				"build1":       true,
+				"build12":      false,
+				"main.go":      false,
+			},
+		},
+		{
+			name:  "double star",
+			rules: "**/*.tmp\ndocs/**\n",
+			ignored: map[string]bool{
+				"a.tmp":         true,
+				"a/b/c.tmp":     true,
+				"docs/api/x.md": true,
+				"docs":          false,
+				"src/docs.md":   false,
+			},
+		},
+		{
+			name:  "directory patterns",
+			rules: "/node_modules/\n./vendor\ncache/\n",
+			ignored: map[string]bool{
+				"node_modules":        true,
+				"node_modules/x/a.js": true,
+				"vendor/lib/lib.go":   true,
+				"cache":               true,
+				"cache/entry":         true,
+				"src/cache/entry":     false,
+				"node_modules_backup": false,
+			},
+		},
+		{
+			name:  "negation",
+			rules: "*.md\n!README.md\ndocs\n! docs/keep.txt\n",
+			ignored: map[string]bool{
+				"CHANGES.md":    true,
+				"README.md":     false,
+				"docs/a.txt":    true,
+				"docs/keep.txt": false,
+			},
+		},
+		{
+			name:  "last match wins",
+			rules: "!secret.txt\n*.txt\n",
+			ignored: map[string]bool{
+				"secret.txt": true,
+			},
+		},
+		{
+			name:  "escapes",
+			rules: `\*.txt` + "\n",
+			ignored: map[string]bool{
+				"*.txt":   true,
+				"any.txt": false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, ignoreFile), []byte(tt.rules), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			rules, err := loadIgnoreRules(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for path, want := range tt.ignored {
+				if got := rules.Ignored(path); got != want {
+					t.Errorf("Ignored(%q) = %v, want %v", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadIgnoreRulesWithoutFile(t *testing.T) {
+	rules, err := loadIgnoreRules(t.TempDir())
+	if err != nil || rules != nil {
+		t.Errorf("loadIgnoreRules() = %v, %v, want no rules", rules, err)
+	}
+	if rules.Ignored("anything") || rules.hasExceptions() {
+		t.Error("no rules ignored a path")
+	}
+}
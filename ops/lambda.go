@@ -14,13 +14,13 @@ type CreateLambdaM struct {
 	LambdaType string
 }
 
-func CreateLambda(ctx context.Context, lambda CreateLambdaM, path string) (*api.Lambda, error) {
-	uploadID, err := upload(ctx, path, true)
+func (c *Client) CreateLambda(ctx context.Context, lambda CreateLambdaM, path string) (*api.Lambda, error) {
+	uploadID, err := c.upload(ctx, path, true)
 	if err != nil {
 		return nil, err
 	}
 
-	createResp, r, err := client.LambdaAPI.
+	createResp, r, err := c.api.LambdaAPI.
 		CreateLambda(ctx).
 		CreateLambda(api.CreateLambda{
 			Name:       lambda.Name,
@@ -31,15 +31,17 @@ func CreateLambda(ctx context.Context, lambda CreateLambdaM, path string) (*api.
 		Execute()
 	if err != nil {
 		var details api.Error
-		json.NewDecoder(r.Body).Decode(&details)
+		if r != nil {
+			json.NewDecoder(r.Body).Decode(&details)
+		}
 		return nil, fmt.Errorf("error when calling `LambdaApi.CreateLambda``: %v\n%v", err, details.GetError())
 	}
 
 	return createResp, nil
 }
 
-func GetLambda(ctx context.Context, id string) (*api.Lambda, error) {
-	resp, _, err := client.LambdaAPI.
+func (c *Client) GetLambda(ctx context.Context, id string) (*api.Lambda, error) {
+	resp, _, err := c.api.LambdaAPI.
 		GetLambda(ctx, id).
 		Execute()
 	if err != nil {
@@ -49,8 +51,8 @@ func GetLambda(ctx context.Context, id string) (*api.Lambda, error) {
 	return resp, nil
 }
 
-func ListLambdas(ctx context.Context) ([]api.Lambda, error) {
-	resp, _, err := client.LambdaAPI.
+func (c *Client) ListLambdas(ctx context.Context) ([]api.Lambda, error) {
+	resp, _, err := c.api.LambdaAPI.
 		ListLambdas(ctx).
 		Execute()
 	if err != nil {
@@ -60,15 +62,42 @@ func ListLambdas(ctx context.Context) ([]api.Lambda, error) {
 	return resp, nil
 }
 
-func StartLambda(ctx context.Context, id string) (*api.Lambda, error) {
-	taskResp, _, err := client.LambdaAPI.
+// FindLambda looks a lambda up by its ID or name, the newest one winning
+// among lambdas of the same name.
+func (c *Client) FindLambda(ctx context.Context, ref string) (*api.Lambda, error) {
+	lambdas, err := c.ListLambdas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, l := range lambdas {
+		if l.Id == ref {
+			return &lambdas[i], nil
+		}
+	}
+
+	var found *api.Lambda
+	for i, l := range lambdas {
+		if l.Name == ref && (found == nil || l.CreatedAt > found.CreatedAt) {
+			found = &lambdas[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("lambda not found: %s", ref)
+	}
+
+	return found, nil
+}
+
+func (c *Client) StartLambda(ctx context.Context, id string) (*api.Lambda, error) {
+	taskResp, _, err := c.api.LambdaAPI.
 		StartLambda(ctx, id).
 		Execute()
 	if err != nil {
 		return nil, fmt.Errorf("error when calling `LambdaApi.StartLambda``: %v", err)
 	}
 
-	res, err := pollTask(ctx, taskResp.GetTask())
+	res, err := c.pollTask(ctx, taskResp.GetTask())
 	if err != nil {
 		return nil, err
 	}
@@ -77,18 +106,18 @@ func StartLambda(ctx context.Context, id string) (*api.Lambda, error) {
 		return nil, fmt.Errorf("error when starting lambda: %v", res.GetDetails()["error"])
 	}
 
-	return GetLambda(ctx, id)
+	return c.GetLambda(ctx, id)
 }
 
-func DestroyLambda(ctx context.Context, id string) error {
-	taskResp, _, err := client.LambdaAPI.
+func (c *Client) DestroyLambda(ctx context.Context, id string) error {
+	taskResp, _, err := c.api.LambdaAPI.
 		DestroyLambda(ctx, id).
 		Execute()
 	if err != nil {
 		return fmt.Errorf("error when calling `LambdaApi.DestroyLambda``: %v", err)
 	}
 
-	res, err := pollTask(ctx, taskResp.GetTask())
+	res, err := c.pollTask(ctx, taskResp.GetTask())
 	if err != nil {
 		return err
 	}
@@ -100,11 +129,11 @@ func DestroyLambda(ctx context.Context, id string) error {
 	return nil
 }
 
-func DeployLambda(ctx context.Context, input CreateLambdaM, path string) (*api.Lambda, error) {
-	lambda, err := CreateLambda(ctx, input, path)
+func (c *Client) DeployLambda(ctx context.Context, input CreateLambdaM, path string) (*api.Lambda, error) {
+	lambda, err := c.CreateLambda(ctx, input, path)
 	if err != nil {
 		return nil, err
 	}
 
-	return StartLambda(ctx, lambda.GetId())
+	return c.StartLambda(ctx, lambda.GetId())
 }
@@ -0,0 +1,435 @@
+package ops_test
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/cassette"
+	"github.com/onpremless/opcli/opcsrv/fake"
+	"github.com/onpremless/opcli/ops"
+)
+
+// Cassettes are replayed by default. With -record the tests run against the
+// server at $OPCLI_RECORD_SERVER, authenticated with $OPCLI_RECORD_TOKEN, or
+// against an in-process fake server when it is unset, and the cassettes are
+// rewritten.
+//
+// The cassettes in testdata were recorded against the in-process fake, not a
+// real server: they pin the requests the client sends, but the responses
+// replayed are the fake's. Record them again with $OPCLI_RECORD_SERVER set
+// to check the client against a real server.
+var record = flag.Bool("record", false, "record cassettes instead of replaying them")
+
+const (
+	lambdaSources = "testdata/lambda"
+	runtimeDir    = "testdata/runtime"
+)
+
+// newClient returns a client replaying testdata/cassettes/<test name>.json,
+// or recording it with -record.
+func newClient(t *testing.T) *ops.Client {
+	t.Helper()
+
+	path := filepath.Join("testdata", "cassettes", strings.ReplaceAll(t.Name(), "/", "_")+".json")
+
+	if *record {
+		server := os.Getenv("OPCLI_RECORD_SERVER")
+		if server == "" {
+			ts := httptest.NewServer(fake.New(fake.Options{TaskLatency: 20 * time.Millisecond}))
+			t.Cleanup(ts.Close)
+			server = ts.URL
+		}
+
+		rec := &cassette.Recorder{RedactKeys: []string{"token", "password", "secret"}}
+		t.Cleanup(func() {
+			if err := rec.Save(path); err != nil {
+				t.Error(err)
+			}
+		})
+
+		return ops.NewClient(
+			ops.WithBaseURL(server),
+			ops.WithHTTPClient(&http.Client{Transport: rec}),
+			ops.WithToken(os.Getenv("OPCLI_RECORD_TOKEN")),
+			ops.WithPollInterval(10*time.Millisecond),
+		)
+	}
+
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatalf("%s, run the tests with -record to create it", err)
+	}
+	t.Cleanup(func() {
+		if n := c.Remaining(); n > 0 && !t.Failed() {
+			t.Errorf("%d recorded exchanges were not replayed", n)
+		}
+	})
+
+	return ops.NewClient(
+		ops.WithBaseURL("http://opcli.test"),
+		ops.WithHTTPClient(&http.Client{Transport: c}),
+		ops.WithPollInterval(0),
+	)
+}
+
+func createRuntime(t *testing.T, c *ops.Client, name string) *api.Runtime {
+	t.Helper()
+
+	rt, err := c.CreateRuntime(context.Background(), ops.CreateRuntimeM{Name: name}, filepath.Join(runtimeDir, "Dockerfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rt
+}
+
+func createLambda(t *testing.T, c *ops.Client, name string, runtime string) *api.Lambda {
+	t.Helper()
+
+	l, err := c.CreateLambda(context.Background(), ops.CreateLambdaM{Name: name, Runtime: runtime, LambdaType: "ENDPOINT"}, lambdaSources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return l
+}
+
+func TestCreateRuntimeFromDockerfile(t *testing.T) {
+	c := newClient(t)
+
+	rt := createRuntime(t, c, "cassette-shell")
+	if rt.Name != "cassette-shell" || rt.Id == "" {
+		t.Errorf("runtime = %+v", rt)
+	}
+}
+
+func TestCreateRuntimeFromContext(t *testing.T) {
+	c := newClient(t)
+
+	rt, err := c.CreateRuntime(context.Background(), ops.CreateRuntimeM{
+		Name:      "cassette-context",
+		BuildArgs: map[string]string{"VERSION": "1"},
+	}, runtimeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.Name != "cassette-context" {
+		t.Errorf("runtime = %+v", rt)
+	}
+}
+
+func TestGetRuntime(t *testing.T) {
+	c := newClient(t)
+	created := createRuntime(t, c, "cassette-get")
+
+	rt, err := c.GetRuntime(context.Background(), created.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.Id != created.Id || rt.Name != created.Name {
+		t.Errorf("runtime = %+v, want %+v", rt, created)
+	}
+}
+
+func TestGetRuntimeNotFound(t *testing.T) {
+	c := newClient(t)
+
+	if _, err := c.GetRuntime(context.Background(), "cassette-missing"); err == nil {
+		t.Error("got no error for a missing runtime")
+	}
+}
+
+func TestListRuntimes(t *testing.T) {
+	c := newClient(t)
+	created := createRuntime(t, c, "cassette-list")
+
+	runtimes, err := c.ListRuntimes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsRuntime(runtimes, created.Id) {
+		t.Errorf("runtimes %+v miss %s", runtimes, created.Id)
+	}
+}
+
+func TestFindRuntime(t *testing.T) {
+	c := newClient(t)
+	created := createRuntime(t, c, "cassette-find")
+
+	for _, ref := range []string{created.Id, created.Name} {
+		rt, err := c.FindRuntime(context.Background(), ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rt.Id != created.Id {
+			t.Errorf("FindRuntime(%s) = %s, want %s", ref, rt.Id, created.Id)
+		}
+	}
+
+	if _, err := c.FindRuntime(context.Background(), "cassette-missing"); err == nil {
+		t.Error("got no error for a missing runtime")
+	}
+}
+
+func TestCreateLambda(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+
+	l := createLambda(t, c, "cassette-create", rt.Id)
+	if l.Name != "cassette-create" || l.Runtime != rt.Id || l.LambdaType != "ENDPOINT" {
+		t.Errorf("lambda = %+v", l)
+	}
+}
+
+func TestCreateLambdaUnknownRuntime(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.CreateLambda(context.Background(), ops.CreateLambdaM{Name: "cassette-orphan", Runtime: "cassette-missing", LambdaType: "ENDPOINT"}, lambdaSources)
+	if err == nil {
+		t.Error("got no error for an unknown runtime")
+	}
+}
+
+func TestGetLambda(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+	created := createLambda(t, c, "cassette-get", rt.Id)
+
+	l, err := c.GetLambda(context.Background(), created.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Id != created.Id || l.Name != created.Name {
+		t.Errorf("lambda = %+v, want %+v", l, created)
+	}
+}
+
+func TestListLambdas(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+	created := createLambda(t, c, "cassette-list", rt.Id)
+
+	lambdas, err := c.ListLambdas(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsLambda(lambdas, created.Id) {
+		t.Errorf("lambdas %+v miss %s", lambdas, created.Id)
+	}
+}
+
+func TestFindLambda(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+	created := createLambda(t, c, "cassette-find", rt.Id)
+
+	for _, ref := range []string{created.Id, created.Name} {
+		l, err := c.FindLambda(context.Background(), ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Id != created.Id {
+			t.Errorf("FindLambda(%s) = %s, want %s", ref, l.Id, created.Id)
+		}
+	}
+
+	if _, err := c.FindLambda(context.Background(), "cassette-missing"); err == nil {
+		t.Error("got no error for a missing lambda")
+	}
+}
+
+func TestStartLambda(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+	created := createLambda(t, c, "cassette-start", rt.Id)
+
+	l, err := c.StartLambda(context.Background(), created.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Docker.Status != "RUNNING" {
+		t.Errorf("status = %s, want RUNNING", l.Docker.Status)
+	}
+}
+
+func TestDestroyLambda(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+	created := createLambda(t, c, "cassette-destroy", rt.Id)
+	ctx := context.Background()
+
+	if err := c.DestroyLambda(ctx, created.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	lambdas, err := c.ListLambdas(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if containsLambda(lambdas, created.Id) {
+		t.Errorf("destroyed lambda %s is still listed", created.Id)
+	}
+}
+
+func TestDeployLambda(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+
+	l, err := c.DeployLambda(context.Background(), ops.CreateLambdaM{Name: "cassette-deploy", Runtime: rt.Id, LambdaType: "INTERNAL"}, lambdaSources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "cassette-deploy" || l.Docker.Status != "RUNNING" {
+		t.Errorf("lambda = %+v", l)
+	}
+}
+
+func TestCreateEndpoint(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+	l := createLambda(t, c, "cassette-endpoint", rt.Id)
+
+	e, err := c.CreateEndpoint(context.Background(), &api.CreateEndpoint{Name: "cassette-create", Path: "/cassette/create", Lambda: l.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Name != "cassette-create" || e.Path != "/cassette/create" || e.Lambda != l.Id {
+		t.Errorf("endpoint = %+v", e)
+	}
+}
+
+func TestListEndpoints(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+	l := createLambda(t, c, "cassette-endpoint", rt.Id)
+	ctx := context.Background()
+
+	created, err := c.CreateEndpoint(ctx, &api.CreateEndpoint{Name: "cassette-list", Path: "/cassette/list", Lambda: l.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints, err := c.ListEndpoints(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsEndpoint(endpoints, created.Id) {
+		t.Errorf("endpoints %+v miss %s", endpoints, created.Id)
+	}
+}
+
+func TestDeleteEndpoint(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+	l := createLambda(t, c, "cassette-endpoint", rt.Id)
+	ctx := context.Background()
+
+	created, err := c.CreateEndpoint(ctx, &api.CreateEndpoint{Name: "cassette-delete", Path: "/cassette/delete", Lambda: l.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteEndpoint(ctx, created.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints, err := c.ListEndpoints(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if containsEndpoint(endpoints, created.Id) {
+		t.Errorf("deleted endpoint %s is still listed", created.Id)
+	}
+}
+
+func TestRouteEndpoint(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+	first := createLambda(t, c, "cassette-route-1", rt.Id)
+	second := createLambda(t, c, "cassette-route-2", rt.Id)
+	ctx := context.Background()
+
+	created, err := c.RouteEndpoint(ctx, "cassette-route", "/cassette/route", first.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	same, err := c.RouteEndpoint(ctx, "cassette-route", "/cassette/route", first.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same.Id != created.Id {
+		t.Errorf("routing to the same lambda replaced endpoint %s with %s", created.Id, same.Id)
+	}
+
+	moved, err := c.RouteEndpoint(ctx, "cassette-route", "/cassette/route", second.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.Lambda != second.Id {
+		t.Errorf("endpoint routes to %s, want %s", moved.Lambda, second.Id)
+	}
+}
+
+func TestFindEndpoint(t *testing.T) {
+	c := newClient(t)
+	rt := createRuntime(t, c, "cassette-lambda")
+	l := createLambda(t, c, "cassette-endpoint", rt.Id)
+	ctx := context.Background()
+
+	created, err := c.CreateEndpoint(ctx, &api.CreateEndpoint{Name: "cassette-find", Path: "/cassette/find", Lambda: l.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ref := range []string{created.Name, created.Path} {
+		e, err := c.FindEndpoint(ctx, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Id != created.Id {
+			t.Errorf("FindEndpoint(%s) = %s, want %s", ref, e.Id, created.Id)
+		}
+	}
+
+	if _, err := c.FindEndpoint(ctx, "/cassette/missing"); err == nil {
+		t.Error("got no error for a missing endpoint")
+	}
+}
+
+func containsRuntime(runtimes []api.Runtime, id string) bool {
+	for _, rt := range runtimes {
+		if rt.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsLambda(lambdas []api.Lambda, id string) bool {
+	for _, l := range lambdas {
+		if l.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsEndpoint(endpoints []api.Endpoint, id string) bool {
+	for _, e := range endpoints {
+		if e.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
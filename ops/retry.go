@@ -0,0 +1,88 @@
+package ops
+
+import (
+	"net/http"
+	"time"
+)
+
+// RetryPolicy retries idempotent requests failing with a transport error or
+// a 429, 502, 503 or 504 response. Creating resources is never retried, as
+// the server may have acted on the request.
+type RetryPolicy struct {
+	// Attempts is the total number of attempts, retries are disabled below 2
+	Attempts int
+	// Backoff is the delay before the first retry, doubled on each retry
+	Backoff time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, Backoff: 500 * time.Millisecond}
+
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if !idempotent(req.Method) || (req.Body != nil && req.GetBody == nil) {
+		return next.RoundTrip(req)
+	}
+
+	backoff := t.policy.Backoff
+	for attempt := 1; ; attempt++ {
+		// The caller's request is never modified, retries send a copy with
+		// a fresh body
+		attemptReq := req
+		if attempt > 1 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := next.RoundTrip(attemptReq)
+		if attempt >= t.policy.Attempts || !retryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
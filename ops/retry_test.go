@@ -0,0 +1,97 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		failures  int32
+		attempts  int
+		wantCode  int
+		wantCalls int32
+	}{
+		{"retryable status", http.MethodPut, 2, 3, http.StatusOK, 3},
+		{"non-idempotent method", http.MethodPost, 2, 3, http.StatusServiceUnavailable, 1},
+		{"attempts exhausted", http.MethodGet, 5, 3, http.StatusServiceUnavailable, 3},
+		{"retries disabled", http.MethodGet, 2, 1, http.StatusServiceUnavailable, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			bodies := make(chan string, 10)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies <- string(body)
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer ts.Close()
+
+			transport := &retryTransport{policy: RetryPolicy{Attempts: tt.attempts, Backoff: time.Millisecond}}
+			req, err := http.NewRequest(tt.method, ts.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := req.Body
+
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode || calls.Load() != tt.wantCalls {
+				t.Errorf("got %d after %d call(s), want %d after %d", resp.StatusCode, calls.Load(), tt.wantCode, tt.wantCalls)
+			}
+			if req.Body != body {
+				t.Error("the caller's request body was replaced")
+			}
+			close(bodies)
+			for b := range bodies {
+				if b != "payload" {
+					t.Errorf("attempt sent body %q", b)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransportStopsOnCancel(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	transport := &retryTransport{policy: RetryPolicy{Attempts: 3, Backoff: time.Hour}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = transport.RoundTrip(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context deadline", err)
+	}
+	if calls.Load() != 1 || time.Since(start) > 5*time.Second {
+		t.Errorf("%d call(s) in %s, want a single call before the backoff was cut short", calls.Load(), time.Since(start))
+	}
+}
@@ -3,20 +3,44 @@ package ops
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	api "github.com/onpremless/go-client"
 )
 
-func CreateRuntime(ctx context.Context, name string, path string) (*api.Runtime, error) {
-	uploadID, err := upload(ctx, path, false)
+const defaultDockerfile = "Dockerfile"
+
+type CreateRuntimeM struct {
+	Name string
+	// Dockerfile names the Dockerfile inside a build context directory,
+	// "Dockerfile" when empty
+	Dockerfile string
+	BuildArgs  map[string]string
+}
+
+// CreateRuntime creates a runtime from either a single Dockerfile or a build
+// context directory. Build args are set as the defaults of the matching ARG
+// instructions, since the API takes nothing but the Dockerfile itself.
+func (c *Client) CreateRuntime(ctx context.Context, runtime CreateRuntimeM, path string) (*api.Runtime, error) {
+	archive, err := PackageRuntime(runtime, path)
 	if err != nil {
 		return nil, err
 	}
+	defer os.Remove(archive)
 
-	createResp, _, err := client.RuntimeAPI.
+	uploadID, err := c.uploadFile(ctx, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	createResp, _, err := c.api.RuntimeAPI.
 		CreateRuntime(ctx).
 		CreateRuntime(api.CreateRuntime{
-			Name:       name,
+			Name:       runtime.Name,
 			Dockerfile: uploadID,
 		}).
 		Execute()
@@ -27,8 +51,126 @@ func CreateRuntime(ctx context.Context, name string, path string) (*api.Runtime,
 	return createResp, nil
 }
 
-func GetRuntime(ctx context.Context, id string) (*api.Runtime, error) {
-	resp, _, err := client.RuntimeAPI.
+// DockerfilePath resolves the Dockerfile of a runtime created from path,
+// which is either the Dockerfile itself or a build context directory.
+func DockerfilePath(path string, dockerfile string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+
+	if dockerfile == "" {
+		dockerfile = defaultDockerfile
+	}
+
+	return filepath.Join(path, dockerfile), nil
+}
+
+// PackageRuntime writes what CreateRuntime uploads for path to a temporary
+// file: the Dockerfile, or an archive of the build context, with the build
+// args applied. The caller removes the file.
+func PackageRuntime(runtime CreateRuntimeM, path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	dockerfile, err := DockerfilePath(path, runtime.Dockerfile)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(dockerfile)
+	if err != nil {
+		return "", err
+	}
+
+	content, err = applyBuildArgs(content, runtime.BuildArgs)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() {
+		return tarPath(path, map[string][]byte{defaultDockerfile: content})
+	}
+
+	file, err := os.CreateTemp("", "runtime-")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(content); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+var argRe = regexp.MustCompile(`(?i)^(\s*ARG\s+)([A-Za-z_][A-Za-z0-9_]*)(=.*)?$`)
+
+// applyBuildArgs sets the defaults of the ARG instructions named in args.
+// Every arg has to be declared in the Dockerfile.
+func applyBuildArgs(dockerfile []byte, args map[string]string) ([]byte, error) {
+	if len(args) == 0 {
+		return dockerfile, nil
+	}
+
+	used := map[string]bool{}
+	lines := strings.Split(string(dockerfile), "\n")
+	for i, line := range lines {
+		m := argRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+
+		value, ok := args[m[2]]
+		if !ok {
+			continue
+		}
+
+		used[m[2]] = true
+		lines[i] = fmt.Sprintf("%s%s=%s", m[1], m[2], quoteArg(value))
+	}
+
+	for key := range args {
+		if !used[key] {
+			return nil, fmt.Errorf("build arg %q is not declared in the Dockerfile", key)
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func quoteArg(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\"'\\") {
+		return value
+	}
+
+	return strconv.Quote(value)
+}
+
+// ParseBuildArgs parses KEY=VAL pairs.
+func ParseBuildArgs(pairs []string) (map[string]string, error) {
+	args := map[string]string{}
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid build arg %q, expected KEY=VAL", pair)
+		}
+
+		args[key] = value
+	}
+
+	return args, nil
+}
+
+func (c *Client) GetRuntime(ctx context.Context, id string) (*api.Runtime, error) {
+	resp, _, err := c.api.RuntimeAPI.
 		GetRuntime(ctx, id).
 		Execute()
 	if err != nil {
@@ -38,8 +180,8 @@ func GetRuntime(ctx context.Context, id string) (*api.Runtime, error) {
 	return resp, nil
 }
 
-func ListRuntimes(ctx context.Context) ([]api.Runtime, error) {
-	listResp, _, err := client.RuntimeAPI.
+func (c *Client) ListRuntimes(ctx context.Context) ([]api.Runtime, error) {
+	listResp, _, err := c.api.RuntimeAPI.
 		ListRuntimes(ctx).
 		Execute()
 	if err != nil {
@@ -48,3 +190,24 @@ func ListRuntimes(ctx context.Context) ([]api.Runtime, error) {
 
 	return listResp, nil
 }
+
+// FindRuntime looks a runtime up by its ID or name.
+func (c *Client) FindRuntime(ctx context.Context, ref string) (*api.Runtime, error) {
+	runtimes, err := c.ListRuntimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, rt := range runtimes {
+		if rt.Id == ref {
+			return &runtimes[i], nil
+		}
+	}
+	for i, rt := range runtimes {
+		if rt.Name == ref {
+			return &runtimes[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("runtime not found: %s", ref)
+}
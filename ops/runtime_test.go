@@ -0,0 +1,82 @@
+package ops
+
+import (
+	"testing"
+)
+
+func TestApplyBuildArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		args       map[string]string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "no args",
+			dockerfile: "FROM alpine\nARG VERSION=1\n",
+			want:       "FROM alpine\nARG VERSION=1\n",
+		},
+		{
+			name:       "without default",
+			dockerfile: "FROM alpine\nARG VERSION\nRUN echo $VERSION\n",
+			args:       map[string]string{"VERSION": "2"},
+			want:       "FROM alpine\nARG VERSION=2\nRUN echo $VERSION\n",
+		},
+		{
+			name:       "with default",
+			dockerfile: "ARG BASE=alpine:3.18\nFROM ${BASE}\n",
+			args:       map[string]string{"BASE": "alpine:3.19"},
+			want:       "ARG BASE=alpine:3.19\nFROM ${BASE}\n",
+		},
+		{
+			name:       "every declaration",
+			dockerfile: "ARG VERSION\nFROM alpine\n  arg VERSION=1\r\nARG OTHER=x\n",
+			args:       map[string]string{"VERSION": "3"},
+			want:       "ARG VERSION=3\nFROM alpine\n  arg VERSION=3\nARG OTHER=x\n",
+		},
+		{
+			name:       "quoted values",
+			dockerfile: "FROM alpine\nARG GREETING\nARG EMPTY=x\n",
+			args:       map[string]string{"GREETING": `say "hi"`, "EMPTY": ""},
+			want:       "FROM alpine\nARG GREETING=\"say \\\"hi\\\"\"\nARG EMPTY=\"\"\n",
+		},
+		{
+			name:       "undeclared",
+			dockerfile: "FROM alpine\nARG VERSION\n",
+			args:       map[string]string{"VERSIONS": "1"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyBuildArgs([]byte(tt.dockerfile), tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("applyBuildArgs() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("applyBuildArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBuildArgs(t *testing.T) {
+	args, err := ParseBuildArgs([]string{"A=1", "B=x=y", "C="})
+	if err != nil || len(args) != 3 || args["A"] != "1" || args["B"] != "x=y" || args["C"] != "" {
+		t.Errorf("ParseBuildArgs() = %v, %v", args, err)
+	}
+
+	for _, pair := range []string{"A", "=1"} {
+		if _, err := ParseBuildArgs([]string{pair}); err == nil {
+			t.Errorf("ParseBuildArgs(%q) succeeded", pair)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package promote copies a lambda deployed on one server to another, such as
+// from staging to production, along with its runtime and endpoints.
+package promote
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/ops"
+)
+
+type Options struct {
+	// From and To are the URLs of the source and target servers, naming
+	// them in the history
+	From string
+	To   string
+	// Endpoints re-creates the endpoints routing to the lambda on the
+	// target
+	Endpoints bool
+	// Report is told about every step, if not nil
+	Report func(msg string)
+}
+
+type Result struct {
+	Source  *api.Lambda
+	Lambda  *api.Lambda
+	Runtime *api.Runtime
+	// RuntimeCreated tells whether the runtime had to be built on the
+	// target
+	RuntimeCreated bool
+	Endpoints      []api.Endpoint
+}
+
+// Promote deploys the lambda ref (an ID or name) of the from server to the
+// to server. The API hands out no archives, so the lambda is packaged again
+// from the sources recorded when it was deployed from this machine, which
+// must not have changed since. Its runtime is looked up by name on the
+// target, and built from the recorded sources of the source runtime when
+// missing. Deployments are recorded for the target in store.
+func Promote(ctx context.Context, from ops.API, to ops.API, store *history.Store, ref string, opts Options) (*Result, error) {
+	report := opts.Report
+	if report == nil {
+		report = func(string) {}
+	}
+
+	l, err := from.FindLambda(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := store.Latest(opts.From, l.Id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, fmt.Errorf("lambda %s (%s) was not deployed from this machine, its sources are unknown", l.Name, l.Id)
+	}
+	if err := d.CheckSources(); err != nil {
+		return nil, err
+	}
+
+	res := &Result{Source: l}
+
+	res.Runtime, res.RuntimeCreated, err = ensureRuntime(ctx, from, to, store, l.Runtime, opts)
+	if err != nil {
+		return res, err
+	}
+	if res.RuntimeCreated {
+		report(fmt.Sprintf("Created runtime %s: %s", res.Runtime.Name, res.Runtime.Id))
+	} else {
+		report(fmt.Sprintf("Using runtime %s: %s", res.Runtime.Name, res.Runtime.Id))
+	}
+
+	res.Lambda, err = to.DeployLambda(ctx, ops.CreateLambdaM{
+		Name:       l.Name,
+		Runtime:    res.Runtime.Id,
+		LambdaType: l.LambdaType,
+	}, d.Source)
+	if err != nil {
+		return res, err
+	}
+	report(fmt.Sprintf("Deployed lambda %s: %s", res.Lambda.Name, res.Lambda.Id))
+
+	promoted := history.New(opts.To, res.Lambda, d.Source, d.Digest)
+	promoted.Git = d.Git
+	if err := store.Add(promoted); err != nil {
+		return res, err
+	}
+
+	if !opts.Endpoints {
+		return res, nil
+	}
+
+	endpoints, err := from.ListEndpoints(ctx)
+	if err != nil {
+		return res, err
+	}
+	for _, e := range endpoints {
+		if e.Lambda != l.Id {
+			continue
+		}
+
+		routed, err := to.RouteEndpoint(ctx, e.Name, e.Path, res.Lambda.Id)
+		if err != nil {
+			return res, err
+		}
+		res.Endpoints = append(res.Endpoints, *routed)
+		report(fmt.Sprintf("Routed endpoint %s %s: %s", routed.Name, routed.Path, routed.Id))
+	}
+
+	return res, nil
+}
+
+// ensureRuntime finds the runtime named like the source one on the target,
+// the newest one among duplicates, or builds it.
+func ensureRuntime(ctx context.Context, from ops.API, to ops.API, store *history.Store, id string, opts Options) (*api.Runtime, bool, error) {
+	src, err := from.GetRuntime(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	runtimes, err := to.ListRuntimes(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	sort.SliceStable(runtimes, func(i, j int) bool { return runtimes[i].CreatedAt > runtimes[j].CreatedAt })
+	for i, rt := range runtimes {
+		if rt.Name == src.Name {
+			return &runtimes[i], false, nil
+		}
+	}
+
+	builds, err := store.Runtimes(opts.From)
+	if err != nil {
+		return nil, false, err
+	}
+	b, ok := builds[src.Id]
+	if !ok {
+		return nil, false, fmt.Errorf("runtime %s is missing on the target and was not built from this machine", src.Name)
+	}
+	if err := b.CheckSources(); err != nil {
+		return nil, false, err
+	}
+
+	rt, err := to.CreateRuntime(ctx, ops.CreateRuntimeM{
+		Name:       src.Name,
+		Dockerfile: b.Dockerfile,
+		BuildArgs:  b.BuildArgs,
+	}, b.Source)
+	if err != nil {
+		return nil, false, err
+	}
+
+	built := history.NewRuntimeBuild(opts.To, rt, b.Source, b.Dockerfile, b.BuildArgs, b.Digest)
+	built.Git = b.Git
+	if err := store.AddRuntime(built); err != nil {
+		return rt, true, err
+	}
+
+	return rt, true, nil
+}
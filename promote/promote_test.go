@@ -0,0 +1,77 @@
+package promote_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/opcsrv/fake"
+	"github.com/onpremless/opcli/ops"
+	"github.com/onpremless/opcli/promote"
+)
+
+func TestPromote(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("OPCLI_CONFIG", filepath.Join(dir, "config.yaml"))
+	store, err := history.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, stagingURL, staging := fake.NewTestClient(t, fake.Options{})
+	prodSrv, prodURL, prod := fake.NewTestClient(t, fake.Options{})
+	ctx := context.Background()
+
+	fake.WriteFile(t, filepath.Join(dir, "Dockerfile"), "FROM alpine\n")
+	rt, err := staging.CreateRuntime(ctx, ops.CreateRuntimeM{Name: "shell"}, filepath.Join(dir, "Dockerfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := ops.SourceDigest(filepath.Join(dir, "Dockerfile"))
+	store.AddRuntime(history.NewRuntimeBuild(stagingURL, rt, filepath.Join(dir, "Dockerfile"), "", nil, digest))
+
+	src := filepath.Join(dir, "hello")
+	fake.WriteFile(t, filepath.Join(src, "handler.sh"), "echo hello\n")
+	l, err := staging.DeployLambda(ctx, ops.CreateLambdaM{Name: "hello", Runtime: rt.Id, LambdaType: "ENDPOINT"}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, _ = ops.SourceDigest(src)
+	store.Add(history.New(stagingURL, l, src, digest))
+	if _, err := staging.CreateEndpoint(ctx, &api.CreateEndpoint{Name: "hello", Path: "/hello", Lambda: l.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := promote.Options{From: stagingURL, To: prodURL, Endpoints: true}
+	res, err := promote.Promote(ctx, staging, prod, store, "hello", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.RuntimeCreated || res.Lambda.Runtime != res.Runtime.Id || res.Lambda.Docker.Status != fake.StatusRunning {
+		t.Errorf("promoted %+v with runtime %+v", res.Lambda, res.Runtime)
+	}
+	if endpoints := prodSrv.Endpoints(); len(endpoints) != 1 || endpoints[0].Lambda != res.Lambda.Id {
+		t.Errorf("prod endpoints = %+v", endpoints)
+	}
+	if d, _ := store.Latest(prodURL, res.Lambda.Id); d == nil || d.Source != src {
+		t.Errorf("promotion recorded as %+v", d)
+	}
+
+	again, err := promote.Promote(ctx, staging, prod, store, l.Id, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.RuntimeCreated || again.Runtime.Id != res.Runtime.Id {
+		t.Errorf("promoting again used runtime %+v, want the existing %s", again.Runtime, res.Runtime.Id)
+	}
+	if endpoints := prodSrv.Endpoints(); len(endpoints) != 1 || endpoints[0].Lambda != again.Lambda.Id {
+		t.Errorf("prod endpoints = %+v, want one routed to %s", endpoints, again.Lambda.Id)
+	}
+
+	fake.WriteFile(t, filepath.Join(src, "handler.sh"), "echo changed\n")
+	if _, err := promote.Promote(ctx, staging, prod, store, "hello", opts); err == nil {
+		t.Error("promoted a lambda whose sources changed since its deployment")
+	}
+}
@@ -0,0 +1,156 @@
+// Package templates holds the built-in runtime and lambda scaffolds used by
+// the init commands.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	RuntimeKind = "runtime"
+	LambdaKind  = "lambda"
+)
+
+// Files ending with tmplSuffix are rendered with text/template and written
+// without the suffix, the rest are copied as is.
+const tmplSuffix = ".tmpl"
+
+//go:embed all:runtime all:lambda
+var files embed.FS
+
+type Template struct {
+	Name        string
+	Description string
+}
+
+var descriptions = map[string]string{
+	"go":     "Go HTTP handler built into a static binary",
+	"node":   "Node.js HTTP handler",
+	"python": "Python HTTP handler",
+	"shell":  "Shell script served over socat",
+}
+
+// Data is passed to the templates on render.
+type Data struct {
+	Name    string
+	Runtime string
+}
+
+// List returns the templates available for the kind, sorted by name.
+func List(kind string) ([]Template, error) {
+	entries, err := fs.ReadDir(files, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	target := []Template{}
+	for _, e := range entries {
+		if e.IsDir() {
+			target = append(target, Template{Name: e.Name(), Description: descriptions[e.Name()]})
+		}
+	}
+
+	return target, nil
+}
+
+type renderedFile struct {
+	name    string
+	content []byte
+}
+
+// Render writes the files of the kind/name template into dir. Nothing is
+// written if any of the files already exists. It returns the written paths.
+func Render(kind string, name string, dir string, data Data) ([]string, error) {
+	root := path.Join(kind, name)
+	if _, err := fs.Stat(files, root); err != nil {
+		return nil, fmt.Errorf("unknown %s template: %s", kind, name)
+	}
+
+	rendered := []renderedFile{}
+	err := fs.WalkDir(files, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := files.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(p, root+"/")
+		if strings.HasSuffix(rel, tmplSuffix) {
+			rel = strings.TrimSuffix(rel, tmplSuffix)
+
+			tmpl, err := template.New(rel).Parse(string(content))
+			if err != nil {
+				return err
+			}
+
+			var buffer bytes.Buffer
+			if err := tmpl.Execute(&buffer, data); err != nil {
+				return err
+			}
+			content = buffer.Bytes()
+		}
+
+		rendered = append(rendered, renderedFile{
+			name:    filepath.Join(dir, filepath.FromSlash(rel)),
+			content: content,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range rendered {
+		if _, err := os.Stat(f.name); err == nil {
+			return nil, fmt.Errorf("file already exists: %s", f.name)
+		}
+	}
+
+	written := []string{}
+	for _, f := range rendered {
+		if err := writeNew(f.name, f.content, fileMode(f.name)); err != nil {
+			return written, err
+		}
+
+		written = append(written, f.name)
+	}
+
+	return written, nil
+}
+
+func fileMode(name string) os.FileMode {
+	if strings.HasSuffix(name, ".sh") {
+		return 0755
+	}
+
+	return 0644
+}
+
+func writeNew(name string, content []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if os.IsExist(err) {
+		return fmt.Errorf("file already exists: %s", name)
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(content)
+	return err
+}
@@ -0,0 +1,533 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/tui/endpoint"
+	"github.com/onpremless/opcli/tui/lambda"
+	"github.com/onpremless/opcli/tui/runtime"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	DRuntimesTab  = 0
+	DLambdasTab   = iota
+	DEndpointsTab = iota
+	DTasksTab     = iota
+)
+
+var tabNames = []string{"Runtimes", "Lambdas", "Endpoints", "Tasks"}
+
+const (
+	DBrowseMode       = 0
+	DFilterMode       = iota
+	DConfirmMode      = iota
+	DEndpointNameMode = iota
+	DEndpointPathMode = iota
+)
+
+const DefaultRefreshInterval = 5 * time.Second
+
+var (
+	docStyle         = lipgloss.NewStyle().Margin(1, 2)
+	tabStyle         = lipgloss.NewStyle().Padding(0, 1)
+	activeTabStyle   = tabStyle.Copy().Bold(true).Reverse(true)
+	detailStyle      = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	statusStyle      = lipgloss.NewStyle().Faint(true)
+	errorStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	confirmStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+	detailPaneWidth  = 48
+	chromeHeight     = 8
+	minTableWidth    = 40
+	keyHelp          = "tab/1-4 switch • / filter • r refresh • s start • d destroy • e new endpoint • q quit"
+	filterHelp       = "enter apply • esc clear"
+	confirmHelp      = "y confirm • n cancel"
+	endpointFormHelp = "enter next • esc cancel"
+)
+
+type refreshTickMsg struct{}
+
+type taskDoneMsg struct {
+	ID  int
+	Msg tea.Msg
+}
+
+type DashboardModel struct {
+	RuntimeLister   runtime.RuntimeLister
+	LambdaLister    lambda.LambdaLister
+	EndpointLister  endpoint.EndpointLister
+	Starter         lambda.LambdaStarter
+	Destroyer       lambda.LambdaDestroyer
+	EndpointCreator endpoint.EndpointCreator
+	RefreshInterval time.Duration
+
+	runtimes  []api.Runtime
+	lambdas   []api.Lambda
+	endpoints []api.Endpoint
+	tasks     []task
+
+	// visible maps table rows of the active tab back to indexes of the
+	// underlying slice after filtering has been applied.
+	visible []int
+
+	// errs holds the last refresh error of each tab
+	errs        [4]error
+	refreshedAt time.Time
+
+	tab     int
+	mode    int
+	filters [4]string
+	pending *api.Lambda
+	newName string
+
+	width  int
+	height int
+
+	table       table.Model
+	filterInput textinput.Model
+	nameInput   textinput.Model
+	pathInput   textinput.Model
+}
+
+func InitDashboardModel(m *DashboardModel) *DashboardModel {
+	if m.RefreshInterval == 0 {
+		m.RefreshInterval = DefaultRefreshInterval
+	}
+
+	m.table = table.New(table.WithFocused(true))
+
+	m.filterInput = textinput.New()
+	m.filterInput.Prompt = "/"
+	m.filterInput.Placeholder = "filter"
+	m.filterInput.CharLimit = 156
+
+	m.nameInput = textinput.New()
+	m.nameInput.CharLimit = 156
+	m.nameInput.Placeholder = "Endpoint name"
+
+	m.pathInput = textinput.New()
+	m.pathInput.CharLimit = 156
+	m.pathInput.Placeholder = "Endpoint path"
+
+	m.setTab(DRuntimesTab)
+
+	return m
+}
+
+func (m DashboardModel) Init() tea.Cmd {
+	return tea.Batch(m.refresh(), m.scheduleRefresh())
+}
+
+func (m DashboardModel) refresh() tea.Cmd {
+	return tea.Batch(m.RuntimeLister.List(), m.LambdaLister.List(), m.EndpointLister.List())
+}
+
+func (m DashboardModel) scheduleRefresh() tea.Cmd {
+	return tea.Tick(m.RefreshInterval, func(time.Time) tea.Msg {
+		return refreshTickMsg{}
+	})
+}
+
+func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		return m, nil
+	case refreshTickMsg:
+		return m, tea.Batch(m.refresh(), m.scheduleRefresh())
+	case runtime.RuntimeListResponseMsg:
+		m.errs[DRuntimesTab] = msg.Resp.Err
+		if msg.Resp.Err == nil {
+			m.runtimes = msg.Resp.Runtimes
+			m.refreshedAt = time.Now()
+		}
+		m.rebuild()
+		return m, nil
+	case lambda.LambdaListResponseMsg:
+		m.errs[DLambdasTab] = msg.Resp.Err
+		if msg.Resp.Err == nil {
+			m.lambdas = msg.Resp.Lambdas
+		}
+		m.rebuild()
+		return m, nil
+	case endpoint.EndpointListResponseMsg:
+		m.errs[DEndpointsTab] = msg.Resp.Err
+		if msg.Resp.Err == nil {
+			m.endpoints = msg.Resp.Endpoints
+		}
+		m.rebuild()
+		return m, nil
+	case taskDoneMsg:
+		m.finishTask(msg)
+		m.rebuild()
+		return m, m.refresh()
+	}
+
+	switch m.mode {
+	case DFilterMode:
+		return m.handleFilterMode(msg)
+	case DConfirmMode:
+		return m.handleConfirmMode(msg)
+	case DEndpointNameMode:
+		return m.handleEndpointNameMode(msg)
+	case DEndpointPathMode:
+		return m.handleEndpointPathMode(msg)
+	}
+
+	return m.handleBrowseMode(msg)
+}
+
+func (m DashboardModel) handleBrowseMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "q", "esc":
+			return m, tea.Quit
+		case "tab", "right", "l":
+			m.setTab((m.tab + 1) % len(tabNames))
+			return m, nil
+		case "shift+tab", "left", "h":
+			m.setTab((m.tab + len(tabNames) - 1) % len(tabNames))
+			return m, nil
+		case "1", "2", "3", "4":
+			m.setTab(int(msg.Runes[0] - '1'))
+			return m, nil
+		case "/":
+			m.mode = DFilterMode
+			m.filterInput.SetValue(m.filters[m.tab])
+			return m, m.filterInput.Focus()
+		case "r":
+			return m, m.refresh()
+		case "s":
+			if l := m.selectedLambda(); l != nil {
+				cmd := m.startTask("start", l, m.Starter.Start(l.Id))
+				return m, cmd
+			}
+			return m, nil
+		case "d":
+			if l := m.selectedLambda(); l != nil {
+				m.pending = l
+				m.mode = DConfirmMode
+			}
+			return m, nil
+		case "e":
+			if l := m.selectedLambda(); l != nil {
+				m.pending = l
+				m.mode = DEndpointNameMode
+				m.nameInput.SetValue("")
+				return m, m.nameInput.Focus()
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m DashboardModel) handleFilterMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.mode = DBrowseMode
+			m.filterInput.Blur()
+			return m, nil
+		case tea.KeyEsc:
+			m.mode = DBrowseMode
+			m.filterInput.Blur()
+			m.filters[m.tab] = ""
+			m.rebuild()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	m.filters[m.tab] = m.filterInput.Value()
+	m.rebuild()
+	return m, cmd
+}
+
+func (m DashboardModel) handleConfirmMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "y", "Y":
+			l := m.pending
+			m.pending = nil
+			m.mode = DBrowseMode
+			cmd := m.startTask("destroy", l, m.Destroyer.Destroy(l.Id))
+			return m, cmd
+		case "n", "N", "esc", "q":
+			m.pending = nil
+			m.mode = DBrowseMode
+		}
+	}
+
+	return m, nil
+}
+
+func (m DashboardModel) handleEndpointNameMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyEnter:
+			if m.nameInput.Value() == "" {
+				return m, nil
+			}
+			m.newName = m.nameInput.Value()
+			m.nameInput.Blur()
+			m.mode = DEndpointPathMode
+			m.pathInput.SetValue("")
+			return m, m.pathInput.Focus()
+		case tea.KeyEsc:
+			m.nameInput.Blur()
+			m.pending = nil
+			m.mode = DBrowseMode
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.nameInput, cmd = m.nameInput.Update(msg)
+	return m, cmd
+}
+
+func (m DashboardModel) handleEndpointPathMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyEnter:
+			if m.pathInput.Value() == "" {
+				return m, nil
+			}
+			l := m.pending
+			m.pending = nil
+			m.pathInput.Blur()
+			m.mode = DBrowseMode
+			cmd := m.startTask("create endpoint", l, m.EndpointCreator.Create(m.newName, m.pathInput.Value(), l.Id))
+			return m, cmd
+		case tea.KeyEsc:
+			m.pathInput.Blur()
+			m.pending = nil
+			m.mode = DBrowseMode
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	return m, cmd
+}
+
+func (m DashboardModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+
+	tabs := make([]string, len(tabNames))
+	for i, name := range tabNames {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if i == m.tab {
+			tabs[i] = activeTabStyle.Render(label)
+		} else {
+			tabs[i] = tabStyle.Render(label)
+		}
+	}
+	header := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+
+	body := m.table.View()
+	if m.width-detailPaneWidth >= minTableWidth {
+		detail := detailStyle.
+			Width(detailPaneWidth - detailStyle.GetHorizontalFrameSize()).
+			Height(m.table.Height() + 1).
+			Render(m.detailView())
+		body = lipgloss.JoinHorizontal(lipgloss.Top, body, " ", detail)
+	}
+
+	var footer string
+	switch m.mode {
+	case DFilterMode:
+		footer = fmt.Sprintf("%s\n%s", m.filterInput.View(), statusStyle.Render(filterHelp))
+	case DConfirmMode:
+		footer = fmt.Sprintf("%s\n%s",
+			confirmStyle.Render(fmt.Sprintf("Destroy lambda %s (%s)?", m.pending.Name, m.pending.Id)),
+			statusStyle.Render(confirmHelp))
+	case DEndpointNameMode:
+		footer = fmt.Sprintf("New endpoint for %s\n%s\n%s", m.pending.Name, m.nameInput.View(), statusStyle.Render(endpointFormHelp))
+	case DEndpointPathMode:
+		footer = fmt.Sprintf("New endpoint %s for %s\n%s\n%s", m.newName, m.pending.Name, m.pathInput.View(), statusStyle.Render(endpointFormHelp))
+	default:
+		footer = fmt.Sprintf("%s\n%s", m.statusLine(), statusStyle.Render(keyHelp))
+	}
+
+	return docStyle.Render(lipgloss.JoinVertical(lipgloss.Left, header, "", body, "", footer))
+}
+
+func (m DashboardModel) statusLine() string {
+	if err := m.errs[m.tab]; err != nil {
+		return errorStyle.Render(fmt.Sprintf("Refresh failed: %s", err))
+	}
+
+	var parts []string
+	if f := m.filters[m.tab]; f != "" {
+		parts = append(parts, fmt.Sprintf("filter: %q", f))
+	}
+	if m.refreshedAt.IsZero() {
+		parts = append(parts, "loading...")
+	} else {
+		parts = append(parts, fmt.Sprintf("refreshed %s", m.refreshedAt.Format(time.TimeOnly)))
+	}
+	if running := m.runningTasks(); running > 0 {
+		parts = append(parts, fmt.Sprintf("%d task(s) running", running))
+	}
+
+	return statusStyle.Render(strings.Join(parts, " • "))
+}
+
+func (m DashboardModel) detailView() string {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.visible) {
+		return "Nothing selected"
+	}
+
+	var v interface{}
+	switch m.tab {
+	case DRuntimesTab:
+		v = m.runtimes[m.visible[i]]
+	case DLambdasTab:
+		l := m.lambdas[m.visible[i]]
+		var routes []string
+		for _, e := range m.endpoints {
+			if e.Lambda == l.Id {
+				routes = append(routes, fmt.Sprintf("%s -> %s", e.Name, e.Path))
+			}
+		}
+		j, _ := json.MarshalIndent(l, "", "  ")
+		if len(routes) == 0 {
+			return string(j)
+		}
+		return fmt.Sprintf("%s\n\nEndpoints:\n%s", j, strings.Join(routes, "\n"))
+	case DEndpointsTab:
+		v = m.endpoints[m.visible[i]]
+	case DTasksTab:
+		return m.tasks[m.visible[i]].detail()
+	}
+
+	j, _ := json.MarshalIndent(v, "", "  ")
+	return string(j)
+}
+
+func (m *DashboardModel) setTab(tab int) {
+	m.tab = tab
+	m.table.SetRows(nil)
+	m.table.SetColumns(m.columns())
+	m.rebuild()
+	m.table.SetCursor(0)
+}
+
+func (m *DashboardModel) resize() {
+	x, y := docStyle.GetFrameSize()
+	width := m.width - x
+	if width-detailPaneWidth >= minTableWidth {
+		width -= detailPaneWidth + 1
+	}
+
+	m.table.SetWidth(width)
+	m.table.SetHeight(max(m.height-y-chromeHeight, 3))
+	m.table.SetColumns(m.columns())
+}
+
+// rebuild recomputes table rows of the active tab from the latest listings
+// and the tab's filter, keeping the cursor within bounds.
+func (m *DashboardModel) rebuild() {
+	filter := strings.ToLower(m.filters[m.tab])
+	m.visible = nil
+
+	var rows []table.Row
+	add := func(i int, row table.Row) {
+		if filter != "" && !strings.Contains(strings.ToLower(strings.Join(row, " ")), filter) {
+			return
+		}
+		m.visible = append(m.visible, i)
+		rows = append(rows, row)
+	}
+
+	switch m.tab {
+	case DRuntimesTab:
+		for i, r := range m.runtimes {
+			add(i, runtimeRow(r))
+		}
+	case DLambdasTab:
+		for i, l := range m.lambdas {
+			add(i, lambdaRow(l, m.runtimeName(l.Runtime), m.endpointCount(l.Id)))
+		}
+	case DEndpointsTab:
+		for i, e := range m.endpoints {
+			add(i, endpointRow(e, m.lambdaName(e.Lambda)))
+		}
+	case DTasksTab:
+		// Most recent tasks first
+		for i := len(m.tasks) - 1; i >= 0; i-- {
+			add(i, m.tasks[i].row())
+		}
+	}
+
+	m.table.SetRows(rows)
+	m.table.SetCursor(max(m.table.Cursor(), 0))
+}
+
+func (m DashboardModel) selectedLambda() *api.Lambda {
+	if m.tab != DLambdasTab {
+		return nil
+	}
+
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.visible) {
+		return nil
+	}
+
+	l := m.lambdas[m.visible[i]]
+	return &l
+}
+
+func (m DashboardModel) runtimeName(id string) string {
+	for _, r := range m.runtimes {
+		if r.Id == id {
+			return r.Name
+		}
+	}
+
+	return id
+}
+
+func (m DashboardModel) lambdaName(id string) string {
+	for _, l := range m.lambdas {
+		if l.Id == id {
+			return l.Name
+		}
+	}
+
+	return id
+}
+
+func (m DashboardModel) endpointCount(lambdaID string) int {
+	n := 0
+	for _, e := range m.endpoints {
+		if e.Lambda == lambdaID {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,43 @@
+package dashboard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/tui/endpoint"
+	"github.com/onpremless/opcli/tui/lambda"
+	"github.com/onpremless/opcli/tui/runtime"
+)
+
+func TestRefreshErrorsStayOnTheirTab(t *testing.T) {
+	var m tea.Model = *InitDashboardModel(&DashboardModel{})
+	for _, msg := range []tea.Msg{
+		tea.WindowSizeMsg{Width: 120, Height: 30},
+		runtime.RuntimeListResponseMsg{Resp: &runtime.RuntimeListResponse{Err: errors.New("runtimes unavailable")}},
+		lambda.LambdaListResponseMsg{Resp: &lambda.LambdaListResponse{Lambdas: []api.Lambda{{Id: "lambda-1", Name: "hello"}}}},
+		endpoint.EndpointListResponseMsg{Resp: &endpoint.EndpointListResponse{Err: errors.New("endpoints unavailable")}},
+	} {
+		m, _ = m.Update(msg)
+	}
+
+	tabs := []struct {
+		key  string
+		want string
+	}{
+		{"1", "Refresh failed: runtimes unavailable"},
+		{"2", ""},
+		{"3", "Refresh failed: endpoints unavailable"},
+		{"4", ""},
+	}
+	for _, tab := range tabs {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(tab.key)})
+
+		status := m.(DashboardModel).statusLine()
+		if tab.want == "" && strings.Contains(status, "Refresh failed") || !strings.Contains(status, tab.want) {
+			t.Errorf("tab %s status = %q, want %q", tab.key, status, tab.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package dashboard
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/table"
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/tui/runtime"
+)
+
+func (m DashboardModel) columns() []table.Column {
+	// The ID column takes whatever is left after the fixed-width columns
+	width := m.table.Width()
+
+	switch m.tab {
+	case DRuntimesTab:
+		return []table.Column{
+			{Title: "ID", Width: max(width-20-20-4, 12)},
+			{Title: "Name", Width: 20},
+			{Title: "Created", Width: 20},
+		}
+	case DLambdasTab:
+		return []table.Column{
+			{Title: "ID", Width: max(width-20-9-16-10-6-7, 12)},
+			{Title: "Name", Width: 20},
+			{Title: "Type", Width: 9},
+			{Title: "Runtime", Width: 16},
+			{Title: "State", Width: 10},
+			{Title: "Routes", Width: 6},
+		}
+	case DEndpointsTab:
+		return []table.Column{
+			{Title: "ID", Width: max(width-16-20-20-4, 12)},
+			{Title: "Name", Width: 16},
+			{Title: "Path", Width: 20},
+			{Title: "Lambda", Width: 20},
+		}
+	case DTasksTab:
+		return []table.Column{
+			{Title: "Action", Width: 16},
+			{Title: "Lambda", Width: 20},
+			{Title: "Status", Width: 8},
+			{Title: "Started", Width: 10},
+			{Title: "Took", Width: 8},
+		}
+	}
+
+	return nil
+}
+
+func runtimeRow(r api.Runtime) table.Row {
+	return table.Row{r.Id, r.Name, runtime.FormatTimestamp(r.CreatedAt)}
+}
+
+func lambdaRow(l api.Lambda, runtimeName string, routes int) table.Row {
+	return table.Row{l.Id, l.Name, l.LambdaType, runtimeName, l.Docker.Status, fmt.Sprint(routes)}
+}
+
+func endpointRow(e api.Endpoint, lambdaName string) table.Row {
+	return table.Row{e.Id, e.Name, e.Path, lambdaName}
+}
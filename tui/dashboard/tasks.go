@@ -0,0 +1,109 @@
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/tui/endpoint"
+	"github.com/onpremless/opcli/tui/lambda"
+)
+
+const (
+	taskPending = "PENDING"
+	taskDone    = "DONE"
+	taskFailed  = "FAILED"
+)
+
+// task is an action dispatched from the dashboard. The server does not
+// expose a task listing, so the tab only shows what was started here.
+type task struct {
+	ID         int
+	Action     string
+	LambdaID   string
+	LambdaName string
+	Status     string
+	Err        error
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+func (t task) row() table.Row {
+	took := ""
+	if !t.FinishedAt.IsZero() {
+		took = t.FinishedAt.Sub(t.StartedAt).Round(100 * time.Millisecond).String()
+	}
+
+	return table.Row{t.Action, t.LambdaName, t.Status, t.StartedAt.Format(time.TimeOnly), took}
+}
+
+func (t task) detail() string {
+	lines := []string{
+		fmt.Sprintf("Action: %s", t.Action),
+		fmt.Sprintf("Lambda: %s (%s)", t.LambdaName, t.LambdaID),
+		fmt.Sprintf("Status: %s", t.Status),
+		fmt.Sprintf("Started: %s", t.StartedAt.Format(time.DateTime)),
+	}
+	if !t.FinishedAt.IsZero() {
+		lines = append(lines, fmt.Sprintf("Finished: %s", t.FinishedAt.Format(time.DateTime)))
+	}
+	if t.Err != nil {
+		lines = append(lines, "", fmt.Sprintf("Error: %s", t.Err))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// startTask records a pending task and wraps cmd so that its response can be
+// matched back to the task once it arrives.
+func (m *DashboardModel) startTask(action string, l *api.Lambda, cmd tea.Cmd) tea.Cmd {
+	id := len(m.tasks)
+	m.tasks = append(m.tasks, task{
+		ID:         id,
+		Action:     action,
+		LambdaID:   l.Id,
+		LambdaName: l.Name,
+		Status:     taskPending,
+		StartedAt:  time.Now(),
+	})
+	m.rebuild()
+
+	return func() tea.Msg {
+		return taskDoneMsg{ID: id, Msg: cmd()}
+	}
+}
+
+func (m *DashboardModel) finishTask(msg taskDoneMsg) {
+	var err error
+	switch resp := msg.Msg.(type) {
+	case lambda.LambdaStartResponseMsg:
+		err = resp.Resp.Err
+	case lambda.LambdaDestroyResponseMsg:
+		err = resp.Resp.Err
+	case endpoint.EndpointCreateResponseMsg:
+		err = resp.Resp.Err
+	}
+
+	t := &m.tasks[msg.ID]
+	t.FinishedAt = time.Now()
+	t.Err = err
+	if err != nil {
+		t.Status = taskFailed
+	} else {
+		t.Status = taskDone
+	}
+}
+
+func (m DashboardModel) runningTasks() int {
+	n := 0
+	for _, t := range m.tasks {
+		if t.Status == taskPending {
+			n++
+		}
+	}
+
+	return n
+}
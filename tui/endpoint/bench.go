@@ -0,0 +1,174 @@
+package endpoint
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/bench"
+)
+
+const benchRefreshInterval = 200 * time.Millisecond
+
+const histogramWidth = 40
+
+type benchTickMsg struct{}
+
+func benchTick() tea.Cmd {
+	return tea.Tick(benchRefreshInterval, func(time.Time) tea.Msg {
+		return benchTickMsg{}
+	})
+}
+
+// BenchRunner is a started benchmark run, implemented by bench.Runner.
+type BenchRunner interface {
+	Options() bench.Options
+	Snapshot() bench.Snapshot
+	Done() <-chan struct{}
+}
+
+// EndpointBenchModel shows the live statistics of an already started
+// benchmark run and quits once it finishes.
+type EndpointBenchModel struct {
+	Name   string
+	Runner BenchRunner
+	// Stop cancels the run, it is called on ctrl+c
+	Stop func()
+
+	snapshot bench.Snapshot
+	finished bool
+	stopped  bool
+
+	loadingSpinner spinner.Model
+}
+
+func InitEndpointBenchModel(m *EndpointBenchModel) *EndpointBenchModel {
+	m.loadingSpinner = spinner.New()
+
+	m.loadingSpinner.Spinner = spinner.Dot
+
+	return m
+}
+
+func (m EndpointBenchModel) Init() tea.Cmd {
+	return tea.Batch(benchTick(), m.loadingSpinner.Tick)
+}
+
+func (m EndpointBenchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			if m.stopped {
+				return m, tea.Quit
+			}
+
+			m.stopped = true
+			if m.Stop != nil {
+				m.Stop()
+			}
+			return m, nil
+		}
+	case benchTickMsg:
+		m.snapshot = m.Runner.Snapshot()
+		select {
+		case <-m.Runner.Done():
+			m.snapshot = m.Runner.Snapshot()
+			m.finished = true
+			return m, tea.Quit
+		default:
+		}
+
+		return m, benchTick()
+	}
+
+	var cmd tea.Cmd
+	m.loadingSpinner, cmd = m.loadingSpinner.Update(msg)
+	return m, cmd
+}
+
+func (m EndpointBenchModel) View() string {
+	opts := m.Runner.Options()
+	s := m.snapshot
+
+	rate := "unlimited rate"
+	if opts.RPS > 0 {
+		rate = fmt.Sprintf("%g req/s", opts.RPS)
+	}
+
+	lines := []string{
+		fmt.Sprintf("Benchmarking %s (%s %s), %d worker(s), %s", m.Name, opts.Method, opts.URL, opts.Concurrency, rate),
+		"",
+	}
+
+	elapsed := s.Elapsed.Round(100 * time.Millisecond)
+	if m.finished {
+		lines = append(lines, fmt.Sprintf("Finished in %s", elapsed))
+	} else if m.stopped {
+		lines = append(lines, fmt.Sprintf("%s Stopping after %s...", m.loadingSpinner.View(), elapsed))
+	} else {
+		lines = append(lines, fmt.Sprintf("%s %s / %s", m.loadingSpinner.View(), elapsed, opts.Duration))
+	}
+
+	lines = append(lines,
+		"",
+		fmt.Sprintf("Requests    %d", s.Requests),
+		fmt.Sprintf("Throughput  %.1f req/s", s.Throughput),
+		fmt.Sprintf("Errors      %d (%.2f%%)", s.Errors, s.ErrorRate*100),
+		fmt.Sprintf("Latency     p50 %s  p90 %s  p99 %s  max %s", round(s.P50), round(s.P90), round(s.P99), round(s.Max)),
+	)
+	if s.LastError != "" {
+		lines = append(lines, errorStyle.Render(fmt.Sprintf("Last error  %s", s.LastError)))
+	}
+
+	lines = append(lines, "", "Latency histogram")
+	lines = append(lines, histogramLines(s.Histogram)...)
+
+	if !m.finished {
+		lines = append(lines, "", helpStyle.Render("ctrl+c stop"))
+	}
+
+	return docStyle.Render(strings.Join(lines, "\n"))
+}
+
+func histogramLines(buckets []bench.Bucket) []string {
+	peak := 0
+	for _, b := range buckets {
+		peak = max(peak, b.Count)
+	}
+
+	lines := []string{}
+	for _, b := range buckets {
+		label := "> " + bench.Bounds[len(bench.Bounds)-1].String()
+		if b.UpperBound > 0 {
+			label = "≤ " + b.UpperBound.String()
+		}
+
+		bar := 0
+		if peak > 0 {
+			bar = b.Count * histogramWidth / peak
+		}
+
+		lines = append(lines, fmt.Sprintf("  %-7s %s %d", label, strings.Repeat("█", bar), b.Count))
+	}
+
+	return lines
+}
+
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+// GetSnapshot returns the statistics of the run at the time the model quit.
+func (m EndpointBenchModel) GetSnapshot() bench.Snapshot {
+	return m.snapshot
+}
@@ -0,0 +1,87 @@
+package endpoint
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/bench"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+// histogram fills the buckets of bench.Bounds, plus the overflow one, with
+// counts.
+func histogram(counts map[time.Duration]int) []bench.Bucket {
+	buckets := []bench.Bucket{}
+	for _, b := range append(append([]time.Duration{}, bench.Bounds...), 0) {
+		buckets = append(buckets, bench.Bucket{UpperBound: b, Count: counts[b]})
+	}
+
+	return buckets
+}
+
+var benchSnapshots = []bench.Snapshot{
+	{
+		Elapsed:    2 * time.Second,
+		Requests:   100,
+		Throughput: 50,
+		P50:        3 * time.Millisecond,
+		P90:        8 * time.Millisecond,
+		P99:        12 * time.Millisecond,
+		Max:        12 * time.Millisecond,
+		Histogram:  histogram(map[time.Duration]int{5 * time.Millisecond: 60, 10 * time.Millisecond: 38, 20 * time.Millisecond: 2}),
+	},
+	{
+		Elapsed:    10 * time.Second,
+		Requests:   500,
+		Errors:     5,
+		Throughput: 50,
+		ErrorRate:  0.01,
+		P50:        3 * time.Millisecond,
+		P90:        9 * time.Millisecond,
+		P99:        40 * time.Millisecond,
+		Max:        1500 * time.Millisecond,
+		Histogram:  histogram(map[time.Duration]int{5 * time.Millisecond: 300, 10 * time.Millisecond: 180, 50 * time.Millisecond: 15, 2 * time.Second: 5}),
+		LastError:  "context deadline exceeded",
+	},
+}
+
+func TestEndpointBench(t *testing.T) {
+	d := tuitest.New(t, InitEndpointBenchModel(&EndpointBenchModel{
+		Name:   "hello",
+		Runner: newFakeBenchRunner(benchSnapshots...),
+	}))
+	d.Send(benchTickMsg{})
+	d.Send(benchTickMsg{})
+
+	if !d.Quit() || d.Model().(EndpointBenchModel).GetSnapshot().Requests != 500 {
+		t.Errorf("quit = %v, want the final snapshot once the run is done", d.Quit())
+	}
+	d.Golden()
+}
+
+func TestEndpointBenchStop(t *testing.T) {
+	stopped := benchSnapshots[0]
+	stopped.Elapsed, stopped.Requests = 2500*time.Millisecond, 125
+
+	stops := 0
+	d := tuitest.New(t, InitEndpointBenchModel(&EndpointBenchModel{
+		Name:   "hello",
+		Runner: newFakeBenchRunner(benchSnapshots[0], stopped),
+		Stop:   func() { stops++ },
+	}))
+	d.Send(benchTickMsg{})
+	d.Key(tea.KeyCtrlC)
+
+	if stops != 1 || d.Quit() {
+		t.Errorf("stops = %d, quit = %v after ctrl+c, want the run stopped", stops, d.Quit())
+	}
+
+	// The run ends early once stopped
+	d.Send(benchTickMsg{})
+
+	if !d.Quit() {
+		t.Error("model did not quit once the stopped run finished")
+	}
+	d.Golden()
+}
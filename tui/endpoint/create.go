@@ -3,9 +3,12 @@ package endpoint
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	api "github.com/onpremless/go-client"
 	"github.com/onpremless/opcli/tui/lambda"
+	"github.com/onpremless/opcli/tui/review"
+	"github.com/onpremless/opcli/tui/runtime"
 
 	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/list"
@@ -17,11 +20,13 @@ import (
 
 const (
 	ECInitStep            = 0
-	ECNameStep            = iota
 	ECLambdasLoadingStep  = iota
+	ECNameStep            = iota
 	ECLambdaSelectionStep = iota
 	ECEndpointStep        = iota
+	ECReviewStep          = iota
 	ECLoadingStep         = iota
+	ECDoneStep            = iota
 )
 
 type EndpointCreateResponse struct {
@@ -41,6 +46,12 @@ func (i lambdaItem) Title() string       { return i.Lambda.Name }
 func (i lambdaItem) Description() string { return i.Lambda.LambdaType }
 func (i lambdaItem) FilterValue() string { return i.Lambda.Name }
 
+type createLambdaItem struct{}
+
+func (i createLambdaItem) Title() string       { return "+ create new" }
+func (i createLambdaItem) Description() string { return "Create a new endpoint lambda from sources" }
+func (i createLambdaItem) FilterValue() string { return "" }
+
 type endpointCreateStartMsg struct{}
 
 func endpointCreateStart() tea.Msg {
@@ -51,7 +62,19 @@ type EndpointCreator interface {
 	Create(name string, path string, lambda string) tea.Cmd
 }
 
-var docStyle = lipgloss.NewStyle().Margin(1, 2)
+var (
+	docStyle   = lipgloss.NewStyle().Margin(1, 2)
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	helpStyle  = lipgloss.NewStyle().Faint(true)
+)
+
+// endpointFields is a snapshot of the values the wizard asks for, used to
+// restore them when editing from the review step is abandoned.
+type endpointFields struct {
+	Name     string
+	Lambda   *api.Lambda
+	Endpoint string
+}
 
 type EndpointCreateModel struct {
 	Name            string
@@ -59,17 +82,42 @@ type EndpointCreateModel struct {
 	Lambda          *api.Lambda
 	EndpointCreator EndpointCreator
 	LambdaLister    lambda.LambdaLister
-
-	static string
-
-	lambdas       []api.Lambda
-	lambdasLoaded bool
-	resp          *EndpointCreateResponse
+	// EndpointLister is optional, when set names and paths of existing
+	// endpoints are rejected
+	EndpointLister EndpointLister
+	// LambdaCreator and RuntimeLister are optional, when set the lambda
+	// picker offers to create a new lambda. RuntimeCreator additionally
+	// allows creating a runtime for it.
+	LambdaCreator  lambda.LambdaCreator
+	RuntimeLister  runtime.RuntimeLister
+	RuntimeCreator runtime.RuntimeCreator
+
+	output string
+	err    string
+
+	lambdas         []api.Lambda
+	lambdasLoaded   bool
+	endpoints       []api.Endpoint
+	endpointsLoaded bool
+	resp            *EndpointCreateResponse
+
+	// interactive is set once any of the values was asked for, a wizard
+	// fully driven by flags doesn't stop on the review step
+	interactive bool
+	confirmed   bool
+	// editing holds the values before a field was picked for editing on the
+	// review step
+	editing *endpointFields
+
+	// subflow is the nested lambda wizard started from the lambda picker
+	subflow    tea.Model
+	windowSize *tea.WindowSizeMsg
 
 	step           int
 	nameInput      textinput.Model
 	pathInput      textinput.Model
 	endpointList   list.Model
+	review         review.Model
 	loadingSpinner spinner.Model
 }
 
@@ -86,6 +134,8 @@ func InitEndpointCreateModel(m *EndpointCreateModel) *EndpointCreateModel {
 	m.endpointList.Title = "Lambda endpoints"
 	m.endpointList.SetFilteringEnabled(false)
 
+	m.review = review.New("Create endpoint", "Create")
+
 	m.loadingSpinner = spinner.New()
 
 	m.loadingSpinner.Spinner = spinner.Dot
@@ -98,6 +148,10 @@ func (m EndpointCreateModel) Init() tea.Cmd {
 }
 
 func (m EndpointCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.subflow != nil {
+		return m.handleSubflow(msg)
+	}
+
 	switch msg := msg.(type) {
 	case endpointCreateStartMsg:
 		return m.incStep()
@@ -105,8 +159,16 @@ func (m EndpointCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			return m, tea.Quit
+		case tea.KeyShiftTab:
+			return m.decStep()
+		case tea.KeyEsc:
+			if m.step == ECNameStep && m.editing == nil {
+				return m, tea.Quit
+			}
+			return m.decStep()
 		}
 	case tea.WindowSizeMsg:
+		m.windowSize = &msg
 		h, v := docStyle.GetFrameSize()
 		m.endpointList.SetSize(msg.Width-h, msg.Height-v)
 	}
@@ -120,6 +182,8 @@ func (m EndpointCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleECLambdaSelectionStep(msg)
 	case ECEndpointStep:
 		return m.handleECEndpointStep(msg)
+	case ECReviewStep:
+		return m.handleECReviewStep(msg)
 	case ECLoadingStep:
 		return m.handleECLoadingStep(msg)
 	}
@@ -127,32 +191,72 @@ func (m EndpointCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m EndpointCreateModel) View() string {
-	static := m.static
+	if m.subflow != nil {
+		return m.subflow.View()
+	}
+
+	static := m.transcript()
 	if static != "" {
 		static += "\n"
 	}
 
 	active := ""
 	if m.step == ECNameStep {
-		active = docStyle.Render(m.nameInput.View())
+		active = docStyle.Render(m.withError(m.nameInput.View()))
 	} else if m.step == ECLambdasLoadingStep {
 		active = docStyle.Render(fmt.Sprintf("%s Loading lambdas...", m.loadingSpinner.View()))
 	} else if m.step == ECLambdaSelectionStep {
+		if m.err != "" {
+			return fmt.Sprintf("%s\n%s", m.endpointList.View(), errorStyle.Render(m.err))
+		}
 		return m.endpointList.View()
 	} else if m.step == ECEndpointStep {
-		active = docStyle.Render(m.pathInput.View())
+		active = docStyle.Render(m.withError(m.pathInput.View()))
+	} else if m.step == ECReviewStep {
+		return docStyle.Render(m.review.View())
 	} else if m.step == ECLoadingStep {
 		active = docStyle.Render(fmt.Sprintf("%s Creating endpoint...", m.loadingSpinner.View()))
+	} else if m.step == ECDoneStep {
+		active = m.output
 	}
 
 	return fmt.Sprintf("%s%s", static, active)
 }
 
+// transcript lists the values chosen in the steps behind the current one.
+func (m EndpointCreateModel) transcript() string {
+	lines := []string{}
+	if m.step > ECNameStep && m.Name != "" {
+		lines = append(lines, fmt.Sprintf("Name: %s", m.Name))
+	}
+	if m.step > ECLambdaSelectionStep && m.Lambda != nil {
+		lines = append(lines, fmt.Sprintf("Lambda endpoint: %s", m.Lambda.Name))
+	}
+	if m.step > ECEndpointStep && m.Endpoint != "" {
+		lines = append(lines, fmt.Sprintf("Path: %s", m.Endpoint))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "\n" + strings.Join(lines, "\n")
+}
+
+func (m EndpointCreateModel) withError(view string) string {
+	help := helpStyle.Render("enter confirm • shift+tab back • esc quit")
+	if m.err == "" {
+		return fmt.Sprintf("%s\n\n%s", view, help)
+	}
+
+	return fmt.Sprintf("%s\n%s\n\n%s", view, errorStyle.Render(m.err), help)
+}
+
 func (m EndpointCreateModel) handleECLambdasLoadingStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case lambda.LambdaListResponseMsg:
 		if msg.Resp.Err != nil {
-			m.static = fmt.Sprintf("%s\n\nFailed to load lambdas: %s", m.static, msg.Resp.Err)
+			m.output = fmt.Sprintf("\nFailed to load lambdas: %s\n", msg.Resp.Err)
+			m.step = ECDoneStep
 			return m, tea.Quit
 		}
 
@@ -162,13 +266,24 @@ func (m EndpointCreateModel) handleECLambdasLoadingStep(msg tea.Msg) (tea.Model,
 			}
 		}
 
-		if len(m.lambdas) == 0 {
-			m.static = fmt.Sprintf("%s\n\nNo suitable lambdas was found", m.static)
+		if len(m.lambdas) == 0 && m.Lambda == nil && !m.canCreateLambda() {
+			m.output = "\nNo suitable lambdas was found\n"
+			m.step = ECDoneStep
 			return m, tea.Quit
 		}
 
 		m.lambdasLoaded = true
 		return m.incStep()
+	case EndpointListResponseMsg:
+		if msg.Resp.Err != nil {
+			m.output = fmt.Sprintf("\nFailed to load endpoints: %s\n", msg.Resp.Err)
+			m.step = ECDoneStep
+			return m, tea.Quit
+		}
+
+		m.endpoints = msg.Resp.Endpoints
+		m.endpointsLoaded = true
+		return m.incStep()
 	}
 
 	var cmd tea.Cmd
@@ -181,10 +296,16 @@ func (m EndpointCreateModel) handleECLambdaSelectionStep(msg tea.Msg) (tea.Model
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEnter:
-			m.Lambda = &m.lambdas[m.endpointList.Cursor()]
+			if m.endpointList.Index() == len(m.lambdas) {
+				if m.canCreateLambda() {
+					return m.startLambdaSubflow()
+				}
+				return m, nil
+			}
+
+			m.err = ""
+			m.Lambda = &m.lambdas[m.endpointList.Index()]
 			return m.incStep()
-		case tea.KeyEsc:
-			return m, tea.Quit
 		}
 	}
 
@@ -193,15 +314,83 @@ func (m EndpointCreateModel) handleECLambdaSelectionStep(msg tea.Msg) (tea.Model
 	return m, cmd
 }
 
+func (m EndpointCreateModel) canCreateLambda() bool {
+	return m.LambdaCreator != nil && m.RuntimeLister != nil
+}
+
+// startLambdaSubflow runs the lambda wizard in place of the lambda picker,
+// the created lambda gets selected once it's done.
+func (m EndpointCreateModel) startLambdaSubflow() (tea.Model, tea.Cmd) {
+	m.err = ""
+	sub := lambda.InitLambdaCreateModel(&lambda.LambdaCreateModel{
+		LambdaType:     "ENDPOINT",
+		LambdaCreator:  m.LambdaCreator,
+		RuntimeLister:  m.RuntimeLister,
+		LambdaLister:   m.LambdaLister,
+		RuntimeCreator: m.RuntimeCreator,
+		Embedded:       true,
+	})
+	m.subflow = sub
+
+	cmds := []tea.Cmd{sub.Init()}
+	if m.windowSize != nil {
+		size := *m.windowSize
+		cmds = append(cmds, func() tea.Msg { return size })
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m EndpointCreateModel) handleSubflow(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case lambda.LambdaCreateDoneMsg:
+		m.subflow = nil
+		if msg.Resp == nil {
+			return m, nil
+		}
+		if msg.Resp.Err != nil {
+			m.err = fmt.Sprintf("Failed to create lambda: %s", msg.Resp.Err)
+			return m, nil
+		}
+
+		m.lambdas = append(m.lambdas, *msg.Resp.Lambda)
+		m.Lambda = &m.lambdas[len(m.lambdas)-1]
+		return m.incStep(m.endpointList.SetItems(m.lambdaItems()))
+	case tea.WindowSizeMsg:
+		m.windowSize = &msg
+	}
+
+	var cmd tea.Cmd
+	m.subflow, cmd = m.subflow.Update(msg)
+	return m, cmd
+}
+
+func (m EndpointCreateModel) lambdaItems() []list.Item {
+	target := []list.Item{}
+	for _, lambda := range m.lambdas {
+		target = append(target, lambdaItem{lambda})
+	}
+
+	if m.canCreateLambda() {
+		target = append(target, createLambdaItem{})
+	}
+
+	return target
+}
+
 func (m EndpointCreateModel) handleECNameStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEnter:
+			if err := m.validateName(m.nameInput.Value()); err != "" {
+				m.err = err
+				return m, nil
+			}
+
+			m.err = ""
 			m.Name = m.nameInput.Value()
 			return m.incStep()
-		case tea.KeyEsc:
-			return m, tea.Quit
 		}
 	}
 
@@ -215,10 +404,14 @@ func (m EndpointCreateModel) handleECEndpointStep(msg tea.Msg) (tea.Model, tea.C
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEnter:
+			if err := m.validatePath(m.pathInput.Value()); err != "" {
+				m.err = err
+				return m, nil
+			}
+
+			m.err = ""
 			m.Endpoint = m.pathInput.Value()
 			return m.incStep()
-		case tea.KeyEsc:
-			return m, tea.Quit
 		}
 	}
 
@@ -227,6 +420,53 @@ func (m EndpointCreateModel) handleECEndpointStep(msg tea.Msg) (tea.Model, tea.C
 	return m, cmd
 }
 
+func (m EndpointCreateModel) validateName(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return "Name can't be empty"
+	}
+
+	for _, e := range m.endpoints {
+		if e.Name == name {
+			return fmt.Sprintf("Endpoint %q already exists (%s)", name, e.Path)
+		}
+	}
+
+	return ""
+}
+
+func (m EndpointCreateModel) validatePath(path string) string {
+	if strings.TrimSpace(path) == "" {
+		return "Path can't be empty"
+	}
+
+	for _, e := range m.endpoints {
+		if e.Path == path {
+			return fmt.Sprintf("Path %q is already routed by endpoint %q", path, e.Name)
+		}
+	}
+
+	return ""
+}
+
+func (m EndpointCreateModel) handleECReviewStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEnter:
+			if m.review.Submitting() {
+				m.confirmed = true
+				return m.incStep()
+			}
+
+			return m.edit(ECNameStep + m.review.Cursor())
+		}
+	}
+
+	var cmd tea.Cmd
+	m.review, cmd = m.review.Update(msg)
+	return m, cmd
+}
+
 func (m EndpointCreateModel) handleECLoadingStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case EndpointCreateResponseMsg:
@@ -239,53 +479,180 @@ func (m EndpointCreateModel) handleECLoadingStep(msg tea.Msg) (tea.Model, tea.Cm
 	return m, cmd
 }
 
+// edit returns to step from the review step, the wizard comes back to the
+// review once the value is entered again.
+func (m *EndpointCreateModel) edit(step int) (*EndpointCreateModel, tea.Cmd) {
+	m.editing = &endpointFields{Name: m.Name, Lambda: m.Lambda, Endpoint: m.Endpoint}
+	return m.enterStep(step)
+}
+
+// decStep goes back to the previous step that asks for a value.
+func (m *EndpointCreateModel) decStep() (*EndpointCreateModel, tea.Cmd) {
+	if m.editing != nil {
+		m.Name, m.Lambda, m.Endpoint = m.editing.Name, m.editing.Lambda, m.editing.Endpoint
+		m.editing = nil
+		m.err = ""
+		m.nameInput.Blur()
+		m.pathInput.Blur()
+		m.step = ECReviewStep
+		return m, nil
+	}
+
+	switch m.step {
+	case ECLambdaSelectionStep:
+		return m.enterStep(ECNameStep)
+	case ECEndpointStep:
+		m.pathInput.Blur()
+		return m.enterStep(ECLambdaSelectionStep)
+	case ECReviewStep:
+		return m.enterStep(ECEndpointStep)
+	}
+
+	return m, nil
+}
+
+// enterStep moves the wizard to an input step, clearing its value so that
+// incStep doesn't skip it while keeping the previous value preselected.
+func (m *EndpointCreateModel) enterStep(step int) (*EndpointCreateModel, tea.Cmd) {
+	m.step = step
+	m.err = ""
+	m.interactive = true
+
+	switch step {
+	case ECNameStep:
+		m.nameInput.SetValue(m.Name)
+		m.nameInput.CursorEnd()
+		m.Name = ""
+		return m, tea.Batch(m.nameInput.Cursor.SetMode(cursor.CursorBlink), m.nameInput.Focus())
+	case ECLambdaSelectionStep:
+		for i, l := range m.lambdas {
+			if m.Lambda != nil && l.Id == m.Lambda.Id {
+				m.endpointList.Select(i)
+			}
+		}
+		m.Lambda = nil
+	case ECEndpointStep:
+		m.pathInput.SetValue(m.Endpoint)
+		m.pathInput.CursorEnd()
+		m.Endpoint = ""
+		return m, tea.Batch(m.pathInput.Cursor.SetMode(cursor.CursorBlink), m.pathInput.Focus())
+	}
+
+	return m, nil
+}
+
+// nextStep returns the step following a completed input step, which is the
+// review step when the value was edited from there.
+func (m *EndpointCreateModel) nextStep() int {
+	if m.editing != nil {
+		m.editing = nil
+		return ECReviewStep
+	}
+
+	return m.step + 1
+}
+
+func (m *EndpointCreateModel) updateReview() {
+	m.review.Fields = []review.Field{
+		{Label: "Name", Value: m.Name},
+		{Label: "Lambda", Value: fmt.Sprintf("%s (%s)", m.Lambda.Name, m.Lambda.Id)},
+		{Label: "Path", Value: m.Endpoint},
+	}
+}
+
 func (m *EndpointCreateModel) incStep(cmds ...tea.Cmd) (*EndpointCreateModel, tea.Cmd) {
 	if m.step == ECInitStep {
 		m.step++
-		return m.incStep(m.nameInput.Cursor.SetMode(cursor.CursorBlink), m.nameInput.Focus())
+
+		cmds = append(cmds, m.LambdaLister.List(), m.loadingSpinner.Tick)
+		if m.EndpointLister != nil {
+			cmds = append(cmds, m.EndpointLister.List())
+		}
+
+		return m.incStep(cmds...)
 	}
 
-	if m.step == ECNameStep && m.Name != "" {
+	if m.step == ECLambdasLoadingStep && m.lambdasLoaded && (m.EndpointLister == nil || m.endpointsLoaded) {
 		m.step++
-		m.nameInput.Blur()
-		m.static = fmt.Sprintf("%s\nName: %s", m.static, m.Name)
 
-		return m.incStep(m.LambdaLister.List(), m.loadingSpinner.Tick)
+		cmds = append(cmds, m.endpointList.SetItems(m.lambdaItems()))
+		// A name given upfront is checked like a typed one, and asked for
+		// again if it's taken
+		if m.Name != "" {
+			if err := m.validateName(m.Name); err != "" {
+				m.nameInput.SetValue(m.Name)
+				m.err = err
+				m.Name = ""
+			}
+		}
+		if m.Name == "" {
+			m.interactive = true
+			cmds = append(cmds, m.nameInput.Cursor.SetMode(cursor.CursorBlink), m.nameInput.Focus())
+		}
+
+		return m.incStep(cmds...)
 	}
 
-	if m.step == ECLambdasLoadingStep && (m.Lambda != nil || m.lambdasLoaded) {
-		m.step++
+	if m.step == ECNameStep && m.Name != "" {
+		m.step = m.nextStep()
+		m.nameInput.Blur()
 
-		target := []list.Item{}
-		for _, lambda := range m.lambdas {
-			target = append(target, lambdaItem{lambda})
+		if m.step == ECLambdaSelectionStep && m.Lambda == nil {
+			m.interactive = true
 		}
 
-		return m.incStep(m.endpointList.SetItems(target))
+		return m.incStep(cmds...)
 	}
 
 	if m.step == ECLambdaSelectionStep && m.Lambda != nil {
-		m.step++
-		m.static = fmt.Sprintf("%s\nLambda endpoint: %s", m.static, m.Lambda.Name)
+		m.step = m.nextStep()
+
+		if m.step == ECEndpointStep && m.Endpoint == "" {
+			m.interactive = true
+			cmds = append(cmds, m.pathInput.Cursor.SetMode(cursor.CursorBlink), m.pathInput.Focus())
+		}
 
-		return m.incStep(m.pathInput.SetCursorMode(textinput.CursorBlink), m.pathInput.Focus())
+		return m.incStep(cmds...)
 	}
 
+	// The path is checked once its step is reached, so that its error isn't
+	// hidden behind the name step
 	if m.step == ECEndpointStep && m.Endpoint != "" {
-		m.step++
+		if err := m.validatePath(m.Endpoint); err != "" {
+			m.pathInput.SetValue(m.Endpoint)
+			m.err = err
+			m.Endpoint = ""
+			m.interactive = true
+			cmds = append(cmds, m.pathInput.Cursor.SetMode(cursor.CursorBlink), m.pathInput.Focus())
+		}
+	}
+
+	if m.step == ECEndpointStep && m.Endpoint != "" {
+		m.step = m.nextStep()
 		m.pathInput.Blur()
-		m.static = fmt.Sprintf("%s\nPath: %s", m.static, m.Lambda.Name)
+		m.updateReview()
+		m.review.Reset()
 
-		return m.incStep(m.EndpointCreator.Create(m.Name, m.Endpoint, m.Lambda.Id), m.loadingSpinner.Tick)
+		return m.incStep(cmds...)
+	}
+
+	if m.step == ECReviewStep {
+		m.updateReview()
+	}
+
+	if m.step == ECReviewStep && (m.confirmed || !m.interactive) {
+		m.step++
+
+		return m.incStep(append(cmds, m.EndpointCreator.Create(m.Name, m.Endpoint, m.Lambda.Id), m.loadingSpinner.Tick)...)
 	}
 
 	if m.step == ECLoadingStep && m.resp != nil {
 		m.step++
 		if m.resp.Err != nil {
-			m.static = fmt.Sprintf("%s\n\nFailed to create endpoint: %s", m.static, m.resp.Err)
+			m.output = fmt.Sprintf("\n\nFailed to create endpoint: %s\n", m.resp.Err)
 		} else {
 			j, _ := json.MarshalIndent(m.resp.Endpoint, "", "  ")
-			m.static = fmt.Sprintf("%s\n\n%s", m.static, j)
+			m.output = fmt.Sprintf("\n\n%s\n", j)
 		}
 
 		return m.incStep(tea.Quit)
@@ -293,3 +660,11 @@ func (m *EndpointCreateModel) incStep(cmds ...tea.Cmd) (*EndpointCreateModel, te
 
 	return m, tea.Batch(cmds...)
 }
+
+func (m EndpointCreateModel) GetEndpoint() *api.Endpoint {
+	if m.resp == nil {
+		return nil
+	}
+
+	return m.resp.Endpoint
+}
@@ -0,0 +1,135 @@
+package endpoint
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+func newEndpointCreateDriver(t *testing.T, m *EndpointCreateModel) *tuitest.Driver {
+	if m.LambdaLister == nil {
+		m.LambdaLister = fakeLambdaLister{lambdas: testLambdas}
+	}
+	m.EndpointLister = fakeEndpointLister{endpoints: testEndpoints}
+
+	d := tuitest.New(t, InitEndpointCreateModel(m))
+	if !d.Quit() {
+		d.Send(tea.WindowSizeMsg{Width: 80, Height: 16})
+	}
+
+	return d
+}
+
+func TestEndpointCreateSuccess(t *testing.T) {
+	d := newEndpointCreateDriver(t, &EndpointCreateModel{
+		EndpointCreator: fakeEndpointCreator{},
+	})
+	d.Type("greeting")
+	d.Key(tea.KeyEnter)
+	d.Key(tea.KeyEnter)
+	d.Type("/greeting")
+	d.Key(tea.KeyEnter)
+	d.Key(tea.KeyEnter)
+
+	if !d.Quit() {
+		t.Error("model did not quit once the endpoint was created")
+	}
+	if e := d.Model().(EndpointCreateModel).GetEndpoint(); e == nil || e.Path != "/greeting" || e.Lambda != "lambda-3" {
+		t.Errorf("created endpoint = %+v", e)
+	}
+	d.Golden()
+}
+
+func TestEndpointCreateRejectsRoutedPath(t *testing.T) {
+	d := newEndpointCreateDriver(t, &EndpointCreateModel{
+		Name:            "greeting",
+		EndpointCreator: fakeEndpointCreator{},
+	})
+	d.Key(tea.KeyEnter)
+	d.Type("/hello")
+	d.Key(tea.KeyEnter)
+
+	d.Golden()
+}
+
+func TestEndpointCreateAsksAgainForExistingName(t *testing.T) {
+	d := newEndpointCreateDriver(t, &EndpointCreateModel{
+		Name:            "hello",
+		Endpoint:        "/greeting",
+		Lambda:          &testLambdas[0],
+		EndpointCreator: fakeEndpointCreator{},
+	})
+	if d.Quit() {
+		t.Fatal("model created an endpoint with an existing name")
+	}
+	d.Golden()
+
+	d.Type("2")
+	d.Key(tea.KeyEnter)
+	d.Key(tea.KeyEnter)
+
+	if e := d.Model().(EndpointCreateModel).GetEndpoint(); !d.Quit() || e == nil || e.Name != "hello2" {
+		t.Errorf("created endpoint = %+v", e)
+	}
+}
+
+func TestEndpointCreateAsksAgainForRoutedPath(t *testing.T) {
+	d := newEndpointCreateDriver(t, &EndpointCreateModel{
+		Name:            "greeting",
+		Endpoint:        "/hello",
+		Lambda:          &testLambdas[0],
+		EndpointCreator: fakeEndpointCreator{},
+	})
+	if d.Quit() {
+		t.Fatal("model created an endpoint on a routed path")
+	}
+	d.Golden()
+
+	d.Type("2")
+	d.Key(tea.KeyEnter)
+	d.Key(tea.KeyEnter)
+
+	if e := d.Model().(EndpointCreateModel).GetEndpoint(); !d.Quit() || e == nil || e.Path != "/hello2" {
+		t.Errorf("created endpoint = %+v", e)
+	}
+}
+
+func TestEndpointCreateNoLambdas(t *testing.T) {
+	d := newEndpointCreateDriver(t, &EndpointCreateModel{
+		LambdaLister:    fakeLambdaLister{lambdas: testLambdas[1:]},
+		EndpointCreator: fakeEndpointCreator{},
+	})
+
+	if !d.Quit() {
+		t.Error("model did not quit without ENDPOINT lambdas")
+	}
+	d.Golden()
+}
+
+func TestEndpointCreateError(t *testing.T) {
+	d := newEndpointCreateDriver(t, &EndpointCreateModel{
+		Name:            "greeting",
+		Endpoint:        "/greeting",
+		Lambda:          &testLambdas[0],
+		EndpointCreator: fakeEndpointCreator{err: errors.New("lambda not found: lambda-3")},
+	})
+
+	if d.Model().(EndpointCreateModel).GetEndpoint() != nil {
+		t.Error("endpoint reported despite the error")
+	}
+	d.Golden()
+}
+
+func TestEndpointCreateCancel(t *testing.T) {
+	d := newEndpointCreateDriver(t, &EndpointCreateModel{
+		EndpointCreator: fakeEndpointCreator{},
+	})
+	d.Key(tea.KeyEsc)
+
+	if !d.Quit() {
+		t.Error("model did not quit on esc")
+	}
+	d.Golden()
+}
@@ -0,0 +1,107 @@
+package endpoint
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/bench"
+	"github.com/onpremless/opcli/tui/lambda"
+)
+
+var createdAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+
+var testLambdas = []api.Lambda{
+	{Id: "lambda-3", Name: "hello", Runtime: "runtime-1", LambdaType: "ENDPOINT", Docker: api.Docker{Status: "RUNNING"}, CreatedAt: createdAt, UpdatedAt: createdAt},
+	{Id: "lambda-4", Name: "worker", Runtime: "runtime-1", LambdaType: "INTERNAL", Docker: api.Docker{Status: "RUNNING"}, CreatedAt: createdAt, UpdatedAt: createdAt},
+}
+
+var testEndpoints = []api.Endpoint{
+	{Id: "endpoint-5", Name: "hello", Path: "/hello", Lambda: "lambda-3", CreatedAt: createdAt, UpdatedAt: createdAt},
+	{Id: "endpoint-6", Name: "legacy", Path: "/legacy", Lambda: "lambda-1", CreatedAt: createdAt, UpdatedAt: createdAt},
+}
+
+type fakeLambdaLister struct {
+	lambdas []api.Lambda
+	err     error
+}
+
+func (f fakeLambdaLister) List() tea.Cmd {
+	return func() tea.Msg {
+		return lambda.LambdaListResponseMsg{Resp: &lambda.LambdaListResponse{Lambdas: f.lambdas, Err: f.err}}
+	}
+}
+
+type fakeEndpointLister struct {
+	endpoints []api.Endpoint
+	err       error
+}
+
+func (f fakeEndpointLister) List() tea.Cmd {
+	return func() tea.Msg {
+		return EndpointListResponseMsg{Resp: &EndpointListResponse{Endpoints: f.endpoints, Err: f.err}}
+	}
+}
+
+type fakeEndpointCreator struct {
+	err error
+}
+
+func (f fakeEndpointCreator) Create(name string, path string, lambdaID string) tea.Cmd {
+	return func() tea.Msg {
+		if f.err != nil {
+			return EndpointCreateResponseMsg{Resp: &EndpointCreateResponse{Err: f.err}}
+		}
+
+		return EndpointCreateResponseMsg{Resp: &EndpointCreateResponse{Endpoint: &api.Endpoint{
+			Id:        "endpoint-7",
+			Name:      name,
+			Path:      path,
+			Lambda:    lambdaID,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}}}
+	}
+}
+
+// fakeBenchRunner returns its snapshots in turn, finishing with the last.
+type fakeBenchRunner struct {
+	opts      bench.Options
+	snapshots []bench.Snapshot
+	done      chan struct{}
+}
+
+func newFakeBenchRunner(snapshots ...bench.Snapshot) *fakeBenchRunner {
+	return &fakeBenchRunner{
+		opts:      bench.Options{URL: "http://gw.test/hello", Method: "GET", Concurrency: 4, RPS: 50, Duration: 10 * time.Second},
+		snapshots: snapshots,
+		done:      make(chan struct{}),
+	}
+}
+
+func (f *fakeBenchRunner) Options() bench.Options {
+	return f.opts
+}
+
+func (f *fakeBenchRunner) Snapshot() bench.Snapshot {
+	s := f.snapshots[0]
+	if len(f.snapshots) > 1 {
+		f.snapshots = f.snapshots[1:]
+	} else {
+		f.finish()
+	}
+
+	return s
+}
+
+func (f *fakeBenchRunner) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *fakeBenchRunner) finish() {
+	select {
+	case <-f.done:
+	default:
+		close(f.done)
+	}
+}
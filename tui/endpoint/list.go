@@ -1,14 +1,23 @@
 package endpoint
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/tui/lambda"
+	"github.com/onpremless/opcli/tui/listing"
 )
 
+var EndpointColumns = []listing.Column{
+	{Key: "id", Title: "ID"},
+	{Key: "name", Title: "Name"},
+	{Key: "path", Title: "Path"},
+	{Key: "lambda", Title: "Lambda"},
+}
+
 type EndpointListResponseMsg struct {
 	Resp *EndpointListResponse
 }
@@ -23,10 +32,19 @@ type EndpointLister interface {
 }
 
 type EndpointListModel struct {
-	Lister EndpointLister
-
-	resp *EndpointListResponse
-
+	Lister       EndpointLister
+	LambdaLister lambda.LambdaLister
+	Filter       listing.Filter
+	Sort         listing.Sort
+	// Plain renders a non-interactive table and quits, used when stdout is
+	// not a terminal
+	Plain bool
+
+	resp        *EndpointListResponse
+	lambdasResp *lambda.LambdaListResponse
+	rows        []table.Row
+
+	table          listing.TableModel
 	loadingSpinner spinner.Model
 }
 
@@ -39,7 +57,7 @@ func InitEndpointListModel(m *EndpointListModel) *EndpointListModel {
 }
 
 func (m EndpointListModel) Init() tea.Cmd {
-	return tea.Batch(m.Lister.List(), m.loadingSpinner.Tick)
+	return tea.Batch(m.Lister.List(), m.LambdaLister.List(), m.loadingSpinner.Tick)
 }
 
 func (m EndpointListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -51,7 +69,16 @@ func (m EndpointListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case EndpointListResponseMsg:
 		m.resp = msg.Resp
-		return m, tea.Quit
+		return m.loaded()
+	case lambda.LambdaListResponseMsg:
+		m.lambdasResp = msg.Resp
+		return m.loaded()
+	}
+
+	if m.rows != nil {
+		var cmd tea.Cmd
+		m.table, cmd = m.table.Update(msg)
+		return m, cmd
 	}
 
 	var cmd tea.Cmd
@@ -59,15 +86,60 @@ func (m EndpointListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m EndpointListModel) loaded() (tea.Model, tea.Cmd) {
+	if m.resp == nil || m.lambdasResp == nil {
+		return m, nil
+	}
+
+	if m.resp.Err != nil || m.lambdasResp.Err != nil {
+		return m, tea.Quit
+	}
+
+	m.rows = EndpointRows(m.resp.Endpoints, m.lambdasResp.Lambdas)
+	if m.Plain {
+		return m, tea.Quit
+	}
+
+	m.table = listing.NewTableModel(EndpointColumns, m.rows, m.Filter, m.Sort)
+	return m, nil
+}
+
 func (m EndpointListModel) View() string {
-	if m.resp == nil {
+	if m.resp == nil || m.lambdasResp == nil {
 		return fmt.Sprintf("%s Query endpoints...", m.loadingSpinner.View())
 	}
 
 	if m.resp.Err != nil {
-		return fmt.Sprintf("Failed to create endpoint: %s\n", m.resp.Err)
-	} else {
-		j, _ := json.MarshalIndent(m.resp.Endpoints, "", "  ")
-		return string(j) + "\n"
+		return fmt.Sprintf("Failed to list endpoints: %s\n", m.resp.Err)
+	}
+	if m.lambdasResp.Err != nil {
+		return fmt.Sprintf("Failed to list lambdas: %s\n", m.lambdasResp.Err)
+	}
+
+	if m.Plain {
+		return listing.Plain(EndpointColumns, listing.Apply(EndpointColumns, m.rows, m.Filter, m.Sort))
+	}
+
+	return m.table.View()
+}
+
+// EndpointRows converts endpoints into table rows, resolving lambda IDs into
+// lambda names where possible.
+func EndpointRows(endpoints []api.Endpoint, lambdas []api.Lambda) []table.Row {
+	names := map[string]string{}
+	for _, l := range lambdas {
+		names[l.Id] = l.Name
 	}
+
+	rows := make([]table.Row, len(endpoints))
+	for i, e := range endpoints {
+		name, ok := names[e.Lambda]
+		if !ok {
+			name = e.Lambda
+		}
+
+		rows[i] = table.Row{e.Id, e.Name, e.Path, name}
+	}
+
+	return rows
 }
@@ -0,0 +1,42 @@
+package endpoint
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+func TestEndpointListSuccess(t *testing.T) {
+	d := tuitest.New(t, InitEndpointListModel(&EndpointListModel{
+		Lister:       fakeEndpointLister{endpoints: testEndpoints},
+		LambdaLister: fakeLambdaLister{lambdas: testLambdas},
+	}))
+	d.Send(tea.WindowSizeMsg{Width: 80, Height: 12})
+	d.Key(tea.KeyCtrlC)
+
+	d.Golden()
+}
+
+func TestEndpointListEmpty(t *testing.T) {
+	d := tuitest.New(t, InitEndpointListModel(&EndpointListModel{
+		Lister:       fakeEndpointLister{},
+		LambdaLister: fakeLambdaLister{lambdas: testLambdas},
+		Plain:        true,
+	}))
+
+	d.Golden()
+}
+
+func TestEndpointListError(t *testing.T) {
+	d := tuitest.New(t, InitEndpointListModel(&EndpointListModel{
+		Lister:       fakeEndpointLister{endpoints: testEndpoints},
+		LambdaLister: fakeLambdaLister{err: errors.New("connection refused")},
+	}))
+
+	if !d.Quit() {
+		t.Error("model did not quit on error")
+	}
+	d.Golden()
+}
@@ -0,0 +1,174 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/deploy"
+)
+
+const batchLineWidth = 60
+
+type batchDoneMsg struct{}
+
+type batchRow struct {
+	status  string
+	line    string
+	err     error
+	started time.Time
+	elapsed time.Duration
+}
+
+// LambdaBatchModel shows the per-lambda progress of a batch deploy fed by
+// Updates, and quits once the channel is closed.
+type LambdaBatchModel struct {
+	Targets  []deploy.Target
+	Parallel int
+	Updates  <-chan deploy.Update
+
+	rows     []batchRow
+	finished bool
+	// now is the clock, set by tests
+	now func() time.Time
+
+	loadingSpinner spinner.Model
+}
+
+func InitLambdaBatchModel(m *LambdaBatchModel) *LambdaBatchModel {
+	if m.now == nil {
+		m.now = time.Now
+	}
+
+	m.rows = make([]batchRow, len(m.Targets))
+	for i := range m.rows {
+		m.rows[i].status = deploy.StatusPending
+	}
+
+	m.loadingSpinner = spinner.New()
+
+	m.loadingSpinner.Spinner = spinner.Dot
+
+	return m
+}
+
+func (m LambdaBatchModel) Init() tea.Cmd {
+	return tea.Batch(m.next(), m.loadingSpinner.Tick)
+}
+
+func (m LambdaBatchModel) next() tea.Cmd {
+	updates := m.Updates
+	return func() tea.Msg {
+		u, ok := <-updates
+		if !ok {
+			return batchDoneMsg{}
+		}
+
+		return u
+	}
+}
+
+func (m LambdaBatchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		}
+	case deploy.Update:
+		m.apply(msg)
+		return m, m.next()
+	case batchDoneMsg:
+		m.finished = true
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.loadingSpinner, cmd = m.loadingSpinner.Update(msg)
+	return m, cmd
+}
+
+func (m *LambdaBatchModel) apply(u deploy.Update) {
+	rows := append([]batchRow{}, m.rows...)
+	row := &rows[u.Index]
+
+	if row.started.IsZero() {
+		row.started = m.now()
+	}
+	if u.Status != row.status {
+		row.line = ""
+	}
+	if u.Line != "" {
+		row.line = u.Line
+	}
+
+	row.status = u.Status
+	row.err = u.Err
+	if u.Status == deploy.StatusDone || u.Status == deploy.StatusFailed {
+		row.elapsed = m.now().Sub(row.started)
+	}
+
+	m.rows = rows
+}
+
+func (m LambdaBatchModel) View() string {
+	done, failed := 0, 0
+	for _, r := range m.rows {
+		switch r.status {
+		case deploy.StatusDone:
+			done++
+		case deploy.StatusFailed:
+			failed++
+		}
+	}
+
+	nameWidth, dirWidth := 4, 3
+	for _, t := range m.Targets {
+		nameWidth = max(nameWidth, len(t.Name()))
+		dirWidth = max(dirWidth, len(t.Dir))
+	}
+
+	lines := []string{
+		fmt.Sprintf("Deploying %d target(s), %d at a time: %d done, %d failed", len(m.Targets), m.Parallel, done, failed),
+		"",
+	}
+
+	for i, t := range m.Targets {
+		r := m.rows[i]
+
+		icon := "· "
+		detail := ""
+		switch r.status {
+		case deploy.StatusPending:
+		case deploy.StatusDone:
+			icon = "✓ "
+			detail = r.elapsed.Round(100 * time.Millisecond).String()
+		case deploy.StatusFailed:
+			icon = errorStyle.Render("✗") + " "
+			detail = errorStyle.Render(truncate(r.err.Error(), batchLineWidth))
+		default:
+			icon = m.loadingSpinner.View()
+			detail = helpStyle.Render(truncate(r.line, batchLineWidth))
+		}
+
+		lines = append(lines, fmt.Sprintf("%s%-*s  %-*s  %-11s %s", icon, nameWidth, t.Name(), dirWidth, t.Dir, r.status, detail))
+	}
+
+	return docStyle.Render(strings.Join(lines, "\n"))
+}
+
+// Interrupted reports whether the model quit before every deploy finished.
+func (m LambdaBatchModel) Interrupted() bool {
+	return !m.finished
+}
+
+func truncate(s string, width int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len([]rune(s)) <= width {
+		return s
+	}
+
+	return string([]rune(s)[:width-1]) + "…"
+}
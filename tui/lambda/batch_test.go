@@ -0,0 +1,56 @@
+package lambda
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/deploy"
+	"github.com/onpremless/opcli/manifest"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+var batchTargets = []deploy.Target{
+	{Dir: "runtimes/go", Runtime: &manifest.Runtime{Name: "go"}},
+	{Dir: "lambdas/hello", Lambda: manifest.Lambda{Name: "hello", Runtime: "go"}},
+	{Dir: "lambdas/worker", Lambda: manifest.Lambda{Name: "worker", Runtime: "go"}},
+}
+
+func TestLambdaBatch(t *testing.T) {
+	updates := make(chan deploy.Update, 8)
+	for _, u := range []deploy.Update{
+		{Index: 0, Status: deploy.StatusBuilding},
+		{Index: 0, Status: deploy.StatusDone},
+		{Index: 1, Status: deploy.StatusBuilding, Line: "> npm run build"},
+		{Index: 2, Status: deploy.StatusDeploying},
+		{Index: 1, Status: deploy.StatusDone},
+		{Index: 2, Status: deploy.StatusFailed, Err: errors.New("lambda worker failed to start:\ncontainer exited with code 1")},
+	} {
+		updates <- u
+	}
+	close(updates)
+
+	// Deploys finish in the order of the updates, a second apart
+	d := tuitest.New(t, InitLambdaBatchModel(&LambdaBatchModel{
+		Targets:  batchTargets,
+		Parallel: 2,
+		Updates:  updates,
+		now:      newFakeClock(time.Second).now,
+	}))
+
+	if !d.Quit() || d.Model().(LambdaBatchModel).Interrupted() {
+		t.Error("batch did not finish once the updates were closed")
+	}
+	d.Golden()
+}
+
+func TestLambdaBatchInterrupted(t *testing.T) {
+	var m tea.Model = *InitLambdaBatchModel(&LambdaBatchModel{Targets: batchTargets, Parallel: 2})
+	m, _ = m.Update(deploy.Update{Index: 0, Status: deploy.StatusBuilding, Line: "Step 1/4 : FROM golang"})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+
+	if cmd == nil || !m.(LambdaBatchModel).Interrupted() {
+		t.Error("ctrl+c did not interrupt the batch")
+	}
+}
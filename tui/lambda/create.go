@@ -2,9 +2,12 @@ package lambda
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/tui/review"
 	"github.com/onpremless/opcli/tui/runtime"
 
 	"github.com/charmbracelet/bubbles/cursor"
@@ -17,11 +20,14 @@ import (
 
 const (
 	LCInitStep             = 0
-	LCCNameStep            = iota
 	LCRuntimesLoadingStep  = iota
+	LCCNameStep            = iota
 	LCRuntimeSelectionStep = iota
 	LCTypeStep             = iota
+	LCPathStep             = iota
+	LCReviewStep           = iota
 	LCLoadingStep          = iota
+	LCDoneStep             = iota
 )
 
 type LambdaCreateResponse struct {
@@ -33,6 +39,12 @@ type LambdaCreateResponseMsg struct {
 	Resp *LambdaCreateResponse
 }
 
+// LambdaCreateDoneMsg is emitted instead of quitting by an embedded
+// LambdaCreateModel. Resp is nil when the wizard was cancelled.
+type LambdaCreateDoneMsg struct {
+	Resp *LambdaCreateResponse
+}
+
 type runtimeItem struct {
 	Runtime api.Runtime
 }
@@ -41,6 +53,12 @@ func (i runtimeItem) Title() string       { return i.Runtime.Name }
 func (i runtimeItem) Description() string { return i.Runtime.GetId() }
 func (i runtimeItem) FilterValue() string { return i.Runtime.Name }
 
+type createRuntimeItem struct{}
+
+func (i createRuntimeItem) Title() string       { return "+ create new" }
+func (i createRuntimeItem) Description() string { return "Build a new runtime from a Dockerfile" }
+func (i createRuntimeItem) FilterValue() string { return "" }
+
 type typeItem struct {
 	LambdaType string
 	Name       string
@@ -61,7 +79,20 @@ type LambdaCreator interface {
 	Create(name string, runtime string, lambdaType string, path string) tea.Cmd
 }
 
-var docStyle = lipgloss.NewStyle().Margin(1, 2)
+var (
+	docStyle   = lipgloss.NewStyle().Margin(1, 2)
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	helpStyle  = lipgloss.NewStyle().Faint(true)
+)
+
+// lambdaFields is a snapshot of the values the wizard asks for, used to
+// restore them when editing from the review step is abandoned.
+type lambdaFields struct {
+	Name       string
+	Runtime    *api.Runtime
+	LambdaType string
+	Path       string
+}
 
 type LambdaCreateModel struct {
 	Name          string
@@ -70,18 +101,44 @@ type LambdaCreateModel struct {
 	Runtime       *api.Runtime
 	LambdaCreator LambdaCreator
 	RuntimeLister runtime.RuntimeLister
-
-	static string
+	// LambdaLister is optional, when set names of existing lambdas are
+	// rejected, whether given with Name or typed in
+	LambdaLister LambdaLister
+	// RuntimeCreator is optional, when set the runtime picker offers to
+	// create a new runtime
+	RuntimeCreator runtime.RuntimeCreator
+	// Embedded makes the wizard report its result with LambdaCreateDoneMsg
+	// rather than quitting, so that it can run as a part of another wizard
+	Embedded bool
+
+	output string
+	err    string
 
 	lambdaTypes    []typeItem
 	runtimes       []api.Runtime
 	runtimesLoaded bool
+	lambdas        []api.Lambda
+	lambdasLoaded  bool
 	resp           *LambdaCreateResponse
 
+	// interactive is set once any of the values was asked for, a wizard
+	// fully driven by flags doesn't stop on the review step
+	interactive bool
+	confirmed   bool
+	// editing holds the values before a field was picked for editing on the
+	// review step
+	editing *lambdaFields
+
+	// subflow is the nested runtime wizard started from the runtime picker
+	subflow    tea.Model
+	windowSize *tea.WindowSizeMsg
+
 	step           int
 	nameInput      textinput.Model
+	pathInput      textinput.Model
 	runtimeList    list.Model
 	typeList       list.Model
+	review         review.Model
 	loadingSpinner spinner.Model
 }
 
@@ -95,8 +152,12 @@ func InitLambdaCreateModel(m *LambdaCreateModel) *LambdaCreateModel {
 	m.nameInput.CharLimit = 156
 	m.nameInput.Placeholder = "Lambda name"
 
+	m.pathInput = textinput.New()
+	m.pathInput.CharLimit = 1024
+	m.pathInput.Placeholder = "Sources directory"
+
 	m.runtimeList = list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
-	m.runtimeList.Title = "Lambda endpoints"
+	m.runtimeList.Title = "Runtimes"
 	m.runtimeList.SetFilteringEnabled(false)
 
 	genericLambdaTypes := make([]list.Item, len(m.lambdaTypes))
@@ -107,6 +168,8 @@ func InitLambdaCreateModel(m *LambdaCreateModel) *LambdaCreateModel {
 	m.typeList.Title = "Lambda type"
 	m.typeList.SetFilteringEnabled(false)
 
+	m.review = review.New("Create lambda", "Create")
+
 	m.loadingSpinner = spinner.New()
 
 	m.loadingSpinner.Spinner = spinner.Dot
@@ -119,6 +182,10 @@ func (m LambdaCreateModel) Init() tea.Cmd {
 }
 
 func (m LambdaCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.subflow != nil {
+		return m.handleSubflow(msg)
+	}
+
 	switch msg := msg.(type) {
 	case lambdaCreateStartMsg:
 		return m.incStep()
@@ -126,8 +193,16 @@ func (m LambdaCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			return m, tea.Quit
+		case tea.KeyShiftTab:
+			return m.decStep()
+		case tea.KeyEsc:
+			if m.step == LCCNameStep && m.editing == nil {
+				return m, m.done()
+			}
+			return m.decStep()
 		}
 	case tea.WindowSizeMsg:
+		m.windowSize = &msg
 		x, y := docStyle.GetFrameSize()
 		m.runtimeList.SetSize(msg.Width-x, msg.Height-y)
 		m.typeList.SetSize(msg.Width-x, msg.Height-y)
@@ -142,6 +217,10 @@ func (m LambdaCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleLCLambdaSelectionStep(msg)
 	case LCTypeStep:
 		return m.handleLCTypeStep(msg)
+	case LCPathStep:
+		return m.handleLCPathStep(msg)
+	case LCReviewStep:
+		return m.handleLCReviewStep(msg)
 	case LCLoadingStep:
 		return m.handleLCLoadingStep(msg)
 	}
@@ -149,44 +228,103 @@ func (m LambdaCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m LambdaCreateModel) View() string {
-	static := m.static
+	if m.subflow != nil {
+		return docStyle.Render(m.subflow.View())
+	}
+
+	static := m.transcript()
 	if static != "" {
 		static += "\n"
 	}
 
 	active := ""
 	if m.step == LCCNameStep {
-		active = docStyle.Render(m.nameInput.View())
+		active = docStyle.Render(m.withError(m.nameInput.View()))
 	} else if m.step == LCRuntimesLoadingStep {
 		active = docStyle.Render(fmt.Sprintf("%s Loading runtimes...", m.loadingSpinner.View()))
 	} else if m.step == LCRuntimeSelectionStep {
+		if m.err != "" {
+			return docStyle.Render(fmt.Sprintf("%s\n%s", m.runtimeList.View(), errorStyle.Render(m.err)))
+		}
 		return docStyle.Render(m.runtimeList.View())
 	} else if m.step == LCTypeStep {
 		return docStyle.Render(m.typeList.View())
+	} else if m.step == LCPathStep {
+		active = docStyle.Render(m.withError(m.pathInput.View()))
+	} else if m.step == LCReviewStep {
+		return docStyle.Render(m.review.View())
 	} else if m.step == LCLoadingStep {
 		active = docStyle.Render(fmt.Sprintf("%s Creating lambda...", m.loadingSpinner.View()))
+	} else if m.step == LCDoneStep {
+		active = m.output
 	}
 
 	return fmt.Sprintf("%s%s", static, active)
 }
 
+// transcript lists the values chosen in the steps behind the current one.
+func (m LambdaCreateModel) transcript() string {
+	lines := []string{}
+	if m.step > LCCNameStep && m.Name != "" {
+		lines = append(lines, fmt.Sprintf("Name: %s", m.Name))
+	}
+	if m.step > LCRuntimeSelectionStep && m.Runtime != nil {
+		lines = append(lines, fmt.Sprintf("Runtime: %s", m.Runtime.Name))
+	}
+	if m.step > LCTypeStep && m.LambdaType != "" {
+		lines = append(lines, fmt.Sprintf("Lambda type: %s", m.LambdaType))
+	}
+	if m.step > LCPathStep && m.Path != "" {
+		lines = append(lines, fmt.Sprintf("Sources: %s", m.Path))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "\n" + strings.Join(lines, "\n")
+}
+
+func (m LambdaCreateModel) withError(view string) string {
+	help := helpStyle.Render("enter confirm • shift+tab back • esc quit")
+	if m.err == "" {
+		return fmt.Sprintf("%s\n\n%s", view, help)
+	}
+
+	return fmt.Sprintf("%s\n%s\n\n%s", view, errorStyle.Render(m.err), help)
+}
+
 func (m LambdaCreateModel) handleLCRuntimesLoadingStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case runtime.RuntimeListResponseMsg:
 		if msg.Resp.Err != nil {
-			m.static = fmt.Sprintf("%s\n\nFailed to load runtimes: %s", m.static, msg.Resp.Err)
-			return m, tea.Quit
+			m.resp = &LambdaCreateResponse{Err: fmt.Errorf("failed to load runtimes: %w", msg.Resp.Err)}
+			m.output = fmt.Sprintf("\nFailed to load runtimes: %s\n", msg.Resp.Err)
+			m.step = LCDoneStep
+			return m, m.done()
 		}
 
 		m.runtimes = msg.Resp.Runtimes
 
-		if len(m.runtimes) == 0 {
-			m.static = fmt.Sprintf("%s\n\nNo runtimes was found", m.static)
-			return m, tea.Quit
+		if len(m.runtimes) == 0 && m.RuntimeCreator == nil {
+			m.resp = &LambdaCreateResponse{Err: errors.New("no runtimes was found")}
+			m.output = "\nNo runtimes was found\n"
+			m.step = LCDoneStep
+			return m, m.done()
 		}
 
 		m.runtimesLoaded = true
 		return m.incStep()
+	case LambdaListResponseMsg:
+		if msg.Resp.Err != nil {
+			m.resp = &LambdaCreateResponse{Err: fmt.Errorf("failed to load lambdas: %w", msg.Resp.Err)}
+			m.output = fmt.Sprintf("\nFailed to load lambdas: %s\n", msg.Resp.Err)
+			m.step = LCDoneStep
+			return m, m.done()
+		}
+
+		m.lambdas = msg.Resp.Lambdas
+		m.lambdasLoaded = true
+		return m.incStep()
 	}
 
 	var cmd tea.Cmd
@@ -199,10 +337,13 @@ func (m LambdaCreateModel) handleLCLambdaSelectionStep(msg tea.Msg) (tea.Model,
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEnter:
-			m.Runtime = &m.runtimes[m.runtimeList.Cursor()]
+			if m.runtimeList.Index() == len(m.runtimes) {
+				return m.startRuntimeSubflow()
+			}
+
+			m.err = ""
+			m.Runtime = &m.runtimes[m.runtimeList.Index()]
 			return m.incStep()
-		case tea.KeyEsc:
-			return m, tea.Quit
 		}
 	}
 
@@ -211,15 +352,75 @@ func (m LambdaCreateModel) handleLCLambdaSelectionStep(msg tea.Msg) (tea.Model,
 	return m, cmd
 }
 
+// startRuntimeSubflow runs the runtime wizard in place of the runtime
+// picker, the created runtime gets selected once it's done.
+func (m LambdaCreateModel) startRuntimeSubflow() (tea.Model, tea.Cmd) {
+	m.err = ""
+	sub := runtime.InitRuntimeCreateModel(&runtime.RuntimeCreateModel{
+		Creator:  m.RuntimeCreator,
+		Embedded: true,
+	})
+	m.subflow = sub
+
+	cmds := []tea.Cmd{sub.Init()}
+	if m.windowSize != nil {
+		size := *m.windowSize
+		cmds = append(cmds, func() tea.Msg { return size })
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m LambdaCreateModel) handleSubflow(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case runtime.RuntimeCreateDoneMsg:
+		m.subflow = nil
+		if msg.Resp == nil {
+			return m, nil
+		}
+		if msg.Resp.Err != nil {
+			m.err = fmt.Sprintf("Failed to create runtime: %s", msg.Resp.Err)
+			return m, nil
+		}
+
+		m.runtimes = append(m.runtimes, *msg.Resp.Runtime)
+		m.Runtime = &m.runtimes[len(m.runtimes)-1]
+		return m.incStep(m.runtimeList.SetItems(m.runtimeItems()))
+	case tea.WindowSizeMsg:
+		m.windowSize = &msg
+	}
+
+	var cmd tea.Cmd
+	m.subflow, cmd = m.subflow.Update(msg)
+	return m, cmd
+}
+
+func (m LambdaCreateModel) runtimeItems() []list.Item {
+	target := []list.Item{}
+	for _, lambda := range m.runtimes {
+		target = append(target, runtimeItem{lambda})
+	}
+
+	if m.RuntimeCreator != nil {
+		target = append(target, createRuntimeItem{})
+	}
+
+	return target
+}
+
 func (m LambdaCreateModel) handleLCNameStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEnter:
+			if err := m.validateName(m.nameInput.Value()); err != "" {
+				m.err = err
+				return m, nil
+			}
+
+			m.err = ""
 			m.Name = m.nameInput.Value()
 			return m.incStep()
-		case tea.KeyEsc:
-			return m, tea.Quit
 		}
 	}
 
@@ -228,15 +429,27 @@ func (m LambdaCreateModel) handleLCNameStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m LambdaCreateModel) validateName(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return "Name can't be empty"
+	}
+
+	for _, l := range m.lambdas {
+		if l.Name == name {
+			return fmt.Sprintf("Lambda %q already exists (%s)", name, l.Id)
+		}
+	}
+
+	return ""
+}
+
 func (m LambdaCreateModel) handleLCTypeStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEnter:
-			m.LambdaType = m.lambdaTypes[m.typeList.Cursor()].LambdaType
+			m.LambdaType = m.lambdaTypes[m.typeList.Index()].LambdaType
 			return m.incStep()
-		case tea.KeyEsc:
-			return m, tea.Quit
 		}
 	}
 
@@ -245,6 +458,46 @@ func (m LambdaCreateModel) handleLCTypeStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m LambdaCreateModel) handleLCPathStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEnter:
+			if strings.TrimSpace(m.pathInput.Value()) == "" {
+				m.err = "Sources directory can't be empty"
+				return m, nil
+			}
+
+			m.err = ""
+			m.Path = m.pathInput.Value()
+			return m.incStep()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	return m, cmd
+}
+
+func (m LambdaCreateModel) handleLCReviewStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEnter:
+			if m.review.Submitting() {
+				m.confirmed = true
+				return m.incStep()
+			}
+
+			return m.edit(LCCNameStep + m.review.Cursor())
+		}
+	}
+
+	var cmd tea.Cmd
+	m.review, cmd = m.review.Update(msg)
+	return m, cmd
+}
+
 func (m LambdaCreateModel) handleLCLoadingStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case LambdaCreateResponseMsg:
@@ -257,55 +510,204 @@ func (m LambdaCreateModel) handleLCLoadingStep(msg tea.Msg) (tea.Model, tea.Cmd)
 	return m, cmd
 }
 
+// edit returns to step from the review step, the wizard comes back to the
+// review once the value is entered again.
+func (m *LambdaCreateModel) edit(step int) (*LambdaCreateModel, tea.Cmd) {
+	m.editing = &lambdaFields{Name: m.Name, Runtime: m.Runtime, LambdaType: m.LambdaType, Path: m.Path}
+	return m.enterStep(step)
+}
+
+// decStep goes back to the previous step that asks for a value.
+func (m *LambdaCreateModel) decStep() (*LambdaCreateModel, tea.Cmd) {
+	if m.editing != nil {
+		m.Name, m.Runtime, m.LambdaType, m.Path = m.editing.Name, m.editing.Runtime, m.editing.LambdaType, m.editing.Path
+		m.editing = nil
+		m.err = ""
+		m.nameInput.Blur()
+		m.pathInput.Blur()
+		m.step = LCReviewStep
+		return m, nil
+	}
+
+	switch m.step {
+	case LCRuntimeSelectionStep:
+		return m.enterStep(LCCNameStep)
+	case LCTypeStep:
+		return m.enterStep(LCRuntimeSelectionStep)
+	case LCPathStep:
+		m.pathInput.Blur()
+		return m.enterStep(LCTypeStep)
+	case LCReviewStep:
+		return m.enterStep(LCPathStep)
+	}
+
+	return m, nil
+}
+
+// enterStep moves the wizard to an input step, clearing its value so that
+// incStep doesn't skip it while keeping the previous value preselected.
+func (m *LambdaCreateModel) enterStep(step int) (*LambdaCreateModel, tea.Cmd) {
+	m.step = step
+	m.err = ""
+	m.interactive = true
+
+	switch step {
+	case LCCNameStep:
+		m.nameInput.SetValue(m.Name)
+		m.nameInput.CursorEnd()
+		m.Name = ""
+		return m, tea.Batch(m.nameInput.Cursor.SetMode(cursor.CursorBlink), m.nameInput.Focus())
+	case LCRuntimeSelectionStep:
+		for i, rt := range m.runtimes {
+			if m.Runtime != nil && rt.Id == m.Runtime.Id {
+				m.runtimeList.Select(i)
+			}
+		}
+		m.Runtime = nil
+	case LCTypeStep:
+		for i, t := range m.lambdaTypes {
+			if t.LambdaType == m.LambdaType {
+				m.typeList.Select(i)
+			}
+		}
+		m.LambdaType = ""
+	case LCPathStep:
+		m.pathInput.SetValue(m.Path)
+		m.pathInput.CursorEnd()
+		m.Path = ""
+		return m, tea.Batch(m.pathInput.Cursor.SetMode(cursor.CursorBlink), m.pathInput.Focus())
+	}
+
+	return m, nil
+}
+
+// nextStep returns the step following a completed input step, which is the
+// review step when the value was edited from there.
+func (m *LambdaCreateModel) nextStep() int {
+	if m.editing != nil {
+		m.editing = nil
+		return LCReviewStep
+	}
+
+	return m.step + 1
+}
+
+func (m *LambdaCreateModel) updateReview() {
+	m.review.Fields = []review.Field{
+		{Label: "Name", Value: m.Name},
+		{Label: "Runtime", Value: fmt.Sprintf("%s (%s)", m.Runtime.Name, m.Runtime.Id)},
+		{Label: "Lambda type", Value: m.LambdaType},
+		{Label: "Sources", Value: m.Path},
+	}
+}
+
+// done finishes the wizard: it quits the program, or hands the result over
+// to the outer wizard when embedded.
+func (m LambdaCreateModel) done() tea.Cmd {
+	if !m.Embedded {
+		return tea.Quit
+	}
+
+	resp := m.resp
+	return func() tea.Msg {
+		return LambdaCreateDoneMsg{Resp: resp}
+	}
+}
+
 func (m *LambdaCreateModel) incStep(cmds ...tea.Cmd) (*LambdaCreateModel, tea.Cmd) {
 	if m.step == LCInitStep {
 		m.step++
-		return m.incStep(m.nameInput.Cursor.SetMode(cursor.CursorBlink), m.nameInput.Focus())
+
+		cmds = append(cmds, m.RuntimeLister.List(), m.loadingSpinner.Tick)
+		if m.LambdaLister != nil {
+			cmds = append(cmds, m.LambdaLister.List())
+		}
+
+		return m.incStep(cmds...)
 	}
 
-	if m.step == LCCNameStep && m.Name != "" {
+	if m.step == LCRuntimesLoadingStep && m.runtimesLoaded && (m.LambdaLister == nil || m.lambdasLoaded) {
 		m.step++
-		m.nameInput.Blur()
-		m.static = fmt.Sprintf("%s\nName: %s", m.static, m.Name)
 
-		return m.incStep(m.RuntimeLister.List(), m.loadingSpinner.Tick)
+		cmds = append(cmds, m.runtimeList.SetItems(m.runtimeItems()))
+		// A name given upfront is checked like a typed one, and asked for
+		// again if it's taken
+		if m.Name != "" {
+			if err := m.validateName(m.Name); err != "" {
+				m.nameInput.SetValue(m.Name)
+				m.err = err
+				m.Name = ""
+			}
+		}
+		if m.Name == "" {
+			m.interactive = true
+			cmds = append(cmds, m.nameInput.Cursor.SetMode(cursor.CursorBlink), m.nameInput.Focus())
+		}
+
+		return m.incStep(cmds...)
 	}
 
-	if m.step == LCRuntimesLoadingStep && (m.Runtime != nil || m.runtimesLoaded) {
-		m.step++
+	if m.step == LCCNameStep && m.Name != "" {
+		m.step = m.nextStep()
+		m.nameInput.Blur()
 
-		target := []list.Item{}
-		for _, lambda := range m.runtimes {
-			target = append(target, runtimeItem{lambda})
+		if m.step == LCRuntimeSelectionStep && m.Runtime == nil {
+			m.interactive = true
 		}
 
-		return m.incStep(m.runtimeList.SetItems(target))
+		return m.incStep(cmds...)
 	}
 
 	if m.step == LCRuntimeSelectionStep && m.Runtime != nil {
-		m.step++
-		m.static = fmt.Sprintf("%s\nRuntime: %s", m.static, m.Runtime.Name)
+		m.step = m.nextStep()
 
-		return m.incStep()
+		if m.step == LCTypeStep && m.LambdaType == "" {
+			m.interactive = true
+		}
+
+		return m.incStep(cmds...)
 	}
 
 	if m.step == LCTypeStep && m.LambdaType != "" {
+		m.step = m.nextStep()
+
+		if m.step == LCPathStep && m.Path == "" {
+			m.interactive = true
+			cmds = append(cmds, m.pathInput.Cursor.SetMode(cursor.CursorBlink), m.pathInput.Focus())
+		}
+
+		return m.incStep(cmds...)
+	}
+
+	if m.step == LCPathStep && m.Path != "" {
+		m.step = m.nextStep()
+		m.pathInput.Blur()
+		m.updateReview()
+		m.review.Reset()
+
+		return m.incStep(cmds...)
+	}
+
+	if m.step == LCReviewStep {
+		m.updateReview()
+	}
+
+	if m.step == LCReviewStep && (m.confirmed || !m.interactive) {
 		m.step++
-		m.static = fmt.Sprintf("%s\nLambda type: %s", m.static, m.LambdaType)
 
-		return m.incStep(m.LambdaCreator.Create(m.Name, m.Runtime.Id, m.LambdaType, m.Path), m.loadingSpinner.Tick)
+		return m.incStep(append(cmds, m.LambdaCreator.Create(m.Name, m.Runtime.Id, m.LambdaType, m.Path), m.loadingSpinner.Tick)...)
 	}
 
 	if m.step == LCLoadingStep && m.resp != nil {
 		m.step++
 		if m.resp.Err != nil {
-			m.static = fmt.Sprintf("%s\n\nFailed to create lambda: %s", m.static, m.resp.Err)
+			m.output = fmt.Sprintf("\n\nFailed to create lambda: %s\n", m.resp.Err)
 		} else {
 			j, _ := json.MarshalIndent(m.resp.Lambda, "", "  ")
-			m.static = fmt.Sprintf("%s\n\n%s", m.static, j)
+			m.output = fmt.Sprintf("\n\n%s\n", j)
 		}
 
-		return m.incStep(tea.Quit)
+		return m.incStep(m.done())
 	}
 
 	return m, tea.Batch(cmds...)
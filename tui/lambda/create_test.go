@@ -0,0 +1,124 @@
+package lambda
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+func newLambdaCreateDriver(t *testing.T, m *LambdaCreateModel) *tuitest.Driver {
+	d := tuitest.New(t, InitLambdaCreateModel(m))
+	if !d.Quit() {
+		d.Send(tea.WindowSizeMsg{Width: 80, Height: 20})
+	}
+
+	return d
+}
+
+func TestLambdaCreateSuccess(t *testing.T) {
+	d := newLambdaCreateDriver(t, &LambdaCreateModel{
+		LambdaCreator: fakeLambdaCreator{},
+		RuntimeLister: fakeRuntimeLister{runtimes: testRuntimes},
+		LambdaLister:  fakeLambdaLister{lambdas: testLambdas},
+	})
+	d.Type("greeter")
+	d.Key(tea.KeyEnter)
+	d.Key(tea.KeyDown)
+	d.Key(tea.KeyEnter)
+	d.Key(tea.KeyEnter)
+	d.Type("./greeter")
+	d.Key(tea.KeyEnter)
+	d.Key(tea.KeyEnter)
+
+	if !d.Quit() {
+		t.Error("model did not quit once the lambda was created")
+	}
+	if l := d.Model().(LambdaCreateModel).GetLambda(); l == nil || l.Name != "greeter" || l.Runtime != "runtime-2" {
+		t.Errorf("created lambda = %+v", l)
+	}
+	d.Golden()
+}
+
+func TestLambdaCreateRejectsExistingName(t *testing.T) {
+	d := newLambdaCreateDriver(t, &LambdaCreateModel{
+		LambdaCreator: fakeLambdaCreator{},
+		RuntimeLister: fakeRuntimeLister{runtimes: testRuntimes},
+		LambdaLister:  fakeLambdaLister{lambdas: testLambdas},
+	})
+	d.Type("hello")
+	d.Key(tea.KeyEnter)
+
+	d.Golden()
+}
+
+func TestLambdaCreateAsksAgainForExistingName(t *testing.T) {
+	d := newLambdaCreateDriver(t, &LambdaCreateModel{
+		Name:          "hello",
+		LambdaType:    "ENDPOINT",
+		Path:          "./hello",
+		Runtime:       &testRuntimes[0],
+		LambdaCreator: fakeLambdaCreator{},
+		RuntimeLister: fakeRuntimeLister{runtimes: testRuntimes},
+		LambdaLister:  fakeLambdaLister{lambdas: testLambdas},
+	})
+	if d.Quit() {
+		t.Fatal("model created a lambda with an existing name")
+	}
+	d.Golden()
+
+	d.Type("2")
+	d.Key(tea.KeyEnter)
+	d.Key(tea.KeyEnter)
+
+	if !d.Quit() {
+		t.Error("model did not quit once the lambda was created")
+	}
+	if l := d.Model().(LambdaCreateModel).GetLambda(); l == nil || l.Name != "hello2" {
+		t.Errorf("created lambda = %+v", l)
+	}
+}
+
+func TestLambdaCreateEmptyRuntimes(t *testing.T) {
+	d := newLambdaCreateDriver(t, &LambdaCreateModel{
+		Name:          "greeter",
+		LambdaCreator: fakeLambdaCreator{},
+		RuntimeLister: fakeRuntimeLister{},
+	})
+
+	d.Golden()
+}
+
+func TestLambdaCreateError(t *testing.T) {
+	d := newLambdaCreateDriver(t, &LambdaCreateModel{
+		Name:          "greeter",
+		LambdaType:    "ENDPOINT",
+		Path:          "./greeter",
+		Runtime:       &testRuntimes[0],
+		LambdaCreator: fakeLambdaCreator{err: errors.New("upload failed")},
+		RuntimeLister: fakeRuntimeLister{runtimes: testRuntimes},
+	})
+
+	if !d.Quit() {
+		t.Error("model did not quit on error")
+	}
+	d.Golden()
+}
+
+func TestLambdaCreateCancel(t *testing.T) {
+	d := newLambdaCreateDriver(t, &LambdaCreateModel{
+		LambdaCreator: fakeLambdaCreator{},
+		RuntimeLister: fakeRuntimeLister{runtimes: testRuntimes},
+	})
+	d.Type("greeter")
+	d.Key(tea.KeyEsc)
+
+	if !d.Quit() {
+		t.Error("model did not quit on esc")
+	}
+	if d.Model().(LambdaCreateModel).GetLambda() != nil {
+		t.Error("cancelled wizard created a lambda")
+	}
+	d.Golden()
+}
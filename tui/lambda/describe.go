@@ -0,0 +1,131 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/tui/runtime"
+)
+
+type LambdaDescribeModel struct {
+	LambdaID  string
+	Describer LambdaDescriber
+	// Deployment is the local record of how the lambda has been deployed,
+	// nil when it was not deployed from this machine
+	Deployment *history.Deployment
+
+	resp *LambdaDescribeResponse
+
+	loadingSpinner spinner.Model
+}
+
+func InitLambdaDescribeModel(m *LambdaDescribeModel) *LambdaDescribeModel {
+	m.loadingSpinner = spinner.New()
+
+	m.loadingSpinner.Spinner = spinner.Dot
+
+	return m
+}
+
+func (m LambdaDescribeModel) Init() tea.Cmd {
+	return tea.Batch(m.Describer.Describe(m.LambdaID), m.loadingSpinner.Tick)
+}
+
+func (m LambdaDescribeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		}
+	case LambdaDescribeResponseMsg:
+		m.resp = msg.Resp
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.loadingSpinner, cmd = m.loadingSpinner.Update(msg)
+	return m, cmd
+}
+
+func (m LambdaDescribeModel) View() string {
+	if m.resp == nil {
+		return fmt.Sprintf("%s Loading lambda...", m.loadingSpinner.View())
+	}
+
+	if m.resp.Err != nil {
+		return fmt.Sprintf("Failed to load lambda: %s\n", m.resp.Err)
+	}
+
+	l := m.resp.Lambda
+	rt := l.Runtime
+	if m.resp.Runtime != nil {
+		rt = fmt.Sprintf("%s (%s)", m.resp.Runtime.Name, m.resp.Runtime.Id)
+	}
+
+	lines := []string{
+		fmt.Sprintf("Lambda: %s (%s)", l.Name, l.Id),
+		fmt.Sprintf("Runtime: %s", rt),
+		fmt.Sprintf("Type: %s", l.LambdaType),
+		fmt.Sprintf("State: %s", l.Docker.Status),
+		fmt.Sprintf("Created: %s", runtime.FormatTimestamp(l.CreatedAt)),
+	}
+
+	if len(m.resp.Endpoints) == 0 {
+		lines = append(lines, "Endpoints: none")
+	} else {
+		lines = append(lines, "Endpoints:")
+		for _, e := range m.resp.Endpoints {
+			lines = append(lines, fmt.Sprintf("  %s %s (%s)", e.Name, e.Path, e.Id))
+		}
+	}
+
+	d := m.Deployment
+	if d == nil {
+		lines = append(lines, "Deployment: not deployed from this machine")
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	lines = append(lines,
+		"Deployment:",
+		fmt.Sprintf("  Source: %s", d.Source),
+		fmt.Sprintf("  Deployed: %s", d.DeployedAt.Local().Format(time.DateTime)),
+	)
+	if d.Digest != "" {
+		lines = append(lines, fmt.Sprintf("  Digest: %s", d.Digest))
+	}
+
+	if d.Git == nil {
+		lines = append(lines, "  Git: not a git repository")
+	} else {
+		branch := d.Git.Branch
+		if branch == "" {
+			branch = "detached HEAD"
+		}
+		state := "clean"
+		if d.Git.Dirty {
+			state = "dirty"
+		}
+
+		lines = append(lines,
+			fmt.Sprintf("  Commit: %s", d.Git.Commit),
+			fmt.Sprintf("  Branch: %s", branch),
+			fmt.Sprintf("  Working tree: %s", state),
+			fmt.Sprintf("  Author: %s", d.Git.Author),
+		)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (m LambdaDescribeModel) GetErr() error {
+	if m.resp == nil {
+		return fmt.Errorf("lambda %s was not loaded", m.LambdaID)
+	}
+
+	return m.resp.Err
+}
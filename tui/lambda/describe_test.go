@@ -0,0 +1,51 @@
+package lambda
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/tui/tuitest"
+	"github.com/onpremless/opcli/vcs"
+)
+
+func TestLambdaDescribeWithDeployment(t *testing.T) {
+	d := tuitest.New(t, InitLambdaDescribeModel(&LambdaDescribeModel{
+		LambdaID:  "lambda-3",
+		Describer: fakeLambdaDescriber{endpoints: testEndpoints},
+		Deployment: &history.Deployment{
+			LambdaID:   "lambda-3",
+			Source:     "/src/hello",
+			Digest:     "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+			DeployedAt: time.Date(2024, 3, 1, 12, 5, 0, 0, time.UTC),
+			Git: &vcs.Info{
+				Commit: "0123456789abcdef0123456789abcdef01234567",
+				Author: "Jane Doe <jane@example.com>",
+			},
+		},
+	}))
+
+	d.Golden()
+}
+
+func TestLambdaDescribeWithoutDeployment(t *testing.T) {
+	d := tuitest.New(t, InitLambdaDescribeModel(&LambdaDescribeModel{
+		LambdaID:  "lambda-3",
+		Describer: fakeLambdaDescriber{},
+	}))
+
+	d.Golden()
+}
+
+func TestLambdaDescribeError(t *testing.T) {
+	d := tuitest.New(t, InitLambdaDescribeModel(&LambdaDescribeModel{
+		LambdaID:  "lambda-9",
+		Describer: fakeLambdaDescriber{err: errors.New("404 Not Found")},
+	}))
+
+	if err := d.Model().(LambdaDescribeModel).GetErr(); err == nil {
+		t.Error("GetErr() = nil on error")
+	}
+	d.Golden()
+}
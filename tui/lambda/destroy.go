@@ -2,9 +2,21 @@ package lambda
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	api "github.com/onpremless/go-client"
+)
+
+const (
+	LDInitStep       = 0
+	LDLoadingStep    = iota
+	LDConfirmStep    = iota
+	LDCascadeStep    = iota
+	LDDestroyingStep = iota
+	LDDoneStep       = iota
 )
 
 type LambdaDestroyResponseMsg struct {
@@ -15,16 +27,69 @@ type LambdaDestroyResponse struct {
 	Err error
 }
 
+type LambdaDescribeResponseMsg struct {
+	Resp *LambdaDescribeResponse
+}
+
+// LambdaDescribeResponse is a lambda along with the resources it is linked
+// to: the runtime it is built from and the endpoints routing to it.
+type LambdaDescribeResponse struct {
+	Lambda    *api.Lambda
+	Runtime   *api.Runtime
+	Endpoints []api.Endpoint
+	Err       error
+}
+
+type EndpointsDeleteResponseMsg struct {
+	Resp *EndpointsDeleteResponse
+}
+
+type EndpointsDeleteResponse struct {
+	Err error
+}
+
 type LambdaDestroyer interface {
 	Destroy(id string) tea.Cmd
 }
 
+type LambdaDescriber interface {
+	Describe(id string) tea.Cmd
+}
+
+type EndpointsDeleter interface {
+	DeleteEndpoints(ids []string) tea.Cmd
+}
+
+type lambdaDestroyStartMsg struct{}
+
+func lambdaDestroyStart() tea.Msg {
+	return lambdaDestroyStartMsg{}
+}
+
+var warnStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+
 type LambdaDestroyModel struct {
-	LambdaID  string
-	Destroyer LambdaDestroyer
+	LambdaID         string
+	Destroyer        LambdaDestroyer
+	Describer        LambdaDescriber
+	EndpointsDeleter EndpointsDeleter
+	// Yes skips the confirmation step
+	Yes bool
+	// DryRun only reports what would be destroyed
+	DryRun bool
+	// Cascade deletes endpoints routing to the lambda before destroying it
+	Cascade bool
+
+	static string
 
-	resp *LambdaDestroyResponse
+	desc          *LambdaDescribeResponse
+	cascadeResp   *EndpointsDeleteResponse
+	resp          *LambdaDestroyResponse
+	confirmed     bool
+	cancelled     bool
+	cascadeIssued bool
 
+	step           int
 	loadingSpinner spinner.Model
 }
 
@@ -37,19 +102,98 @@ func InitLambdaDestroyModel(m *LambdaDestroyModel) *LambdaDestroyModel {
 }
 
 func (m LambdaDestroyModel) Init() tea.Cmd {
-	return tea.Batch(m.Destroyer.Destroy(m.LambdaID), m.loadingSpinner.Tick)
+	return lambdaDestroyStart
 }
 
 func (m LambdaDestroyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case lambdaDestroyStartMsg:
+		return m.incStep()
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			return m, tea.Quit
 		}
+	}
+
+	switch m.step {
+	case LDLoadingStep:
+		return m.handleLDLoadingStep(msg)
+	case LDConfirmStep:
+		return m.handleLDConfirmStep(msg)
+	case LDCascadeStep:
+		return m.handleLDCascadeStep(msg)
+	case LDDestroyingStep:
+		return m.handleLDDestroyingStep(msg)
+	}
+	return m, nil
+}
+
+func (m LambdaDestroyModel) View() string {
+	static := m.static
+	if static != "" {
+		static += "\n"
+	}
+
+	active := ""
+	if m.step == LDLoadingStep {
+		active = fmt.Sprintf("%s Loading lambda...", m.loadingSpinner.View())
+	} else if m.step == LDConfirmStep {
+		active = warnStyle.Render("Destroy this lambda? [y/N]")
+	} else if m.step == LDCascadeStep {
+		active = fmt.Sprintf("%s Deleting endpoints...", m.loadingSpinner.View())
+	} else if m.step == LDDestroyingStep {
+		active = fmt.Sprintf("%s Destroying lambda...", m.loadingSpinner.View())
+	}
+
+	return fmt.Sprintf("%s%s", static, active)
+}
+
+func (m LambdaDestroyModel) handleLDLoadingStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case LambdaDescribeResponseMsg:
+		m.desc = msg.Resp
+		return m.incStep()
+	}
+
+	var cmd tea.Cmd
+	m.loadingSpinner, cmd = m.loadingSpinner.Update(msg)
+	return m, cmd
+}
+
+func (m LambdaDestroyModel) handleLDConfirmStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "Y":
+			m.confirmed = true
+			return m.incStep()
+		case "n", "N", "enter", "esc", "q":
+			m.cancelled = true
+			return m.incStep()
+		}
+	}
+
+	return m, nil
+}
+
+func (m LambdaDestroyModel) handleLDCascadeStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case EndpointsDeleteResponseMsg:
+		m.cascadeResp = msg.Resp
+		return m.incStep()
+	}
+
+	var cmd tea.Cmd
+	m.loadingSpinner, cmd = m.loadingSpinner.Update(msg)
+	return m, cmd
+}
+
+func (m LambdaDestroyModel) handleLDDestroyingStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
 	case LambdaDestroyResponseMsg:
 		m.resp = msg.Resp
-		return m, tea.Quit
+		return m.incStep()
 	}
 
 	var cmd tea.Cmd
@@ -57,14 +201,140 @@ func (m LambdaDestroyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-func (m LambdaDestroyModel) View() string {
-	if m.resp == nil {
-		return fmt.Sprintf("%s Destroying lambda...", m.loadingSpinner.View())
+func (m *LambdaDestroyModel) incStep(cmds ...tea.Cmd) (*LambdaDestroyModel, tea.Cmd) {
+	if m.step == LDInitStep {
+		m.step++
+		return m.incStep(m.Describer.Describe(m.LambdaID), m.loadingSpinner.Tick)
+	}
+
+	if m.step == LDLoadingStep && m.desc != nil {
+		m.step++
+		if m.desc.Err != nil {
+			m.static = fmt.Sprintf("Failed to load lambda: %s\n", m.desc.Err)
+			m.step = LDDoneStep
+			return m.incStep(tea.Quit)
+		}
+
+		m.static = m.summary()
+		if m.DryRun {
+			m.static += "\nDry run, nothing has been destroyed\n"
+			m.step = LDDoneStep
+			return m.incStep(tea.Quit)
+		}
+
+		if m.Yes {
+			m.confirmed = true
+		}
+
+		return m.incStep()
 	}
 
-	if m.resp.Err != nil {
-		return fmt.Sprintf("Failed to destroy lambda: %s\n", m.resp.Err)
+	if m.step == LDConfirmStep && m.cancelled {
+		m.step = LDDoneStep
+		m.static += "\nCancelled\n"
+
+		return m.incStep(tea.Quit)
+	}
+
+	if m.step == LDConfirmStep && m.confirmed {
+		m.step++
+
+		ids := m.cascadeIDs()
+		if len(ids) == 0 {
+			return m.incStep()
+		}
+
+		m.cascadeIssued = true
+		return m.incStep(m.EndpointsDeleter.DeleteEndpoints(ids), m.loadingSpinner.Tick)
+	}
+
+	if m.step == LDCascadeStep && (!m.cascadeIssued || m.cascadeResp != nil) {
+		m.step++
+		if m.cascadeResp != nil && m.cascadeResp.Err != nil {
+			m.static += fmt.Sprintf("\nFailed to delete endpoints: %s\n", m.cascadeResp.Err)
+			m.step = LDDoneStep
+			return m.incStep(tea.Quit)
+		}
+		if m.cascadeIssued {
+			m.static += fmt.Sprintf("\nDeleted %d endpoint(s)", len(m.desc.Endpoints))
+		}
+
+		return m.incStep(m.Destroyer.Destroy(m.LambdaID), m.loadingSpinner.Tick)
+	}
+
+	if m.step == LDDestroyingStep && m.resp != nil {
+		m.step++
+		if m.resp.Err != nil {
+			m.static += fmt.Sprintf("\nFailed to destroy lambda: %s\n", m.resp.Err)
+		} else {
+			m.static += "\nLambda has been destroyed\n"
+		}
+
+		return m.incStep(tea.Quit)
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m LambdaDestroyModel) summary() string {
+	l := m.desc.Lambda
+	rt := l.Runtime
+	if m.desc.Runtime != nil {
+		rt = fmt.Sprintf("%s (%s)", m.desc.Runtime.Name, m.desc.Runtime.Id)
+	}
+
+	lines := []string{
+		fmt.Sprintf("Lambda: %s (%s)", l.Name, l.Id),
+		fmt.Sprintf("Runtime: %s", rt),
+		fmt.Sprintf("Type: %s", l.LambdaType),
+	}
+
+	if len(m.desc.Endpoints) == 0 {
+		lines = append(lines, "Endpoints: none")
 	} else {
-		return "Lambda has been destroyed\n"
+		lines = append(lines, "Endpoints:")
+		for _, e := range m.desc.Endpoints {
+			lines = append(lines, fmt.Sprintf("  %s %s (%s)", e.Name, e.Path, e.Id))
+		}
+
+		verb := "will"
+		if m.DryRun {
+			verb = "would"
+		}
+
+		if m.Cascade {
+			lines = append(lines, warnStyle.Render(fmt.Sprintf("%d endpoint(s) %s be deleted as well", len(m.desc.Endpoints), verb)))
+		} else {
+			lines = append(lines, warnStyle.Render(fmt.Sprintf("%d endpoint(s) %s be left pointing to a destroyed lambda, use --cascade to delete them", len(m.desc.Endpoints), verb)))
+		}
 	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (m LambdaDestroyModel) cascadeIDs() []string {
+	if !m.Cascade {
+		return nil
+	}
+
+	ids := make([]string, len(m.desc.Endpoints))
+	for i, e := range m.desc.Endpoints {
+		ids[i] = e.Id
+	}
+
+	return ids
+}
+
+func (m LambdaDestroyModel) GetErr() error {
+	if m.desc != nil && m.desc.Err != nil {
+		return m.desc.Err
+	}
+	if m.cascadeResp != nil && m.cascadeResp.Err != nil {
+		return m.cascadeResp.Err
+	}
+	if m.resp != nil {
+		return m.resp.Err
+	}
+
+	return nil
 }
@@ -0,0 +1,81 @@
+package lambda
+
+import (
+	"errors"
+	"testing"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+var testEndpoints = []api.Endpoint{
+	{Id: "endpoint-6", Name: "hello", Path: "/hello", Lambda: "lambda-3"},
+}
+
+func newLambdaDestroyModel(m *LambdaDestroyModel) *LambdaDestroyModel {
+	m.LambdaID = "lambda-3"
+	if m.Describer == nil {
+		m.Describer = fakeLambdaDescriber{endpoints: testEndpoints}
+	}
+	if m.Destroyer == nil {
+		m.Destroyer = fakeLambdaDestroyer{}
+	}
+	m.EndpointsDeleter = fakeEndpointsDeleter{}
+
+	return InitLambdaDestroyModel(m)
+}
+
+func TestLambdaDestroyConfirmed(t *testing.T) {
+	d := tuitest.New(t, newLambdaDestroyModel(&LambdaDestroyModel{Cascade: true}))
+	d.Type("y")
+
+	if err := d.Model().(LambdaDestroyModel).GetErr(); err != nil {
+		t.Errorf("GetErr() = %v", err)
+	}
+	d.Golden()
+}
+
+func TestLambdaDestroyNoEndpoints(t *testing.T) {
+	d := tuitest.New(t, newLambdaDestroyModel(&LambdaDestroyModel{
+		Describer: fakeLambdaDescriber{},
+		Yes:       true,
+	}))
+
+	d.Golden()
+}
+
+func TestLambdaDestroyCancelled(t *testing.T) {
+	d := tuitest.New(t, newLambdaDestroyModel(&LambdaDestroyModel{}))
+	d.Type("n")
+
+	if !d.Quit() {
+		t.Error("model did not quit once cancelled")
+	}
+	d.Golden()
+}
+
+func TestLambdaDestroyDryRun(t *testing.T) {
+	d := tuitest.New(t, newLambdaDestroyModel(&LambdaDestroyModel{DryRun: true}))
+
+	d.Golden()
+}
+
+func TestLambdaDestroyError(t *testing.T) {
+	d := tuitest.New(t, newLambdaDestroyModel(&LambdaDestroyModel{
+		Destroyer: fakeLambdaDestroyer{err: errors.New("task timed out")},
+		Yes:       true,
+	}))
+
+	if err := d.Model().(LambdaDestroyModel).GetErr(); err == nil {
+		t.Error("GetErr() = nil on error")
+	}
+	d.Golden()
+}
+
+func TestLambdaDestroyNotFound(t *testing.T) {
+	d := tuitest.New(t, newLambdaDestroyModel(&LambdaDestroyModel{
+		Describer: fakeLambdaDescriber{err: errors.New("404 Not Found")},
+	}))
+
+	d.Golden()
+}
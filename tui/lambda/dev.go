@@ -0,0 +1,284 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	api "github.com/onpremless/go-client"
+)
+
+const (
+	DefaultDevPollInterval = 500 * time.Millisecond
+	DefaultDevDebounce     = time.Second
+)
+
+const devLogSize = 8
+
+type LambdaDevDigestMsg struct {
+	Digest string
+	Err    error
+}
+
+type LambdaDevDeployResponse struct {
+	Lambda   *api.Lambda
+	Endpoint *api.Endpoint
+	// Destroyed are the IDs of the previous lambdas destroyed
+	Destroyed []string
+	Err       error
+}
+
+type LambdaDevDeployResponseMsg struct {
+	Resp *LambdaDevDeployResponse
+}
+
+type SourceDigester interface {
+	Digest(path string) tea.Cmd
+}
+
+// LambdaDevDeployer deploys the sources as a new lambda replacing the
+// previous ones, which are destroyed once the new lambda is routed.
+type LambdaDevDeployer interface {
+	Deploy(path string, previous []*api.Lambda) tea.Cmd
+}
+
+type lambdaDevPollMsg struct{}
+
+var statusStyle = lipgloss.NewStyle().Reverse(true).Padding(0, 1)
+
+// LambdaDevModel watches the sources directory and redeploys the lambda
+// once the sources stop changing for the debounce period.
+type LambdaDevModel struct {
+	Path         string
+	Digester     SourceDigester
+	Deployer     LambdaDevDeployer
+	PollInterval time.Duration
+	Debounce     time.Duration
+
+	lambda   *api.Lambda
+	endpoint *api.Endpoint
+	// stale are the lambdas replaced by a deploy but not destroyed yet,
+	// such as when routing to the new lambda failed
+	stale          []*api.Lambda
+	deployedDigest string
+	failedDigest   string
+	lastDeploy     time.Time
+	lastDuration   time.Duration
+	lastErr        error
+
+	pending       string
+	pendingSince  time.Time
+	deploying     string
+	deployStarted time.Time
+	replacing     []*api.Lambda
+
+	log []string
+	// now is the clock, set by tests
+	now func() time.Time
+
+	width          int
+	loadingSpinner spinner.Model
+}
+
+func InitLambdaDevModel(m *LambdaDevModel) *LambdaDevModel {
+	if m.PollInterval == 0 {
+		m.PollInterval = DefaultDevPollInterval
+	}
+	if m.Debounce == 0 {
+		m.Debounce = DefaultDevDebounce
+	}
+	if m.now == nil {
+		m.now = time.Now
+	}
+
+	m.loadingSpinner = spinner.New()
+
+	m.loadingSpinner.Spinner = spinner.Dot
+
+	return m
+}
+
+func (m LambdaDevModel) Init() tea.Cmd {
+	return tea.Batch(m.Digester.Digest(m.Path), m.loadingSpinner.Tick)
+}
+
+func (m LambdaDevModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case lambdaDevPollMsg:
+		return m, m.Digester.Digest(m.Path)
+	case LambdaDevDigestMsg:
+		return m.handleDigest(msg)
+	case LambdaDevDeployResponseMsg:
+		return m.handleDeployed(msg.Resp)
+	}
+
+	var cmd tea.Cmd
+	m.loadingSpinner, cmd = m.loadingSpinner.Update(msg)
+	return m, cmd
+}
+
+func (m LambdaDevModel) poll() tea.Cmd {
+	return tea.Tick(m.PollInterval, func(time.Time) tea.Msg {
+		return lambdaDevPollMsg{}
+	})
+}
+
+func (m LambdaDevModel) handleDigest(msg LambdaDevDigestMsg) (tea.Model, tea.Cmd) {
+	poll := m.poll()
+
+	if msg.Err != nil {
+		m.lastErr = fmt.Errorf("failed to read sources: %w", msg.Err)
+		return m, poll
+	}
+
+	if msg.Digest == m.deployedDigest || msg.Digest == m.failedDigest || msg.Digest == m.deploying {
+		m.pending = ""
+		return m, poll
+	}
+
+	if msg.Digest != m.pending {
+		if m.deployedDigest != "" {
+			m.addLog("change detected")
+		}
+		m.pending = msg.Digest
+		m.pendingSince = m.now()
+		return m, poll
+	}
+
+	if m.deploying != "" || m.now().Sub(m.pendingSince) < m.Debounce {
+		return m, poll
+	}
+
+	m.deploying = m.pending
+	m.deployStarted = m.now()
+	m.pending = ""
+	m.addLog(fmt.Sprintf("deploying %s", shortDigest(m.deploying)))
+
+	m.replacing = append([]*api.Lambda{}, m.stale...)
+	if m.lambda != nil {
+		m.replacing = append(m.replacing, m.lambda)
+	}
+
+	deploy := m.Deployer.Deploy(m.Path, m.replacing)
+	return m, tea.Batch(poll, deploy, m.loadingSpinner.Tick)
+}
+
+func (m LambdaDevModel) handleDeployed(resp *LambdaDevDeployResponse) (tea.Model, tea.Cmd) {
+	digest := m.deploying
+	m.deploying = ""
+	m.lastDuration = m.now().Sub(m.deployStarted)
+
+	if resp.Lambda == nil {
+		m.lastErr = resp.Err
+		m.failedDigest = digest
+		m.addLog(fmt.Sprintf("deploy of %s failed: %s", shortDigest(digest), resp.Err))
+		return m, nil
+	}
+
+	// Err may still be set when the lambda got deployed but a follow-up
+	// step, such as destroying the previous lambda, failed. Lambdas left
+	// behind are destroyed by the next deploy.
+	destroyed := map[string]bool{}
+	for _, id := range resp.Destroyed {
+		destroyed[id] = true
+	}
+	m.stale = nil
+	for _, l := range m.replacing {
+		if !destroyed[l.Id] {
+			m.stale = append(m.stale, l)
+		}
+	}
+
+	m.lambda = resp.Lambda
+	m.endpoint = resp.Endpoint
+	m.deployedDigest = digest
+	m.failedDigest = ""
+	m.lastDeploy = m.now()
+	m.lastErr = resp.Err
+
+	line := fmt.Sprintf("deployed %s as %s in %s", shortDigest(digest), m.lambda.Id, m.lastDuration.Round(100*time.Millisecond))
+	if m.endpoint != nil {
+		line += fmt.Sprintf(", routed from %s", m.endpoint.Path)
+	}
+	m.addLog(line)
+
+	return m, nil
+}
+
+func (m *LambdaDevModel) addLog(line string) {
+	m.log = append(m.log, fmt.Sprintf("%s %s", m.now().Format("15:04:05"), line))
+	if len(m.log) > devLogSize {
+		m.log = m.log[len(m.log)-devLogSize:]
+	}
+}
+
+func (m LambdaDevModel) View() string {
+	lines := []string{fmt.Sprintf("Watching %s", m.Path), ""}
+	lines = append(lines, m.log...)
+	if len(m.log) > 0 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, statusStyle.Render(m.status()))
+	if m.lastErr != nil {
+		lines = append(lines, errorStyle.Render(m.lastErr.Error()))
+	}
+	lines = append(lines, "", helpStyle.Render("q quit, the last deployment is left running"))
+
+	return docStyle.Render(strings.Join(lines, "\n"))
+}
+
+func (m LambdaDevModel) status() string {
+	parts := []string{}
+	if m.deploying != "" {
+		parts = append(parts, fmt.Sprintf("%s deploying %s", m.loadingSpinner.View(), shortDigest(m.deploying)))
+	} else if m.pending != "" {
+		parts = append(parts, "waiting for changes to settle")
+	}
+
+	if m.lastDeploy.IsZero() {
+		parts = append(parts, "not deployed yet")
+	} else {
+		parts = append(parts,
+			fmt.Sprintf("last deploy %s", m.lastDeploy.Format("15:04:05")),
+			fmt.Sprintf("digest %s", shortDigest(m.deployedDigest)),
+			fmt.Sprintf("lambda %s", m.lambda.Id),
+		)
+		if m.endpoint != nil {
+			parts = append(parts, fmt.Sprintf("endpoint %s", m.endpoint.Path))
+		}
+	}
+
+	if len(m.stale) > 0 {
+		parts = append(parts, fmt.Sprintf("%d replaced lambda(s) left to destroy", len(m.stale)))
+	}
+
+	if m.lastErr != nil {
+		parts = append(parts, "error")
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+
+	return digest
+}
+
+func (m LambdaDevModel) GetLambda() *api.Lambda {
+	return m.lambda
+}
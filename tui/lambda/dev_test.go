@@ -0,0 +1,117 @@
+package lambda
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+func newLambdaDevDriver(t *testing.T, deployer LambdaDevDeployer) *tuitest.Driver {
+	return tuitest.New(t, InitLambdaDevModel(&LambdaDevModel{
+		Path:     "./hello",
+		Digester: fakeSourceDigester{digest: "sha256:aaaa"},
+		Deployer: deployer,
+		Debounce: time.Nanosecond,
+	}))
+}
+
+// change reports the digest twice, the second time deploys once the
+// debounce period passed.
+func change(d *tuitest.Driver, digest string) {
+	d.Send(LambdaDevDigestMsg{Digest: digest})
+	d.Send(LambdaDevDigestMsg{Digest: digest})
+}
+
+func TestLambdaDevKeepsReplacedLambdasUntilDestroyed(t *testing.T) {
+	deployer := &fakeDevDeployer{resps: []*LambdaDevDeployResponse{
+		{Lambda: &api.Lambda{Id: "lambda-1"}},
+		{Lambda: &api.Lambda{Id: "lambda-2"}, Err: errors.New("failed to route endpoint")},
+		{Lambda: &api.Lambda{Id: "lambda-3"}, Destroyed: []string{"lambda-2"}, Err: errors.New("failed to destroy previous lambda lambda-1")},
+		{Lambda: &api.Lambda{Id: "lambda-4"}, Destroyed: []string{"lambda-1", "lambda-3"}},
+	}}
+	d := newLambdaDevDriver(t, deployer)
+
+	change(d, "sha256:aaaa")
+	change(d, "sha256:bbbb")
+	change(d, "sha256:cccc")
+	change(d, "sha256:dddd")
+
+	want := [][]string{{}, {"lambda-1"}, {"lambda-1", "lambda-2"}, {"lambda-1", "lambda-3"}}
+	if !reflect.DeepEqual(deployer.previous, want) {
+		t.Errorf("previous lambdas = %v, want %v", deployer.previous, want)
+	}
+
+	m := d.Model().(LambdaDevModel)
+	if m.GetLambda().Id != "lambda-4" || len(m.stale) != 0 || m.lastErr != nil {
+		t.Errorf("lambda = %s, stale = %v, err = %v", m.GetLambda().Id, m.stale, m.lastErr)
+	}
+}
+
+func TestLambdaDevDebounce(t *testing.T) {
+	clock := newFakeClock(0)
+	deployer := &fakeDevDeployer{resps: []*LambdaDevDeployResponse{
+		{Lambda: &api.Lambda{Id: "lambda-1"}, Endpoint: &api.Endpoint{Path: "/hello"}},
+		{Lambda: &api.Lambda{Id: "lambda-2"}, Endpoint: &api.Endpoint{Path: "/hello"}, Destroyed: []string{"lambda-1"}},
+	}}
+	d := tuitest.New(t, InitLambdaDevModel(&LambdaDevModel{
+		Path:     "./hello",
+		Digester: fakeSourceDigester{digest: "sha256:aaaa"},
+		Deployer: deployer,
+		now:      clock.now,
+	}))
+
+	clock.advance(500 * time.Millisecond)
+	d.Send(LambdaDevDigestMsg{Digest: "sha256:aaaa"})
+	clock.advance(600 * time.Millisecond)
+	d.Send(LambdaDevDigestMsg{Digest: "sha256:aaaa"})
+
+	// A digest changing again restarts the debounce period
+	d.Send(LambdaDevDigestMsg{Digest: "sha256:bbbb"})
+	clock.advance(time.Second)
+	d.Send(LambdaDevDigestMsg{Digest: "sha256:cccc"})
+	clock.advance(500 * time.Millisecond)
+	d.Send(LambdaDevDigestMsg{Digest: "sha256:cccc"})
+	clock.advance(500 * time.Millisecond)
+	d.Send(LambdaDevDigestMsg{Digest: "sha256:cccc"})
+
+	if len(deployer.previous) != 2 {
+		t.Errorf("deployed %d time(s), want 2", len(deployer.previous))
+	}
+	d.Golden()
+}
+
+func TestLambdaDevFailedDigest(t *testing.T) {
+	clock := newFakeClock(0)
+	deployer := &fakeDevDeployer{resps: []*LambdaDevDeployResponse{
+		{Err: errors.New("build failed: exit status 2")},
+		{Lambda: &api.Lambda{Id: "lambda-1"}},
+	}}
+	d := tuitest.New(t, InitLambdaDevModel(&LambdaDevModel{
+		Path:     "./hello",
+		Digester: fakeSourceDigester{digest: "sha256:aaaa"},
+		Deployer: deployer,
+		now:      clock.now,
+	}))
+
+	clock.advance(time.Second)
+	d.Send(LambdaDevDigestMsg{Digest: "sha256:aaaa"})
+
+	// The failed sources are not deployed again until they change
+	clock.advance(time.Second)
+	d.Send(LambdaDevDigestMsg{Digest: "sha256:aaaa"})
+	d.Send(LambdaDevDigestMsg{Err: errors.New("open ./hello: permission denied")})
+
+	d.Send(LambdaDevDigestMsg{Digest: "sha256:bbbb"})
+	clock.advance(time.Second)
+	d.Send(LambdaDevDigestMsg{Digest: "sha256:bbbb"})
+	d.Type("q")
+
+	if len(deployer.previous) != 2 {
+		t.Errorf("deployed %d time(s), want 2", len(deployer.previous))
+	}
+	d.Golden()
+}
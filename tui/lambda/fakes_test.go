@@ -0,0 +1,187 @@
+package lambda
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/tui/runtime"
+)
+
+var createdAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+
+var testRuntimes = []api.Runtime{
+	{Id: "runtime-1", Name: "go", CreatedAt: createdAt, UpdatedAt: createdAt},
+	{Id: "runtime-2", Name: "python", CreatedAt: createdAt, UpdatedAt: createdAt},
+}
+
+var testLambdas = []api.Lambda{
+	{Id: "lambda-3", Name: "hello", Runtime: "runtime-1", LambdaType: "ENDPOINT", Docker: api.Docker{Status: "RUNNING"}, CreatedAt: createdAt, UpdatedAt: createdAt},
+	{Id: "lambda-4", Name: "worker", Runtime: "runtime-2", LambdaType: "INTERNAL", Docker: api.Docker{Status: "CREATED"}, CreatedAt: createdAt, UpdatedAt: createdAt},
+}
+
+type fakeLambdaLister struct {
+	lambdas []api.Lambda
+	err     error
+}
+
+func (f fakeLambdaLister) List() tea.Cmd {
+	return func() tea.Msg {
+		return LambdaListResponseMsg{Resp: &LambdaListResponse{Lambdas: f.lambdas, Err: f.err}}
+	}
+}
+
+type fakeRuntimeLister struct {
+	runtimes []api.Runtime
+	err      error
+}
+
+func (f fakeRuntimeLister) List() tea.Cmd {
+	return func() tea.Msg {
+		return runtime.RuntimeListResponseMsg{Resp: &runtime.RuntimeListResponse{Runtimes: f.runtimes, Err: f.err}}
+	}
+}
+
+type fakeLambdaCreator struct {
+	err error
+}
+
+func (f fakeLambdaCreator) Create(name string, runtime string, lambdaType string, path string) tea.Cmd {
+	return func() tea.Msg {
+		if f.err != nil {
+			return LambdaCreateResponseMsg{Resp: &LambdaCreateResponse{Err: f.err}}
+		}
+
+		return LambdaCreateResponseMsg{Resp: &LambdaCreateResponse{Lambda: &api.Lambda{
+			Id:         "lambda-5",
+			Name:       name,
+			Runtime:    runtime,
+			LambdaType: lambdaType,
+			Docker:     api.Docker{Status: "CREATED"},
+			CreatedAt:  createdAt,
+			UpdatedAt:  createdAt,
+		}}}
+	}
+}
+
+type fakeLambdaStarter struct {
+	err error
+}
+
+func (f fakeLambdaStarter) Start(id string) tea.Cmd {
+	return func() tea.Msg {
+		if f.err != nil {
+			return LambdaStartResponseMsg{Resp: &LambdaStartResponse{Err: f.err}}
+		}
+
+		l := testLambdas[1]
+		l.Docker.Status = "RUNNING"
+		return LambdaStartResponseMsg{Resp: &LambdaStartResponse{Lambda: &l}}
+	}
+}
+
+type fakeLambdaDescriber struct {
+	endpoints []api.Endpoint
+	err       error
+}
+
+func (f fakeLambdaDescriber) Describe(id string) tea.Cmd {
+	return func() tea.Msg {
+		if f.err != nil {
+			return LambdaDescribeResponseMsg{Resp: &LambdaDescribeResponse{Err: f.err}}
+		}
+
+		return LambdaDescribeResponseMsg{Resp: &LambdaDescribeResponse{
+			Lambda:    &testLambdas[0],
+			Runtime:   &testRuntimes[0],
+			Endpoints: f.endpoints,
+		}}
+	}
+}
+
+type fakeLambdaDestroyer struct {
+	err error
+}
+
+func (f fakeLambdaDestroyer) Destroy(id string) tea.Cmd {
+	return func() tea.Msg {
+		return LambdaDestroyResponseMsg{Resp: &LambdaDestroyResponse{Err: f.err}}
+	}
+}
+
+type fakeEndpointsDeleter struct {
+	err error
+}
+
+func (f fakeEndpointsDeleter) DeleteEndpoints(ids []string) tea.Cmd {
+	return func() tea.Msg {
+		return EndpointsDeleteResponseMsg{Resp: &EndpointsDeleteResponse{Err: f.err}}
+	}
+}
+
+type fakeSourceDigester struct {
+	digest string
+	err    error
+}
+
+func (f fakeSourceDigester) Digest(path string) tea.Cmd {
+	return func() tea.Msg {
+		return LambdaDevDigestMsg{Digest: f.digest, Err: f.err}
+	}
+}
+
+// fakeDevDeployer answers deploys with resps in turn, recording the IDs of
+// the previous lambdas of each.
+type fakeDevDeployer struct {
+	resps    []*LambdaDevDeployResponse
+	previous [][]string
+}
+
+func (f *fakeDevDeployer) Deploy(path string, previous []*api.Lambda) tea.Cmd {
+	ids := []string{}
+	for _, l := range previous {
+		ids = append(ids, l.Id)
+	}
+	f.previous = append(f.previous, ids)
+
+	resp := f.resps[0]
+	f.resps = f.resps[1:]
+	return func() tea.Msg {
+		return LambdaDevDeployResponseMsg{Resp: resp}
+	}
+}
+
+// fakeClock starts at createdAt and moves step forward on every reading.
+type fakeClock struct {
+	t    time.Time
+	step time.Duration
+}
+
+func newFakeClock(step time.Duration) *fakeClock {
+	return &fakeClock{t: time.UnixMilli(createdAt), step: step}
+}
+
+func (c *fakeClock) now() time.Time {
+	t := c.t
+	c.t = c.t.Add(c.step)
+	return t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+type fakeHookRunner struct {
+	lines []string
+	err   error
+}
+
+func (f fakeHookRunner) RunHook(stage string, command string) <-chan tea.Msg {
+	msgs := make(chan tea.Msg, len(f.lines)+1)
+	for _, line := range f.lines {
+		msgs <- LambdaHookOutputMsg{Line: line}
+	}
+	msgs <- LambdaHookDoneMsg{Err: f.err}
+
+	return msgs
+}
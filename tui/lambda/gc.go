@@ -0,0 +1,308 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/onpremless/opcli/gc"
+	"github.com/onpremless/opcli/tui/runtime"
+)
+
+const (
+	GCInitStep       = 0
+	GCSelectStep     = iota
+	GCConfirmStep    = iota
+	GCDestroyingStep = iota
+	GCDoneStep       = iota
+)
+
+var (
+	gcCursorStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
+	gcFaintStyle  = lipgloss.NewStyle().Faint(true)
+)
+
+type gcStartMsg struct{}
+
+func gcStart() tea.Msg {
+	return gcStartMsg{}
+}
+
+// LambdaGCModel lets the user select which of the garbage collection
+// candidates to destroy, then destroys them one after the other. Candidates
+// the API cannot remove are listed but cannot be selected.
+type LambdaGCModel struct {
+	Candidates []gc.Candidate
+	Destroyer  LambdaDestroyer
+	// Yes selects every removable candidate and skips the confirmation
+	Yes bool
+
+	static string
+
+	selected  map[int]bool
+	cursor    int
+	current   int
+	destroyed int
+	failed    int
+	err       error
+
+	step           int
+	loadingSpinner spinner.Model
+}
+
+func InitLambdaGCModel(m *LambdaGCModel) *LambdaGCModel {
+	m.selected = map[int]bool{}
+	m.current = -1
+
+	m.loadingSpinner = spinner.New()
+
+	m.loadingSpinner.Spinner = spinner.Dot
+
+	return m
+}
+
+func (m LambdaGCModel) Init() tea.Cmd {
+	return gcStart
+}
+
+func (m LambdaGCModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case gcStartMsg:
+		return m.incStep()
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		}
+	}
+
+	switch m.step {
+	case GCSelectStep:
+		return m.handleGCSelectStep(msg)
+	case GCConfirmStep:
+		return m.handleGCConfirmStep(msg)
+	case GCDestroyingStep:
+		return m.handleGCDestroyingStep(msg)
+	}
+	return m, nil
+}
+
+func (m LambdaGCModel) View() string {
+	static := m.static
+	if static != "" {
+		static += "\n"
+	}
+
+	active := ""
+	if m.step == GCSelectStep {
+		active = m.selectView()
+	} else if m.step == GCConfirmStep {
+		active = warnStyle.Render(fmt.Sprintf("Destroy %d lambda(s)? [y/N]", len(m.selection())))
+	} else if m.step == GCDestroyingStep && m.current >= 0 {
+		c := m.Candidates[m.current]
+		active = fmt.Sprintf("%s Destroying lambda %s (%s)...", m.loadingSpinner.View(), c.Name, c.ID)
+	}
+
+	return fmt.Sprintf("%s%s", static, active)
+}
+
+func (m LambdaGCModel) selectView() string {
+	rows := make([][]string, len(m.Candidates))
+	widths := make([]int, 5)
+	for i, c := range m.Candidates {
+		rows[i] = []string{c.Kind, c.Name, c.ID, runtime.FormatTimestamp(c.CreatedAt), c.Reason}
+		for j, cell := range rows[i] {
+			widths[j] = max(widths[j], len(cell))
+		}
+	}
+
+	lines := []string{
+		"Select the resources to garbage collect",
+		gcFaintStyle.Render("space: toggle, a: toggle all, enter: destroy selected, q: cancel"),
+		"",
+	}
+	for i, c := range m.Candidates {
+		cells := make([]string, len(rows[i]))
+		for j, cell := range rows[i] {
+			cells[j] = fmt.Sprintf("%-*s", widths[j], cell)
+		}
+
+		box := "[ ]"
+		if !c.Removable {
+			box = " - "
+		} else if m.selected[i] {
+			box = "[x]"
+		}
+
+		line := fmt.Sprintf("%s %s  %s", box, strings.Join(cells, "  "), c.Details)
+		switch {
+		case i == m.cursor:
+			line = gcCursorStyle.Render("> " + line)
+		case !c.Removable:
+			line = gcFaintStyle.Render("  " + line)
+		default:
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (m LambdaGCModel) handleGCSelectStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			m.cursor = max(m.cursor-1, 0)
+		case "down", "j":
+			m.cursor = min(m.cursor+1, len(m.Candidates)-1)
+		case " ", "x":
+			if m.Candidates[m.cursor].Removable {
+				m.selected[m.cursor] = !m.selected[m.cursor]
+			}
+		case "a":
+			all := len(m.selection()) < m.removable()
+			for i, c := range m.Candidates {
+				m.selected[i] = all && c.Removable
+			}
+		case "enter":
+			return m.incStep()
+		case "q", "esc":
+			m.static = "Cancelled\n"
+			m.step = GCDoneStep
+			return m.incStep(tea.Quit)
+		}
+	}
+
+	return m, nil
+}
+
+func (m LambdaGCModel) handleGCConfirmStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "Y":
+			return m.incStep()
+		case "n", "N", "enter", "esc", "q":
+			m.step = GCSelectStep
+			return m, nil
+		}
+	}
+
+	return m, nil
+}
+
+func (m LambdaGCModel) handleGCDestroyingStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case LambdaDestroyResponseMsg:
+		c := m.Candidates[m.current]
+		if msg.Resp.Err != nil {
+			m.failed++
+			if m.err == nil {
+				m.err = msg.Resp.Err
+			}
+			m.static += fmt.Sprintf("Failed to destroy lambda %s (%s): %s\n", c.Name, c.ID, msg.Resp.Err)
+		} else {
+			m.destroyed++
+			m.static += fmt.Sprintf("Destroyed lambda %s (%s)\n", c.Name, c.ID)
+		}
+
+		return m.incStep()
+	}
+
+	var cmd tea.Cmd
+	m.loadingSpinner, cmd = m.loadingSpinner.Update(msg)
+	return m, cmd
+}
+
+func (m *LambdaGCModel) incStep(cmds ...tea.Cmd) (*LambdaGCModel, tea.Cmd) {
+	if m.step == GCInitStep {
+		m.step++
+		if m.removable() == 0 {
+			m.static = m.unremovableSummary()
+			m.step = GCDoneStep
+			return m.incStep(tea.Quit)
+		}
+		if m.Yes {
+			for i, c := range m.Candidates {
+				m.selected[i] = c.Removable
+			}
+			m.step = GCDestroyingStep
+			return m.incStep(m.loadingSpinner.Tick)
+		}
+
+		return m, nil
+	}
+
+	if m.step == GCSelectStep {
+		if len(m.selection()) == 0 {
+			m.static = "Nothing selected\n"
+			m.step = GCDoneStep
+			return m.incStep(tea.Quit)
+		}
+
+		m.step++
+		return m, nil
+	}
+
+	if m.step == GCConfirmStep {
+		m.step++
+		return m.incStep(m.loadingSpinner.Tick)
+	}
+
+	if m.step == GCDestroyingStep {
+		selection := m.selection()
+		for _, i := range selection {
+			if i > m.current {
+				m.current = i
+				return m, tea.Batch(append(cmds, m.Destroyer.Destroy(m.Candidates[i].ID))...)
+			}
+		}
+
+		m.step++
+		m.static += fmt.Sprintf("\nDestroyed %d of %d lambda(s)\n", m.destroyed, len(selection))
+		m.static += m.unremovableSummary()
+		return m.incStep(tea.Quit)
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// selection returns the indexes of the selected candidates, in order.
+func (m LambdaGCModel) selection() []int {
+	res := []int{}
+	for i := range m.Candidates {
+		if m.selected[i] {
+			res = append(res, i)
+		}
+	}
+
+	return res
+}
+
+func (m LambdaGCModel) removable() int {
+	n := 0
+	for _, c := range m.Candidates {
+		if c.Removable {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (m LambdaGCModel) unremovableSummary() string {
+	n := len(m.Candidates) - m.removable()
+	if n == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%d unused runtime(s) left, the API cannot delete runtimes\n", n)
+}
+
+func (m LambdaGCModel) GetErr() error {
+	return m.err
+}
@@ -0,0 +1,78 @@
+package lambda
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/gc"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+var testCandidates = []gc.Candidate{
+	{Kind: gc.KindLambda, ID: "lambda-3", Name: "hello", CreatedAt: 1700000000, Reason: gc.ReasonDuplicate, Details: "superseded by lambda-5", Removable: true},
+	{Kind: gc.KindLambda, ID: "lambda-4", Name: "orphan", CreatedAt: 1700000100, Reason: gc.ReasonOrphaned, Details: "no endpoint routes to it", Removable: true},
+	{Kind: gc.KindRuntime, ID: "runtime-1", Name: "node", CreatedAt: 1700000200, Reason: gc.ReasonUnused, Details: "no lambda uses it, the API cannot delete runtimes"},
+}
+
+func newLambdaGCModel(m *LambdaGCModel) *LambdaGCModel {
+	if m.Candidates == nil {
+		m.Candidates = testCandidates
+	}
+	if m.Destroyer == nil {
+		m.Destroyer = fakeLambdaDestroyer{}
+	}
+
+	return InitLambdaGCModel(m)
+}
+
+func TestLambdaGCSelected(t *testing.T) {
+	d := tuitest.New(t, newLambdaGCModel(&LambdaGCModel{}))
+	d.Key(tea.KeyDown).Type(" ").Key(tea.KeyDown).Type(" ").Key(tea.KeyEnter).Type("y")
+
+	if !d.Quit() {
+		t.Error("model did not quit once done")
+	}
+	if err := d.Model().(*LambdaGCModel).GetErr(); err != nil {
+		t.Errorf("GetErr() = %v", err)
+	}
+	d.Golden()
+}
+
+func TestLambdaGCYes(t *testing.T) {
+	d := tuitest.New(t, newLambdaGCModel(&LambdaGCModel{
+		Destroyer: fakeLambdaDestroyer{err: errors.New("task failed")},
+		Yes:       true,
+	}))
+
+	if err := d.Model().(*LambdaGCModel).GetErr(); err == nil {
+		t.Error("GetErr() = nil, want the destroy error")
+	}
+	d.Golden()
+}
+
+func TestLambdaGCNothingSelected(t *testing.T) {
+	d := tuitest.New(t, newLambdaGCModel(&LambdaGCModel{}))
+	d.Type("a").Type("a").Key(tea.KeyEnter)
+
+	if !d.Quit() {
+		t.Error("model did not quit without selection")
+	}
+	d.Golden()
+}
+
+func TestLambdaGCDeclined(t *testing.T) {
+	d := tuitest.New(t, newLambdaGCModel(&LambdaGCModel{}))
+	d.Type("a").Key(tea.KeyEnter).Type("n").Type("q")
+
+	d.Golden()
+}
+
+func TestLambdaGCOnlyRuntimes(t *testing.T) {
+	d := tuitest.New(t, newLambdaGCModel(&LambdaGCModel{Candidates: testCandidates[2:]}))
+
+	if !d.Quit() {
+		t.Error("model did not quit without removable candidates")
+	}
+	d.Golden()
+}
@@ -0,0 +1,116 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const hookOutputLines = 20
+
+type LambdaHookOutputMsg struct {
+	Line string
+}
+
+type LambdaHookDoneMsg struct {
+	Err error
+}
+
+// HookRunner starts the hook command and returns the channel its output
+// lines (LambdaHookOutputMsg) and final LambdaHookDoneMsg are delivered on.
+type HookRunner interface {
+	RunHook(stage string, command string) <-chan tea.Msg
+}
+
+type lambdaHookStartMsg struct{}
+
+func lambdaHookStart() tea.Msg {
+	return lambdaHookStartMsg{}
+}
+
+// LambdaHookModel runs a single hook, streaming the tail of its output.
+type LambdaHookModel struct {
+	Stage   string
+	Command string
+	Runner  HookRunner
+
+	msgs  <-chan tea.Msg
+	lines []string
+	done  *LambdaHookDoneMsg
+
+	loadingSpinner spinner.Model
+}
+
+func InitLambdaHookModel(m *LambdaHookModel) *LambdaHookModel {
+	m.loadingSpinner = spinner.New()
+
+	m.loadingSpinner.Spinner = spinner.Dot
+
+	return m
+}
+
+func (m LambdaHookModel) Init() tea.Cmd {
+	return lambdaHookStart
+}
+
+func (m LambdaHookModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case lambdaHookStartMsg:
+		m.msgs = m.Runner.RunHook(m.Stage, m.Command)
+		return m, tea.Batch(m.next(), m.loadingSpinner.Tick)
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		}
+	case LambdaHookOutputMsg:
+		m.lines = append(m.lines, msg.Line)
+		return m, m.next()
+	case LambdaHookDoneMsg:
+		m.done = &msg
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.loadingSpinner, cmd = m.loadingSpinner.Update(msg)
+	return m, cmd
+}
+
+func (m LambdaHookModel) next() tea.Cmd {
+	msgs := m.msgs
+	return func() tea.Msg {
+		return <-msgs
+	}
+}
+
+func (m LambdaHookModel) View() string {
+	var header string
+	if m.done == nil {
+		header = fmt.Sprintf("%s Running %s hook: %s", m.loadingSpinner.View(), m.Stage, m.Command)
+	} else if m.done.Err != nil {
+		header = errorStyle.Render(m.done.Err.Error())
+	} else {
+		header = fmt.Sprintf("%s hook finished: %s", m.Stage, m.Command)
+	}
+
+	lines := m.lines
+	if m.done == nil && len(lines) > hookOutputLines {
+		lines = lines[len(lines)-hookOutputLines:]
+	}
+
+	if len(lines) == 0 {
+		return header + "\n"
+	}
+
+	return fmt.Sprintf("%s\n%s\n", header, helpStyle.Render(strings.Join(lines, "\n")))
+}
+
+func (m LambdaHookModel) GetErr() error {
+	if m.done == nil {
+		return fmt.Errorf("%s hook was interrupted", m.Stage)
+	}
+
+	return m.done.Err
+}
@@ -0,0 +1,44 @@
+package lambda
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+func TestLambdaHookSuccess(t *testing.T) {
+	d := tuitest.New(t, InitLambdaHookModel(&LambdaHookModel{
+		Stage:   "pre-build",
+		Command: "npm run build",
+		Runner:  fakeHookRunner{lines: []string{"> build", "compiled 3 files"}},
+	}))
+
+	if err := d.Model().(LambdaHookModel).GetErr(); err != nil {
+		t.Errorf("GetErr() = %v", err)
+	}
+	d.Golden()
+}
+
+func TestLambdaHookFailure(t *testing.T) {
+	d := tuitest.New(t, InitLambdaHookModel(&LambdaHookModel{
+		Stage:   "post-deploy",
+		Command: "./smoke.sh",
+		Runner:  fakeHookRunner{lines: []string{"GET /hello", "got 502"}, err: errors.New("post-deploy hook failed: exit status 1")},
+	}))
+
+	if err := d.Model().(LambdaHookModel).GetErr(); err == nil {
+		t.Error("GetErr() = nil on failure")
+	}
+	d.Golden()
+}
+
+func TestLambdaHookInterrupted(t *testing.T) {
+	var m tea.Model = *InitLambdaHookModel(&LambdaHookModel{Stage: "pre-build", Command: "sleep 60"})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+
+	if err := m.(LambdaHookModel).GetErr(); err == nil || err.Error() != "pre-build hook was interrupted" {
+		t.Errorf("GetErr() = %v", err)
+	}
+}
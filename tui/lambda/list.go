@@ -1,14 +1,39 @@
 package lambda
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/tui/listing"
+	"github.com/onpremless/opcli/tui/runtime"
 )
 
+var LambdaColumns = []listing.Column{
+	{Key: "id", Title: "ID"},
+	{Key: "name", Title: "Name"},
+	{Key: "type", Title: "Type"},
+	{Key: "runtime", Title: "Runtime"},
+	{Key: "state", Title: "State"},
+}
+
+// LambdaGitColumn shows the git state the lambda has been deployed from,
+// as recorded in the local deployment history.
+var LambdaGitColumn = listing.Column{Key: "git", Title: "Git"}
+
+// LambdaListColumns returns the columns of the lambda list, with the git
+// column when showGit is set.
+func LambdaListColumns(showGit bool) []listing.Column {
+	if !showGit {
+		return LambdaColumns
+	}
+
+	return append(append([]listing.Column{}, LambdaColumns...), LambdaGitColumn)
+}
+
 type LambdaListResponseMsg struct {
 	Resp *LambdaListResponse
 }
@@ -23,10 +48,23 @@ type LambdaLister interface {
 }
 
 type LambdaListModel struct {
-	Lister LambdaLister
-
-	resp *LambdaListResponse
-
+	Lister        LambdaLister
+	RuntimeLister runtime.RuntimeLister
+	Filter        listing.Filter
+	Sort          listing.Sort
+	// Plain renders a non-interactive table and quits, used when stdout is
+	// not a terminal
+	Plain bool
+	// ShowGit adds a column with the git state each lambda has been
+	// deployed from, looked up in Deployments by lambda ID
+	ShowGit     bool
+	Deployments map[string]history.Deployment
+
+	resp         *LambdaListResponse
+	runtimesResp *runtime.RuntimeListResponse
+	rows         []table.Row
+
+	table          listing.TableModel
 	loadingSpinner spinner.Model
 }
 
@@ -39,7 +77,7 @@ func InitLambdaListModel(m *LambdaListModel) *LambdaListModel {
 }
 
 func (m LambdaListModel) Init() tea.Cmd {
-	return tea.Batch(m.Lister.List(), m.loadingSpinner.Tick)
+	return tea.Batch(m.Lister.List(), m.RuntimeLister.List(), m.loadingSpinner.Tick)
 }
 
 func (m LambdaListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -51,7 +89,16 @@ func (m LambdaListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case LambdaListResponseMsg:
 		m.resp = msg.Resp
-		return m, tea.Quit
+		return m.loaded()
+	case runtime.RuntimeListResponseMsg:
+		m.runtimesResp = msg.Resp
+		return m.loaded()
+	}
+
+	if m.rows != nil {
+		var cmd tea.Cmd
+		m.table, cmd = m.table.Update(msg)
+		return m, cmd
 	}
 
 	var cmd tea.Cmd
@@ -59,15 +106,78 @@ func (m LambdaListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m LambdaListModel) loaded() (tea.Model, tea.Cmd) {
+	if m.resp == nil || m.runtimesResp == nil {
+		return m, nil
+	}
+
+	if m.resp.Err != nil || m.runtimesResp.Err != nil {
+		return m, tea.Quit
+	}
+
+	m.rows = LambdaRows(m.resp.Lambdas, m.runtimesResp.Runtimes)
+	if m.ShowGit {
+		for i, row := range m.rows {
+			m.rows[i] = append(row, gitCell(m.Deployments, row[0]))
+		}
+	}
+	if m.Plain {
+		return m, tea.Quit
+	}
+
+	m.table = listing.NewTableModel(LambdaListColumns(m.ShowGit), m.rows, m.Filter, m.Sort)
+	return m, nil
+}
+
 func (m LambdaListModel) View() string {
-	if m.resp == nil {
+	if m.resp == nil || m.runtimesResp == nil {
 		return fmt.Sprintf("%s Query lambdas...", m.loadingSpinner.View())
 	}
 
 	if m.resp.Err != nil {
-		return fmt.Sprintf("Failed to create lambda: %s\n", m.resp.Err)
-	} else {
-		j, _ := json.MarshalIndent(m.resp.Lambdas, "", "  ")
-		return string(j) + "\n"
+		return fmt.Sprintf("Failed to list lambdas: %s\n", m.resp.Err)
+	}
+	if m.runtimesResp.Err != nil {
+		return fmt.Sprintf("Failed to list runtimes: %s\n", m.runtimesResp.Err)
+	}
+
+	if m.Plain {
+		cols := LambdaListColumns(m.ShowGit)
+		return listing.Plain(cols, listing.Apply(cols, m.rows, m.Filter, m.Sort))
+	}
+
+	return m.table.View()
+}
+
+// LambdaRows converts lambdas into table rows, resolving runtime IDs into
+// runtime names where possible.
+func LambdaRows(lambdas []api.Lambda, runtimes []api.Runtime) []table.Row {
+	names := map[string]string{}
+	for _, rt := range runtimes {
+		names[rt.Id] = rt.Name
 	}
+
+	rows := make([]table.Row, len(lambdas))
+	for i, l := range lambdas {
+		rt, ok := names[l.Runtime]
+		if !ok {
+			rt = l.Runtime
+		}
+
+		rows[i] = table.Row{l.Id, l.Name, l.LambdaType, rt, l.Docker.Status}
+	}
+
+	return rows
+}
+
+func gitCell(deployments map[string]history.Deployment, id string) string {
+	d, ok := deployments[id]
+	if !ok {
+		return "-"
+	}
+	if d.Git == nil {
+		return "not a git repository"
+	}
+
+	return d.Git.Short()
 }
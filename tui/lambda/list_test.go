@@ -0,0 +1,59 @@
+package lambda
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/history"
+	"github.com/onpremless/opcli/tui/tuitest"
+	"github.com/onpremless/opcli/vcs"
+)
+
+func TestLambdaListSuccess(t *testing.T) {
+	d := tuitest.New(t, InitLambdaListModel(&LambdaListModel{
+		Lister:        fakeLambdaLister{lambdas: testLambdas},
+		RuntimeLister: fakeRuntimeLister{runtimes: testRuntimes},
+	}))
+	d.Send(tea.WindowSizeMsg{Width: 80, Height: 12})
+	d.Key(tea.KeyDown)
+	d.Key(tea.KeyCtrlC)
+
+	d.Golden()
+}
+
+func TestLambdaListEmpty(t *testing.T) {
+	d := tuitest.New(t, InitLambdaListModel(&LambdaListModel{
+		Lister:        fakeLambdaLister{},
+		RuntimeLister: fakeRuntimeLister{runtimes: testRuntimes},
+		Plain:         true,
+	}))
+
+	d.Golden()
+}
+
+func TestLambdaListError(t *testing.T) {
+	d := tuitest.New(t, InitLambdaListModel(&LambdaListModel{
+		Lister:        fakeLambdaLister{err: errors.New("connection refused")},
+		RuntimeLister: fakeRuntimeLister{runtimes: testRuntimes},
+	}))
+
+	if !d.Quit() {
+		t.Error("model did not quit on error")
+	}
+	d.Golden()
+}
+
+func TestLambdaListShowGit(t *testing.T) {
+	d := tuitest.New(t, InitLambdaListModel(&LambdaListModel{
+		Lister:        fakeLambdaLister{lambdas: testLambdas},
+		RuntimeLister: fakeRuntimeLister{runtimes: testRuntimes},
+		Plain:         true,
+		ShowGit:       true,
+		Deployments: map[string]history.Deployment{
+			"lambda-3": {LambdaID: "lambda-3", Git: &vcs.Info{Commit: "0123456789abcdef", Branch: "main", Dirty: true}},
+		},
+	}))
+
+	d.Golden()
+}
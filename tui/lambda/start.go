@@ -72,3 +72,11 @@ func (m LambdaStartModel) View() string {
 		return string(j) + "\n"
 	}
 }
+
+func (m LambdaStartModel) GetErr() error {
+	if m.resp == nil {
+		return fmt.Errorf("lambda %s was not started", m.LambdaID)
+	}
+
+	return m.resp.Err
+}
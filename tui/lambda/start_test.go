@@ -0,0 +1,32 @@
+package lambda
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+func TestLambdaStartSuccess(t *testing.T) {
+	d := tuitest.New(t, InitLambdaStartModel(&LambdaStartModel{
+		LambdaID: "lambda-4",
+		Starter:  fakeLambdaStarter{},
+	}))
+
+	if err := d.Model().(LambdaStartModel).GetErr(); err != nil {
+		t.Errorf("GetErr() = %v", err)
+	}
+	d.Golden()
+}
+
+func TestLambdaStartError(t *testing.T) {
+	d := tuitest.New(t, InitLambdaStartModel(&LambdaStartModel{
+		LambdaID: "lambda-4",
+		Starter:  fakeLambdaStarter{err: errors.New("container exited with code 1")},
+	}))
+
+	if err := d.Model().(LambdaStartModel).GetErr(); err == nil {
+		t.Error("GetErr() = nil on error")
+	}
+	d.Golden()
+}
@@ -0,0 +1,170 @@
+package listing
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+const maxColumnWidth = 40
+
+type Column struct {
+	// Key is the name used to refer to the column in --sort-by and --filter
+	Key   string
+	Title string
+}
+
+// Filter maps column keys to case-insensitive substrings a row must contain.
+type Filter map[string]string
+
+// ParseFilter parses a comma separated list of key=value pairs, for example
+// "name=hello,type=ENDPOINT".
+func ParseFilter(spec string) (Filter, error) {
+	f := Filter{}
+	if spec == "" {
+		return f, nil
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid filter %q, expected key=value", pair)
+		}
+
+		f[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+
+	return f, nil
+}
+
+// Validate checks that every filter key names one of the columns.
+func (f Filter) Validate(cols []Column) error {
+	for key := range f {
+		if columnIndex(cols, key) < 0 {
+			return fmt.Errorf("unknown filter column %q, expected one of: %s", key, columnKeys(cols))
+		}
+	}
+
+	return nil
+}
+
+// Match reports whether row satisfies every condition of the filter.
+func (f Filter) Match(cols []Column, row table.Row) bool {
+	for key, value := range f {
+		i := columnIndex(cols, key)
+		if i < 0 || !strings.Contains(strings.ToLower(row[i]), strings.ToLower(value)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Sort describes ordering of the rows by a single column.
+type Sort struct {
+	Key  string
+	Desc bool
+}
+
+// ParseSort parses a column key optionally prefixed with "-" for descending
+// order.
+func ParseSort(cols []Column, spec string) (Sort, error) {
+	if spec == "" {
+		return Sort{}, nil
+	}
+
+	s := Sort{Key: strings.ToLower(strings.TrimPrefix(spec, "-")), Desc: strings.HasPrefix(spec, "-")}
+	if columnIndex(cols, s.Key) < 0 {
+		return Sort{}, fmt.Errorf("unknown sort column %q, expected one of: %s", s.Key, columnKeys(cols))
+	}
+
+	return s, nil
+}
+
+// Apply returns the rows matching filter ordered according to s.
+func Apply(cols []Column, rows []table.Row, filter Filter, s Sort) []table.Row {
+	res := []table.Row{}
+	for _, row := range rows {
+		if filter.Match(cols, row) {
+			res = append(res, row)
+		}
+	}
+
+	if i := columnIndex(cols, s.Key); i >= 0 {
+		sort.SliceStable(res, func(a, b int) bool {
+			if s.Desc {
+				return res[a][i] > res[b][i]
+			}
+			return res[a][i] < res[b][i]
+		})
+	}
+
+	return res
+}
+
+// Plain renders rows as a tab aligned table without any styling, suitable
+// for pipes and files.
+func Plain(cols []Column, rows []table.Row) string {
+	var b strings.Builder
+	WritePlain(&b, cols, rows)
+	return b.String()
+}
+
+func WritePlain(w io.Writer, cols []Column, rows []table.Row) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	titles := make([]string, len(cols))
+	for i, col := range cols {
+		titles[i] = strings.ToUpper(col.Title)
+	}
+	fmt.Fprintln(tw, strings.Join(titles, "\t"))
+
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	tw.Flush()
+}
+
+// widths sizes every column to fit its title and the widest cell.
+func widths(cols []Column, rows []table.Row) []int {
+	res := make([]int, len(cols))
+	for i, col := range cols {
+		res[i] = len(col.Title) + 2
+	}
+
+	for _, row := range rows {
+		for i, cell := range row {
+			res[i] = max(res[i], len(cell))
+		}
+	}
+
+	for i := range res {
+		res[i] = min(res[i], maxColumnWidth)
+	}
+
+	return res
+}
+
+func columnIndex(cols []Column, key string) int {
+	for i, col := range cols {
+		if col.Key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func columnKeys(cols []Column) string {
+	keys := make([]string, len(cols))
+	for i, col := range cols {
+		keys[i] = col.Key
+	}
+
+	return strings.Join(keys, ", ")
+}
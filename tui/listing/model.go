@@ -0,0 +1,148 @@
+package listing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	helpStyle = lipgloss.NewStyle().Faint(true)
+	help      = "↑/↓ move • s sort column • S reverse • / filter • q quit"
+)
+
+// TableModel is an interactive table with column sorting and free text
+// filtering on top of the filter given on the command line.
+type TableModel struct {
+	cols   []Column
+	rows   []table.Row
+	filter Filter
+	sort   Sort
+
+	query     string
+	filtering bool
+
+	table       table.Model
+	filterInput textinput.Model
+}
+
+func NewTableModel(cols []Column, rows []table.Row, filter Filter, s Sort) TableModel {
+	m := TableModel{
+		cols:   cols,
+		rows:   rows,
+		filter: filter,
+		sort:   s,
+	}
+
+	m.filterInput = textinput.New()
+	m.filterInput.Prompt = "/"
+	m.filterInput.Placeholder = "filter"
+	m.filterInput.CharLimit = 156
+
+	m.table = table.New(table.WithFocused(true), table.WithHeight(min(len(rows), 20)+1))
+	m.rebuild()
+
+	return m
+}
+
+func (m TableModel) Update(msg tea.Msg) (TableModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.table.SetHeight(max(min(len(m.rows)+1, msg.Height-4), 3))
+		return m, nil
+	case tea.KeyMsg:
+		if m.filtering {
+			switch msg.Type {
+			case tea.KeyEnter:
+				m.filtering = false
+				m.filterInput.Blur()
+				return m, nil
+			case tea.KeyEsc:
+				m.filtering = false
+				m.filterInput.Blur()
+				m.filterInput.SetValue("")
+				m.query = ""
+				m.rebuild()
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			m.filterInput, cmd = m.filterInput.Update(msg)
+			m.query = m.filterInput.Value()
+			m.rebuild()
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "q", "esc", "enter":
+			return m, tea.Quit
+		case "/":
+			m.filtering = true
+			return m, m.filterInput.Focus()
+		case "s":
+			i := (columnIndex(m.cols, m.sort.Key) + 1) % len(m.cols)
+			m.sort = Sort{Key: m.cols[i].Key}
+			m.rebuild()
+			return m, nil
+		case "S":
+			if m.sort.Key == "" {
+				m.sort.Key = m.cols[0].Key
+			}
+			m.sort.Desc = !m.sort.Desc
+			m.rebuild()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m TableModel) View() string {
+	footer := helpStyle.Render(help)
+	if m.filtering {
+		footer = m.filterInput.View()
+	} else if m.query != "" {
+		footer = fmt.Sprintf("%s\n%s", helpStyle.Render(fmt.Sprintf("filter: %q", m.query)), footer)
+	}
+
+	return fmt.Sprintf("%s\n%s\n", m.table.View(), footer)
+}
+
+func (m *TableModel) rebuild() {
+	rows := Apply(m.cols, m.rows, m.filter, m.sort)
+	if m.query != "" {
+		query := strings.ToLower(m.query)
+		matched := []table.Row{}
+		for _, row := range rows {
+			if strings.Contains(strings.ToLower(strings.Join(row, " ")), query) {
+				matched = append(matched, row)
+			}
+		}
+		rows = matched
+	}
+
+	w := widths(m.cols, m.rows)
+	cols := make([]table.Column, len(m.cols))
+	for i, col := range m.cols {
+		title := col.Title
+		if col.Key == m.sort.Key {
+			if m.sort.Desc {
+				title += " ▼"
+			} else {
+				title += " ▲"
+			}
+		}
+		cols[i] = table.Column{Title: title, Width: w[i]}
+	}
+
+	m.table.SetRows(nil)
+	m.table.SetColumns(cols)
+	m.table.SetRows(rows)
+	m.table.SetCursor(max(m.table.Cursor(), 0))
+}
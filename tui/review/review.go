@@ -0,0 +1,103 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	labelStyle    = lipgloss.NewStyle().Width(16)
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
+	warnStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+)
+
+type Field struct {
+	Label string
+	Value string
+}
+
+// Model is the final summary screen of a wizard. Every field can be chosen
+// for editing, the entry after the last field submits the wizard.
+type Model struct {
+	Title    string
+	Submit   string
+	Fields   []Field
+	Warnings []string
+
+	cursor int
+}
+
+func New(title string, submit string) Model {
+	return Model{Title: title, Submit: submit}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			m.cursor = max(m.cursor-1, 0)
+		case "down", "j", "tab":
+			m.cursor = min(m.cursor+1, len(m.Fields))
+		}
+	}
+
+	return m, nil
+}
+
+// Cursor returns the index of the selected field, or len(Fields) when the
+// submit entry is selected.
+func (m Model) Cursor() int {
+	return m.cursor
+}
+
+// Submitting reports whether the submit entry is selected.
+func (m Model) Submitting() bool {
+	return m.cursor == len(m.Fields)
+}
+
+// Reset moves the cursor to the submit entry.
+func (m *Model) Reset() {
+	m.cursor = len(m.Fields)
+}
+
+func (m Model) View() string {
+	var b strings.Builder
+	b.WriteString(m.Title)
+	b.WriteString("\n\n")
+
+	for i, f := range m.Fields {
+		line := fmt.Sprintf("%s%s", labelStyle.Render(f.Label+":"), f.Value)
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if m.Submitting() {
+		b.WriteString(selectedStyle.Render("> " + m.Submit))
+	} else {
+		b.WriteString("  " + m.Submit)
+	}
+	b.WriteString("\n")
+
+	for _, w := range m.Warnings {
+		b.WriteString("\n")
+		b.WriteString(warnStyle.Render(w))
+	}
+	if len(m.Warnings) > 0 {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑/↓ select • enter edit or submit • shift+tab/esc back"))
+
+	return b.String()
+}
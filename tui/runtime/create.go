@@ -14,8 +14,10 @@ import (
 
 const (
 	RCInitStep    = 0
+	RCPathStep    = iota
 	RCNameStep    = iota
 	RCLoadingStep = iota
+	RCDoneStep    = iota
 )
 
 type RuntimeCreateResponse struct {
@@ -27,6 +29,12 @@ type RuntimeCreateResponseMsg struct {
 	Resp *RuntimeCreateResponse
 }
 
+// RuntimeCreateDoneMsg is emitted instead of quitting by an embedded
+// RuntimeCreateModel. Resp is nil when the wizard was cancelled.
+type RuntimeCreateDoneMsg struct {
+	Resp *RuntimeCreateResponse
+}
+
 type runtimeCreateStartMsg struct{}
 
 func runtimeCreateStart() tea.Msg {
@@ -41,17 +49,25 @@ type RuntimeCreateModel struct {
 	Name    string
 	Path    string
 	Creator RuntimeCreator
+	// Embedded makes the wizard report its result with RuntimeCreateDoneMsg
+	// rather than quitting, so that it can run as a part of another wizard
+	Embedded bool
 
 	static string
 
 	resp *RuntimeCreateResponse
 
 	step           int
+	pathInput      textinput.Model
 	nameInput      textinput.Model
 	loadingSpinner spinner.Model
 }
 
 func InitRuntimeCreateModel(m *RuntimeCreateModel) *RuntimeCreateModel {
+	m.pathInput = textinput.New()
+	m.pathInput.CharLimit = 1024
+	m.pathInput.Placeholder = "Dockerfile or build context path"
+
 	m.nameInput = textinput.New()
 	m.nameInput.CharLimit = 156
 	m.nameInput.Placeholder = "Runtime name"
@@ -79,6 +95,8 @@ func (m RuntimeCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch m.step {
+	case RCPathStep:
+		return m.handleRCPathStep(msg)
 	case RCNameStep:
 		return m.handleRCNameStep(msg)
 	case RCLoadingStep:
@@ -94,7 +112,9 @@ func (m RuntimeCreateModel) View() string {
 	}
 
 	active := ""
-	if m.step == RCNameStep {
+	if m.step == RCPathStep {
+		active = m.pathInput.View()
+	} else if m.step == RCNameStep {
 		active = m.nameInput.View()
 	} else if m.step == RCLoadingStep {
 		active = fmt.Sprintf("%s Creating runtime...", m.loadingSpinner.View())
@@ -103,6 +123,23 @@ func (m RuntimeCreateModel) View() string {
 	return fmt.Sprintf("%s%s", static, active)
 }
 
+func (m RuntimeCreateModel) handleRCPathStep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.Path = m.pathInput.Value()
+			return m.incStep()
+		case tea.KeyEsc:
+			return m, m.done()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	return m, cmd
+}
+
 func (m RuntimeCreateModel) handleRCNameStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -111,7 +148,7 @@ func (m RuntimeCreateModel) handleRCNameStep(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Name = m.nameInput.Value()
 			return m.incStep()
 		case tea.KeyEsc:
-			return m, tea.Quit
+			return m, m.done()
 		}
 	}
 
@@ -132,9 +169,28 @@ func (m RuntimeCreateModel) handleRCLoadingStep(msg tea.Msg) (tea.Model, tea.Cmd
 	return m, cmd
 }
 
+// done finishes the wizard: it quits the program, or hands the result over
+// to the outer wizard when embedded.
+func (m RuntimeCreateModel) done() tea.Cmd {
+	if !m.Embedded {
+		return tea.Quit
+	}
+
+	resp := m.resp
+	return func() tea.Msg {
+		return RuntimeCreateDoneMsg{Resp: resp}
+	}
+}
+
 func (m *RuntimeCreateModel) incStep(cmds ...tea.Cmd) (*RuntimeCreateModel, tea.Cmd) {
 	if m.step == RCInitStep {
 		m.step++
+		return m.incStep(m.pathInput.Cursor.SetMode(cursor.CursorBlink), m.pathInput.Focus())
+	}
+
+	if m.step == RCPathStep && m.Path != "" {
+		m.step++
+		m.pathInput.Blur()
 		m.static = fmt.Sprintf("Path: %s", m.Path)
 		return m.incStep(m.nameInput.Cursor.SetMode(cursor.CursorBlink), m.nameInput.Focus())
 	}
@@ -156,8 +212,16 @@ func (m *RuntimeCreateModel) incStep(cmds ...tea.Cmd) (*RuntimeCreateModel, tea.
 			m.static = fmt.Sprintf("%s\n\n%s", m.static, j)
 		}
 
-		return m.incStep(tea.Quit)
+		return m.incStep(m.done())
 	}
 
 	return m, tea.Batch(cmds...)
 }
+
+func (m RuntimeCreateModel) GetRuntime() *api.Runtime {
+	if m.resp == nil {
+		return nil
+	}
+
+	return m.resp.Runtime
+}
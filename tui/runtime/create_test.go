@@ -0,0 +1,66 @@
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+func TestRuntimeCreateSuccess(t *testing.T) {
+	d := tuitest.New(t, InitRuntimeCreateModel(&RuntimeCreateModel{
+		Creator: fakeRuntimeCreator{},
+	}))
+	d.Type("./Dockerfile")
+	d.Key(tea.KeyEnter)
+	d.Type("go")
+	d.Key(tea.KeyEnter)
+
+	if !d.Quit() {
+		t.Error("model did not quit once the runtime was created")
+	}
+	if rt := d.Model().(RuntimeCreateModel).GetRuntime(); rt == nil || rt.Name != "go" {
+		t.Errorf("created runtime = %+v", rt)
+	}
+	d.Golden()
+}
+
+func TestRuntimeCreateError(t *testing.T) {
+	d := tuitest.New(t, InitRuntimeCreateModel(&RuntimeCreateModel{
+		Name:    "go",
+		Path:    "./Dockerfile",
+		Creator: fakeRuntimeCreator{err: errors.New("build arg \"VERSION\" is not declared in the Dockerfile")},
+	}))
+
+	if d.Model().(RuntimeCreateModel).GetRuntime() != nil {
+		t.Error("runtime reported despite the error")
+	}
+	d.Golden()
+}
+
+func TestRuntimeCreateCancel(t *testing.T) {
+	d := tuitest.New(t, InitRuntimeCreateModel(&RuntimeCreateModel{
+		Creator: fakeRuntimeCreator{},
+	}))
+	d.Type("./Docker")
+	d.Key(tea.KeyEsc)
+
+	if !d.Quit() {
+		t.Error("model did not quit on esc")
+	}
+	d.Golden()
+}
+
+func TestRuntimeCreateEmbeddedCancel(t *testing.T) {
+	d := tuitest.New(t, InitRuntimeCreateModel(&RuntimeCreateModel{
+		Creator:  fakeRuntimeCreator{},
+		Embedded: true,
+	}))
+	d.Key(tea.KeyEsc)
+
+	if d.Quit() {
+		t.Error("embedded wizard quit the program")
+	}
+	d.Golden()
+}
@@ -0,0 +1,45 @@
+package runtime
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	api "github.com/onpremless/go-client"
+)
+
+var createdAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+
+var testRuntimes = []api.Runtime{
+	{Id: "runtime-1", Name: "go", CreatedAt: createdAt, UpdatedAt: createdAt},
+	{Id: "runtime-2", Name: "python", CreatedAt: createdAt / 1000, UpdatedAt: createdAt},
+}
+
+type fakeRuntimeLister struct {
+	runtimes []api.Runtime
+	err      error
+}
+
+func (f fakeRuntimeLister) List() tea.Cmd {
+	return func() tea.Msg {
+		return RuntimeListResponseMsg{Resp: &RuntimeListResponse{Runtimes: f.runtimes, Err: f.err}}
+	}
+}
+
+type fakeRuntimeCreator struct {
+	err error
+}
+
+func (f fakeRuntimeCreator) Create(name string, path string) tea.Cmd {
+	return func() tea.Msg {
+		if f.err != nil {
+			return RuntimeCreateResponseMsg{Resp: &RuntimeCreateResponse{Err: f.err}}
+		}
+
+		return RuntimeCreateResponseMsg{Resp: &RuntimeCreateResponse{Runtime: &api.Runtime{
+			Id:        "runtime-3",
+			Name:      name,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}}}
+	}
+}
@@ -1,14 +1,23 @@
 package runtime
 
 import (
-	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	api "github.com/onpremless/go-client"
+	"github.com/onpremless/opcli/ops"
+	"github.com/onpremless/opcli/tui/listing"
 )
 
+var RuntimeColumns = []listing.Column{
+	{Key: "id", Title: "ID"},
+	{Key: "name", Title: "Name"},
+	{Key: "created", Title: "Created"},
+}
+
 type RuntimeListResponseMsg struct {
 	Resp *RuntimeListResponse
 }
@@ -24,9 +33,16 @@ type RuntimeLister interface {
 
 type RuntimeListModel struct {
 	Lister RuntimeLister
+	Filter listing.Filter
+	Sort   listing.Sort
+	// Plain renders a non-interactive table and quits, used when stdout is
+	// not a terminal
+	Plain bool
 
 	resp *RuntimeListResponse
+	rows []table.Row
 
+	table          listing.TableModel
 	loadingSpinner spinner.Model
 }
 
@@ -51,7 +67,23 @@ func (m RuntimeListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case RuntimeListResponseMsg:
 		m.resp = msg.Resp
-		return m, tea.Quit
+		if m.resp.Err != nil {
+			return m, tea.Quit
+		}
+
+		m.rows = RuntimeRows(m.resp.Runtimes)
+		if m.Plain {
+			return m, tea.Quit
+		}
+
+		m.table = listing.NewTableModel(RuntimeColumns, m.rows, m.Filter, m.Sort)
+		return m, nil
+	}
+
+	if m.rows != nil {
+		var cmd tea.Cmd
+		m.table, cmd = m.table.Update(msg)
+		return m, cmd
 	}
 
 	var cmd tea.Cmd
@@ -65,9 +97,30 @@ func (m RuntimeListModel) View() string {
 	}
 
 	if m.resp.Err != nil {
-		return fmt.Sprintf("Failed to create runtime: %s\n", m.resp.Err)
-	} else {
-		j, _ := json.MarshalIndent(m.resp.Runtimes, "", "  ")
-		return string(j) + "\n"
+		return fmt.Sprintf("Failed to list runtimes: %s\n", m.resp.Err)
 	}
+
+	if m.Plain {
+		return listing.Plain(RuntimeColumns, listing.Apply(RuntimeColumns, m.rows, m.Filter, m.Sort))
+	}
+
+	return m.table.View()
+}
+
+func RuntimeRows(runtimes []api.Runtime) []table.Row {
+	rows := make([]table.Row, len(runtimes))
+	for i, rt := range runtimes {
+		rows[i] = table.Row{rt.Id, rt.Name, FormatTimestamp(rt.CreatedAt)}
+	}
+
+	return rows
+}
+
+// FormatTimestamp renders a server timestamp, see ops.Timestamp.
+func FormatTimestamp(ts int64) string {
+	if ts == 0 {
+		return ""
+	}
+
+	return ops.Timestamp(ts).Format(time.DateTime)
 }
@@ -0,0 +1,55 @@
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/onpremless/opcli/tui/listing"
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+func TestRuntimeListSuccess(t *testing.T) {
+	d := tuitest.New(t, InitRuntimeListModel(&RuntimeListModel{
+		Lister: fakeRuntimeLister{runtimes: testRuntimes},
+	}))
+	d.Send(tea.WindowSizeMsg{Width: 80, Height: 12})
+	d.Type("q")
+
+	d.Golden()
+}
+
+func TestRuntimeListFiltered(t *testing.T) {
+	filter, err := listing.ParseFilter("name=python")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := tuitest.New(t, InitRuntimeListModel(&RuntimeListModel{
+		Lister: fakeRuntimeLister{runtimes: testRuntimes},
+		Filter: filter,
+		Plain:  true,
+	}))
+
+	d.Golden()
+}
+
+func TestRuntimeListEmpty(t *testing.T) {
+	d := tuitest.New(t, InitRuntimeListModel(&RuntimeListModel{
+		Lister: fakeRuntimeLister{},
+		Plain:  true,
+	}))
+
+	d.Golden()
+}
+
+func TestRuntimeListError(t *testing.T) {
+	d := tuitest.New(t, InitRuntimeListModel(&RuntimeListModel{
+		Lister: fakeRuntimeLister{err: errors.New("connection refused")},
+	}))
+
+	if !d.Quit() {
+		t.Error("model did not quit on error")
+	}
+	d.Golden()
+}
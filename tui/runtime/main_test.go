@@ -0,0 +1,11 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/onpremless/opcli/tui/tuitest"
+)
+
+func TestMain(m *testing.M) {
+	tuitest.Main(m)
+}
@@ -0,0 +1,80 @@
+package scaffold
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/onpremless/opcli/templates"
+)
+
+var docStyle = lipgloss.NewStyle().Margin(1, 2)
+
+type templateItem struct {
+	Template templates.Template
+}
+
+func (i templateItem) Title() string       { return i.Template.Name }
+func (i templateItem) Description() string { return i.Template.Description }
+func (i templateItem) FilterValue() string { return i.Template.Name }
+
+// TemplatePickerModel lets the user choose one of the built-in templates.
+type TemplatePickerModel struct {
+	Title     string
+	Templates []templates.Template
+
+	selected *templates.Template
+
+	templateList list.Model
+}
+
+func InitTemplatePickerModel(m *TemplatePickerModel) *TemplatePickerModel {
+	items := make([]list.Item, len(m.Templates))
+	for i, t := range m.Templates {
+		items[i] = templateItem{t}
+	}
+
+	m.templateList = list.New(items, list.NewDefaultDelegate(), 0, 0)
+	m.templateList.Title = m.Title
+	m.templateList.SetFilteringEnabled(false)
+
+	return m
+}
+
+func (m TemplatePickerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m TemplatePickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, tea.Quit
+		case tea.KeyEnter:
+			if item, ok := m.templateList.SelectedItem().(templateItem); ok {
+				m.selected = &item.Template
+			}
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		x, y := docStyle.GetFrameSize()
+		m.templateList.SetSize(msg.Width-x, msg.Height-y)
+	}
+
+	var cmd tea.Cmd
+	m.templateList, cmd = m.templateList.Update(msg)
+	return m, cmd
+}
+
+func (m TemplatePickerModel) View() string {
+	if m.selected != nil {
+		return ""
+	}
+
+	return docStyle.Render(m.templateList.View())
+}
+
+// GetTemplate returns the chosen template, nil if the picker was cancelled.
+func (m TemplatePickerModel) GetTemplate() *templates.Template {
+	return m.selected
+}
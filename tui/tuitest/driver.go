@@ -0,0 +1,220 @@
+// Package tuitest drives Bubble Tea models without a terminal and compares
+// the frames they render to golden files.
+//
+// Commands returned by the model are run and the messages they produce fed
+// back, except for ticks, such as spinner and cursor ticks, which are
+// dropped. Commands are told apart as ticks by their code, see MarkTick.
+// Fakes of the model dependencies may take their time, they are waited
+// for.
+//
+// Packages using the driver run their tests through Main, so that times are
+// rendered in UTC.
+package tuitest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// ticks holds the code pointers of the commands producing messages on a
+// timer.
+var ticks = map[uintptr]bool{}
+
+func init() {
+	MarkTick(tea.Tick(time.Second, nil))
+	MarkTick(tea.Every(time.Second, nil))
+
+	c := cursor.New()
+	c.SetMode(cursor.CursorBlink)
+	MarkTick(c.BlinkCmd())
+}
+
+// MarkTick marks every command sharing the code of cmd, such as every
+// command returned by tea.Tick, as a tick the driver drops. It is not safe
+// to call while models are driven.
+func MarkTick(cmd tea.Cmd) {
+	ticks[reflect.ValueOf(cmd).Pointer()] = true
+}
+
+// Main runs the tests of m with the local time zone set to UTC, so that
+// frames are reproducible. It must be called from TestMain, before any
+// use of the local time zone.
+func Main(m *testing.M) {
+	os.Setenv("TZ", "UTC")
+	os.Exit(m.Run())
+}
+
+type frame struct {
+	label string
+	view  string
+}
+
+type Driver struct {
+	t      *testing.T
+	model  tea.Model
+	quit   bool
+	frames []frame
+}
+
+// New initializes the model and records its first frame. Colors are
+// disabled so that frames are reproducible.
+func New(t *testing.T, m tea.Model) *Driver {
+	t.Helper()
+
+	lipgloss.SetColorProfile(termenv.Ascii)
+
+	d := &Driver{t: t, model: m}
+	d.process(nil, m.Init())
+	d.record("init")
+
+	return d
+}
+
+// Send feeds msg to the model along with the messages of the commands it
+// returns, then records the frame.
+func (d *Driver) Send(msg tea.Msg) *Driver {
+	d.t.Helper()
+
+	if d.quit {
+		d.t.Fatalf("sending %s to a model that has quit", describe(msg))
+	}
+
+	d.process([]tea.Msg{msg}, nil)
+	d.record(describe(msg))
+
+	return d
+}
+
+// Type sends s as typed runes.
+func (d *Driver) Type(s string) *Driver {
+	d.t.Helper()
+
+	return d.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+}
+
+// Key sends a special key such as tea.KeyEnter.
+func (d *Driver) Key(k tea.KeyType) *Driver {
+	d.t.Helper()
+
+	return d.Send(tea.KeyMsg{Type: k})
+}
+
+// Quit reports whether the model has asked to quit.
+func (d *Driver) Quit() bool {
+	return d.quit
+}
+
+func (d *Driver) Model() tea.Model {
+	return d.model
+}
+
+// View returns the last recorded frame.
+func (d *Driver) View() string {
+	return d.frames[len(d.frames)-1].view
+}
+
+// Golden compares the recorded frames to testdata/<test name>.golden,
+// rewriting it when the tests run with -update.
+func (d *Driver) Golden() {
+	d.t.Helper()
+
+	var b strings.Builder
+	for i, f := range d.frames {
+		fmt.Fprintf(&b, "--- frame %d: %s\n%s\n", i, f.label, f.view)
+	}
+	if d.quit {
+		b.WriteString("--- quit\n")
+	}
+	got := b.String()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(d.t.Name())
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			d.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			d.t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		d.t.Fatalf("%s, run the tests with -update to create it", err)
+	}
+	if got != string(want) {
+		d.t.Errorf("frames differ from %s, run the tests with -update to accept them\n--- got\n%s", path, got)
+	}
+}
+
+// process updates the model with every message in order, queueing the
+// messages of the commands it returns, until none is left or the model
+// quits.
+func (d *Driver) process(queue []tea.Msg, cmd tea.Cmd) {
+	queue = append(queue, run(cmd)...)
+
+	for len(queue) > 0 && !d.quit {
+		msg := queue[0]
+		queue = queue[1:]
+
+		if _, ok := msg.(tea.QuitMsg); ok {
+			d.quit = true
+			return
+		}
+
+		var cmd tea.Cmd
+		d.model, cmd = d.model.Update(msg)
+		queue = append(queue, run(cmd)...)
+	}
+}
+
+func (d *Driver) record(label string) {
+	d.frames = append(d.frames, frame{label: label, view: d.model.View()})
+}
+
+// run returns the messages cmd produces, flattening batches while keeping
+// their order. Ticks are dropped.
+func run(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil || ticks[reflect.ValueOf(cmd).Pointer()] {
+		return nil
+	}
+
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		if msg == nil {
+			return nil
+		}
+		return []tea.Msg{msg}
+	}
+
+	msgs := []tea.Msg{}
+	for _, c := range batch {
+		msgs = append(msgs, run(c)...)
+	}
+
+	return msgs
+}
+
+func describe(msg tea.Msg) string {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		return fmt.Sprintf("key %q", k.String())
+	}
+
+	return fmt.Sprintf("%T", msg)
+}
@@ -0,0 +1,50 @@
+package tuitest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type doneMsg struct{}
+
+// slowModel waits for a slow response while its spinner ticks.
+type slowModel struct {
+	spinner spinner.Model
+	done    bool
+}
+
+func (m slowModel) Init() tea.Cmd {
+	slow := func() tea.Msg {
+		time.Sleep(100 * time.Millisecond)
+		return doneMsg{}
+	}
+
+	return tea.Batch(m.spinner.Tick, slow)
+}
+
+func (m slowModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case doneMsg:
+		m.done = true
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+func (m slowModel) View() string {
+	return ""
+}
+
+func TestRunWaitsForSlowCommandsAndDropsTicks(t *testing.T) {
+	d := New(t, slowModel{spinner: spinner.New()})
+
+	if !d.Model().(slowModel).done || !d.Quit() {
+		t.Error("slow response dropped")
+	}
+}
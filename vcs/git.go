@@ -0,0 +1,124 @@
+// Package vcs inspects the git working tree lambda sources live in, through
+// the git binary.
+package vcs
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+	}
+
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// TopLevel returns the root of the working tree dir belongs to.
+func TopLevel(dir string) (string, error) {
+	return git(dir, "rev-parse", "--show-toplevel")
+}
+
+// ChangedFiles lists the absolute paths of the files of the working tree
+// holding dir that differ from ref, including uncommitted and untracked
+// files.
+func ChangedFiles(dir string, ref string) ([]string, error) {
+	top, err := TopLevel(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := git(top, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown git revision: %s", ref)
+	}
+
+	// -z keeps the names unquoted, like ones holding non-ASCII characters
+	diff, err := git(top, "diff", "--name-only", "-z", "--no-renames", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := git(top, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, out := range []string{diff, untracked} {
+		for _, name := range strings.Split(out, "\x00") {
+			if name != "" {
+				files = append(files, filepath.Join(top, filepath.FromSlash(name)))
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// Info is the state of the working tree a lambda has been deployed from.
+type Info struct {
+	Commit string `json:"commit"`
+	Branch string `json:"branch,omitempty"`
+	// Dirty is set when the sources directory has uncommitted changes
+	Dirty  bool   `json:"dirty"`
+	Author string `json:"author,omitempty"`
+}
+
+// Short renders the commit abbreviated along with the branch, marking
+// dirty trees with a "*".
+func (i Info) Short() string {
+	s := i.Commit
+	if len(s) > 7 {
+		s = s[:7]
+	}
+	if i.Dirty {
+		s += "*"
+	}
+	if i.Branch != "" {
+		s += " (" + i.Branch + ")"
+	}
+
+	return s
+}
+
+// Describe returns the commit checked out in the working tree holding dir,
+// its branch and author, and whether dir has uncommitted changes.
+func Describe(dir string) (*Info, error) {
+	commit, err := git(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{Commit: commit}
+
+	// A detached HEAD has no branch
+	if branch, err := git(dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		info.Branch = branch
+	}
+
+	info.Author, err = git(dir, "log", "-1", "--format=%an <%ae>")
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := git(dir, "status", "--porcelain", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	info.Dirty = status != ""
+
+	return info, nil
+}